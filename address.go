@@ -0,0 +1,323 @@
+package bitgo
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Address errors are wrapped in AddressError.
+var (
+	// ErrAddressFormat means the address is malformed, e.g., has a typo which broke its encoding.
+	ErrAddressFormat = errors.New("bitgo: malformed address")
+	// ErrAddressChecksum means the address checksum doesn't match, usually because of a typo.
+	ErrAddressChecksum = errors.New("bitgo: address checksum mismatch")
+	// ErrAddressNetwork means the address is well-formed, but belongs to another coin or network,
+	// e.g., a testnet address was given for a mainnet coin.
+	ErrAddressNetwork = errors.New("bitgo: address belongs to another network")
+)
+
+// AddressError is returned when an address can't be used with a coin.
+type AddressError struct {
+	// Address is the invalid address.
+	Address string
+	// Coin is a ticker of the coin the address was checked against.
+	Coin string
+	// Err is ErrAddressFormat, ErrAddressChecksum, or ErrAddressNetwork.
+	Err error
+}
+
+func (e *AddressError) Error() string {
+	return fmt.Sprintf("bitgo: invalid %s address %q: %v", e.Coin, e.Address, e.Err)
+}
+
+// Unwrap returns the underlying error, so errors.Is(err, ErrAddressNetwork) works.
+func (e *AddressError) Unwrap() error {
+	return e.Err
+}
+
+// AddressType is a type of the output script an address pays to.
+type AddressType string
+
+// Address types.
+const (
+	// AddressP2PKH pays to a public key hash.
+	AddressP2PKH AddressType = "p2pkh"
+	// AddressP2SH pays to a script hash, e.g., a multisig redeem script of a BitGo wallet.
+	AddressP2SH AddressType = "p2sh"
+	// AddressP2WPKH pays to a witness public key hash (segwit v0, 20-byte program).
+	AddressP2WPKH AddressType = "p2wpkh"
+	// AddressP2WSH pays to a witness script hash (segwit v0, 32-byte program).
+	AddressP2WSH AddressType = "p2wsh"
+	// AddressP2TR pays to a taproot output key (segwit v1, 32-byte program).
+	AddressP2TR AddressType = "p2tr"
+	// AddressWitnessUnknown pays to a witness program of a future segwit version.
+	AddressWitnessUnknown AddressType = "witness_unknown"
+	// AddressAccount is an account of an account based coin.
+	AddressAccount AddressType = "account"
+)
+
+// Address is a parsed address of a coin.
+type Address struct {
+	// Coin is a ticker of the coin the address belongs to.
+	Coin string
+	// Format is the encoding the address was parsed from.
+	Format AddressFormat
+	// Type is the type of the output script the address pays to.
+	Type AddressType
+	// WitnessVersion is a segwit version of bech32 and bech32m addresses.
+	WitnessVersion int
+	// Hash is a public key hash, script hash, witness program, or account ID.
+	Hash []byte
+}
+
+// String returns the address in its original format,
+// bech32 addresses are in lowercase and cashaddrs have the prefix.
+func (a *Address) String() string {
+	s, err := a.Encode(a.Format)
+	if err != nil {
+		return ""
+	}
+	return s
+}
+
+// Encode returns the address in the format.
+// It allows to convert BCH legacy addresses to cashaddr and back, for example,
+//
+//	a, err := bitgo.ParseAddress("bch", "1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu")
+//	cashaddr, err := a.Encode(bitgo.AddressCashAddr)
+//	// bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a
+func (a *Address) Encode(f AddressFormat) (string, error) {
+	n, ok := addressNetworks[a.Coin]
+	if !ok {
+		return "", fmt.Errorf("%w: %s address encoding", ErrUnsupportedByCoin, a.Coin)
+	}
+
+	switch {
+	case f == AddressBase58Check && (a.Type == AddressP2PKH || a.Type == AddressP2SH) && len(n.p2pkh) > 0:
+		version := n.p2pkh
+		if a.Type == AddressP2SH {
+			version = n.p2sh[0]
+		}
+		return base58CheckEncode(append(append([]byte(nil), version...), a.Hash...)), nil
+	case f == AddressBase58Check && a.Type == AddressAccount && n.account != nil:
+		return base58CheckEncode(append(append([]byte(nil), n.account...), a.Hash...)), nil
+	case f == AddressCashAddr && (a.Type == AddressP2PKH || a.Type == AddressP2SH) && n.cashAddrPrefix != "":
+		version := cashAddrP2PKH
+		if a.Type == AddressP2SH {
+			version = cashAddrP2SH
+		}
+		return cashAddrEncode(n.cashAddrPrefix, version, a.Hash), nil
+	case (f == AddressBech32 && a.WitnessVersion == 0 || f == AddressBech32m && a.WitnessVersion > 0) && n.hrp != "" && a.isWitness():
+		return segwitEncode(n.hrp, a.WitnessVersion, a.Hash), nil
+	case f == AddressHex && a.Type == AddressAccount && n.hex:
+		return "0x" + hex.EncodeToString(a.Hash), nil
+	}
+	return "", fmt.Errorf("%w: %s %s address in %s format", ErrUnsupportedByCoin, a.Coin, a.Type, f)
+}
+
+// isWitness reports whether the address pays to a witness program.
+func (a *Address) isWitness() bool {
+	switch a.Type {
+	case AddressP2WPKH, AddressP2WSH, AddressP2TR, AddressWitnessUnknown:
+		return true
+	}
+	return false
+}
+
+// addressNetwork describes how addresses of a coin are encoded.
+type addressNetwork struct {
+	// p2pkh is a base58check version prefix of P2PKH addresses.
+	p2pkh []byte
+	// p2sh are base58check version prefixes of P2SH addresses, the first one is preferred.
+	p2sh [][]byte
+	// account is a base58check version prefix of account addresses (trx).
+	account []byte
+	// hrp is a human readable part of segwit addresses.
+	hrp string
+	// cashAddrPrefix is a prefix of cashaddrs.
+	cashAddrPrefix string
+	// hex is true for 0x-prefixed hex account addresses (eth).
+	hex bool
+}
+
+// addressNetworks describe address encodings of the coins in the registry.
+// Coins which aren't here (xrp, xlm, eos, algo) don't support local address validation.
+var addressNetworks = map[string]addressNetwork{
+	"btc":   {p2pkh: []byte{0x00}, p2sh: [][]byte{{0x05}}, hrp: "bc"},
+	"tbtc":  {p2pkh: []byte{0x6f}, p2sh: [][]byte{{0xc4}}, hrp: "tb"},
+	"bch":   {p2pkh: []byte{0x00}, p2sh: [][]byte{{0x05}}, cashAddrPrefix: "bitcoincash"},
+	"tbch":  {p2pkh: []byte{0x6f}, p2sh: [][]byte{{0xc4}}, cashAddrPrefix: "bchtest"},
+	"bsv":   {p2pkh: []byte{0x00}, p2sh: [][]byte{{0x05}}},
+	"tbsv":  {p2pkh: []byte{0x6f}, p2sh: [][]byte{{0xc4}}},
+	"btg":   {p2pkh: []byte{0x26}, p2sh: [][]byte{{0x17}}, hrp: "btg"},
+	"tbtg":  {p2pkh: []byte{0x6f}, p2sh: [][]byte{{0xc4}}, hrp: "tbtg"},
+	"ltc":   {p2pkh: []byte{0x30}, p2sh: [][]byte{{0x32}, {0x05}}, hrp: "ltc"},
+	"tltc":  {p2pkh: []byte{0x6f}, p2sh: [][]byte{{0x3a}, {0xc4}}, hrp: "tltc"},
+	"zec":   {p2pkh: []byte{0x1c, 0xb8}, p2sh: [][]byte{{0x1c, 0xbd}}},
+	"tzec":  {p2pkh: []byte{0x1d, 0x25}, p2sh: [][]byte{{0x1c, 0xba}}},
+	"dash":  {p2pkh: []byte{0x4c}, p2sh: [][]byte{{0x10}}},
+	"tdash": {p2pkh: []byte{0x8c}, p2sh: [][]byte{{0x13}}},
+	"trx":   {account: []byte{0x41}},
+	"ttrx":  {account: []byte{0x41}},
+	"eth":   {hex: true},
+	"hteth": {hex: true},
+}
+
+// ParseAddress parses an address of the coin, e.g., "btc" or "tbch",
+// checking its checksum and that it belongs to the coin's network.
+// Supported formats are base58check (P2PKH, P2SH), bech32 and bech32m (P2WPKH, P2WSH, P2TR),
+// BCH cashaddr, and 0x-prefixed hex (eth, the mixed-case checksum is not verified).
+// Invalid addresses are reported with AddressError.
+func ParseAddress(coin, address string) (*Address, error) {
+	ci, ok := LookupCoin(coin)
+	if !ok {
+		return nil, fmt.Errorf("bitgo: unknown coin %q", coin)
+	}
+	return parseAddress(ci, address)
+}
+
+// ParseAddress parses an address of the client's coin, see ParseAddress function.
+func (c *Client) ParseAddress(address string) (*Address, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return parseAddress(c.config.coinInfo, address)
+}
+
+// parseAddress parses an address of the coin.
+func parseAddress(ci CoinInfo, address string) (*Address, error) {
+	n, ok := addressNetworks[ci.Ticker]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s address validation", ErrUnsupportedByCoin, ci.Ticker)
+	}
+	a, err := n.parse(address)
+	if err != nil {
+		return nil, &AddressError{Address: address, Coin: ci.Ticker, Err: err}
+	}
+	a.Coin = ci.Ticker
+	return a, nil
+}
+
+// parse parses an address trying the encodings of the network.
+func (n addressNetwork) parse(s string) (*Address, error) {
+	if n.hex {
+		return parseHexAddress(s)
+	}
+	if n.hrp != "" {
+		a, err := n.parseSegwit(s)
+		// Base58 addresses don't have the segwit prefix, e.g., "bc1".
+		if err == nil || err == ErrAddressNetwork || strings.HasPrefix(strings.ToLower(s), n.hrp+"1") {
+			return a, err
+		}
+	}
+	if n.cashAddrPrefix != "" {
+		a, err := n.parseCashAddr(s)
+		// Cashaddrs can be given without the prefix, so legacy addresses are tried next.
+		if err == nil || strings.Contains(s, ":") {
+			return a, err
+		}
+	}
+	a, err := n.parseBase58(s)
+	if err != nil && n.hrp == "" {
+		// A segwit address was given for a coin without segwit.
+		if _, _, _, segwitErr := segwitDecode(s); segwitErr == nil {
+			return nil, ErrAddressNetwork
+		}
+	}
+	return a, err
+}
+
+// parseSegwit parses bech32 and bech32m addresses.
+func (n addressNetwork) parseSegwit(s string) (*Address, error) {
+	hrp, version, program, err := segwitDecode(s)
+	if err != nil {
+		return nil, err
+	}
+	if hrp != n.hrp {
+		return nil, ErrAddressNetwork
+	}
+
+	a := Address{Format: AddressBech32, WitnessVersion: version, Hash: program, Type: AddressWitnessUnknown}
+	if version > 0 {
+		a.Format = AddressBech32m
+	}
+	switch {
+	case version == 0 && len(program) == 20:
+		a.Type = AddressP2WPKH
+	case version == 0 && len(program) == 32:
+		a.Type = AddressP2WSH
+	case version == 1 && len(program) == 32:
+		a.Type = AddressP2TR
+	}
+	return &a, nil
+}
+
+// parseCashAddr parses a cashaddr with or without the prefix.
+func (n addressNetwork) parseCashAddr(s string) (*Address, error) {
+	prefix, version, hash, err := cashAddrDecode(s, n.cashAddrPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if prefix != n.cashAddrPrefix {
+		return nil, ErrAddressNetwork
+	}
+
+	a := Address{Format: AddressCashAddr, Hash: hash}
+	switch version {
+	case cashAddrP2PKH:
+		a.Type = AddressP2PKH
+	case cashAddrP2SH:
+		a.Type = AddressP2SH
+	default:
+		return nil, ErrAddressFormat
+	}
+	return &a, nil
+}
+
+// parseBase58 parses a base58check address.
+func (n addressNetwork) parseBase58(s string) (*Address, error) {
+	payload, err := base58CheckDecode(s)
+	if err != nil {
+		return nil, err
+	}
+
+	a := Address{Format: AddressBase58Check}
+	switch {
+	case n.account != nil && bytes.HasPrefix(payload, n.account):
+		a.Type = AddressAccount
+		a.Hash = payload[len(n.account):]
+	case n.p2pkh != nil && bytes.HasPrefix(payload, n.p2pkh):
+		a.Type = AddressP2PKH
+		a.Hash = payload[len(n.p2pkh):]
+	default:
+		for _, v := range n.p2sh {
+			if bytes.HasPrefix(payload, v) {
+				a.Type = AddressP2SH
+				a.Hash = payload[len(v):]
+				break
+			}
+		}
+	}
+	if a.Type == "" {
+		return nil, ErrAddressNetwork
+	}
+	if len(a.Hash) != 20 {
+		return nil, ErrAddressFormat
+	}
+	return &a, nil
+}
+
+// parseHexAddress parses a 0x-prefixed 20-byte hex address.
+func parseHexAddress(s string) (*Address, error) {
+	if len(s) != 42 || !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+		return nil, ErrAddressFormat
+	}
+	b, err := hex.DecodeString(s[2:])
+	if err != nil {
+		return nil, ErrAddressFormat
+	}
+	return &Address{Format: AddressHex, Type: AddressAccount, Hash: b}, nil
+}
@@ -0,0 +1,127 @@
+package bitgo_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/marselester/bitgo-v2"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		coin     string
+		address  string
+		wantType bitgo.AddressType
+		wantHash string
+	}{
+		{"btc", "1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu", bitgo.AddressP2PKH, "76a04053bda0a88bda5177b86a15c3b29f559873"},
+		{"btc", "3CWFddi6m4ndiGyKqzYvsFYagqDLPVMTzC", bitgo.AddressP2SH, "76a04053bda0a88bda5177b86a15c3b29f559873"},
+		{"btc", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", bitgo.AddressP2WPKH, "751e76e8199196d454941c45d1b3a323f1433bd6"},
+		{"tbtc", "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", bitgo.AddressP2WSH, "1863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262"},
+		{"btc", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", bitgo.AddressP2TR, "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"},
+		{"bch", "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", bitgo.AddressP2PKH, "76a04053bda0a88bda5177b86a15c3b29f559873"},
+		{"bch", "qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", bitgo.AddressP2PKH, "76a04053bda0a88bda5177b86a15c3b29f559873"},
+		{"bch", "bitcoincash:ppm2qsznhks23z7629mms6s4cwef74vcwvn0h829pq", bitgo.AddressP2SH, "76a04053bda0a88bda5177b86a15c3b29f559873"},
+		{"bch", "1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu", bitgo.AddressP2PKH, "76a04053bda0a88bda5177b86a15c3b29f559873"},
+		{"eth", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", bitgo.AddressAccount, "5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"},
+	}
+	for _, test := range tests {
+		a, err := bitgo.ParseAddress(test.coin, test.address)
+		if err != nil {
+			t.Errorf("%s %s: %v", test.coin, test.address, err)
+			continue
+		}
+		if a.Type != test.wantType || hex.EncodeToString(a.Hash) != test.wantHash {
+			t.Errorf("%s %s: unexpected %s %x", test.coin, test.address, a.Type, a.Hash)
+		}
+	}
+}
+
+func TestParseAddressError(t *testing.T) {
+	tests := []struct {
+		coin    string
+		address string
+		want    error
+	}{
+		{"btc", "1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggv", bitgo.ErrAddressChecksum},
+		{"btc", "1BpEi6DfDAUFd7GtittLSdBeYJvcoaVgg0", bitgo.ErrAddressFormat},
+		{"btc", "mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn", bitgo.ErrAddressNetwork},
+		{"tbtc", "1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu", bitgo.ErrAddressNetwork},
+		{"btc", "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", bitgo.ErrAddressNetwork},
+		{"btc", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", bitgo.ErrAddressChecksum},
+		{"btc", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kV8f3t4", bitgo.ErrAddressFormat},
+		// Segwit v1 address with bech32 instead of bech32m checksum.
+		{"btc", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd", bitgo.ErrAddressChecksum},
+		{"bch", "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6c", bitgo.ErrAddressChecksum},
+		{"bch", "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6b", bitgo.ErrAddressFormat},
+		{"bch", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", bitgo.ErrAddressNetwork},
+		{"eth", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA", bitgo.ErrAddressFormat},
+	}
+	for _, test := range tests {
+		_, err := bitgo.ParseAddress(test.coin, test.address)
+		if !errors.Is(err, test.want) {
+			t.Errorf("%s %s: expected %v, got %v", test.coin, test.address, test.want, err)
+		}
+		var aerr *bitgo.AddressError
+		if !errors.As(err, &aerr) || aerr.Coin != test.coin {
+			t.Errorf("%s %s: expected AddressError, got %#v", test.coin, test.address, err)
+		}
+	}
+
+	// Testnet cashaddr is converted from a testnet legacy address.
+	a, err := bitgo.ParseAddress("tbch", "mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testnet, err := a.Encode(bitgo.AddressCashAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = bitgo.ParseAddress("bch", testnet); !errors.Is(err, bitgo.ErrAddressNetwork) {
+		t.Errorf("%s: expected %v, got %v", testnet, bitgo.ErrAddressNetwork, err)
+	}
+
+	if _, err := bitgo.ParseAddress("xrp", "rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh"); !errors.Is(err, bitgo.ErrUnsupportedByCoin) {
+		t.Errorf("expected unsupported xrp address validation, got %v", err)
+	}
+}
+
+func TestAddressEncode(t *testing.T) {
+	tests := []struct {
+		legacy   string
+		cashaddr string
+	}{
+		{"1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu", "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a"},
+		{"1KXrWXciRDZUpQwQmuM1DbwsKDLYAYsVLR", "bitcoincash:qr95sy3j9xwd2ap32xkykttr4cvcu7as4y0qverfuy"},
+		{"16w1D5WRVKJuZUsSRzdLp9w3YGcgoxDXb", "bitcoincash:qqq3728yw0y47sqn6l2na30mcw6zm78dzqre909m2r"},
+		{"3CWFddi6m4ndiGyKqzYvsFYagqDLPVMTzC", "bitcoincash:ppm2qsznhks23z7629mms6s4cwef74vcwvn0h829pq"},
+	}
+	for _, test := range tests {
+		a, err := bitgo.ParseAddress("bch", test.legacy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := a.Encode(bitgo.AddressCashAddr); got != test.cashaddr || err != nil {
+			t.Errorf("%s: expected %s, got %s %v", test.legacy, test.cashaddr, got, err)
+		}
+
+		if a, err = bitgo.ParseAddress("bch", test.cashaddr); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := a.Encode(bitgo.AddressBase58Check); got != test.legacy || err != nil {
+			t.Errorf("%s: expected %s, got %s %v", test.cashaddr, test.legacy, got, err)
+		}
+	}
+
+	a, err := bitgo.ParseAddress("btc", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.String() != "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4" {
+		t.Errorf("expected lowercase segwit address, got %s", a)
+	}
+	if _, err = a.Encode(bitgo.AddressCashAddr); !errors.Is(err, bitgo.ErrUnsupportedByCoin) {
+		t.Errorf("segwit address can't be a cashaddr, got %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+package bitgo
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Amount is an exact amount of coins in base units, e.g., satoshis for BTC or wei for ETH.
+// Amounts that fit into int64 are kept without allocations,
+// larger ones (18-decimal account coins) are backed by big.Int.
+// The zero value is zero base units.
+//
+//	a, err := bitgo.ParseAmount("0.29", 8)
+//	fmt.Println(a)           // 29000000
+//	fmt.Println(a.Format(8)) // 0.29000000
+type Amount struct {
+	// n is the amount when b is nil.
+	n int64
+	// b is set only when the amount doesn't fit into int64.
+	b *big.Int
+}
+
+// NewAmount returns an amount of n base units.
+func NewAmount(n int64) Amount {
+	return Amount{n: n}
+}
+
+// NewAmountFromBig returns an amount of b base units. The b is copied.
+func NewAmountFromBig(b *big.Int) Amount {
+	if b.IsInt64() {
+		return Amount{n: b.Int64()}
+	}
+	return Amount{b: new(big.Int).Set(b)}
+}
+
+// ParseBaseAmount parses a whole number of base units, e.g., "29000000" satoshis.
+func ParseBaseAmount(s string) (Amount, error) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return Amount{n: n}, nil
+	}
+	if !isInteger(s) {
+		return Amount{}, fmt.Errorf("bitgo: invalid amount %q", s)
+	}
+	b, _ := new(big.Int).SetString(s, 10)
+	return NewAmountFromBig(b), nil
+}
+
+// ParseAmount parses a decimal amount in display units (e.g., "0.29" BTC)
+// into base units given the number of decimal places of the coin, see CoinDecimals.
+// Unlike float conversion the parsing is exact, and it fails
+// when the amount has more fractional digits than the coin's decimals.
+func ParseAmount(s string, decimals int) (Amount, error) {
+	if decimals < 0 {
+		return Amount{}, fmt.Errorf("bitgo: invalid number of decimals %d", decimals)
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+	sign := ""
+	if strings.HasPrefix(whole, "-") || strings.HasPrefix(whole, "+") {
+		sign, whole = whole[:1], whole[1:]
+	}
+	if whole == "" && frac == "" || whole != "" && !isDigits(whole) || frac != "" && !isDigits(frac) {
+		return Amount{}, fmt.Errorf("bitgo: invalid amount %q", s)
+	}
+
+	frac = strings.TrimRight(frac, "0")
+	if len(frac) > decimals {
+		return Amount{}, fmt.Errorf("bitgo: amount %q has more than %d decimal places", s, decimals)
+	}
+	digits := sign + whole + frac + strings.Repeat("0", decimals-len(frac))
+	return ParseBaseAmount(digits)
+}
+
+// isDigits reports whether s consists of decimal digits only.
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// isInteger reports whether s is a decimal integer with an optional sign.
+func isInteger(s string) bool {
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+	return isDigits(s)
+}
+
+// Int64 returns the amount in base units.
+// The second result is false if the amount doesn't fit into int64.
+func (a Amount) Int64() (int64, bool) {
+	if a.b != nil {
+		return 0, false
+	}
+	return a.n, true
+}
+
+// BigInt returns the amount in base units as a new big.Int.
+func (a Amount) BigInt() *big.Int {
+	if a.b != nil {
+		return new(big.Int).Set(a.b)
+	}
+	return big.NewInt(a.n)
+}
+
+// IsZero reports whether the amount is zero, so the amount can be omitted from JSON with omitzero tag (Go 1.24+).
+func (a Amount) IsZero() bool {
+	return a.b == nil && a.n == 0
+}
+
+// Sign returns -1, 0, or +1 depending on the sign of the amount.
+func (a Amount) Sign() int {
+	switch {
+	case a.b != nil:
+		return a.b.Sign()
+	case a.n < 0:
+		return -1
+	case a.n > 0:
+		return 1
+	}
+	return 0
+}
+
+// Cmp compares the amounts and returns -1 if a < x, 0 if a == x, and +1 if a > x.
+func (a Amount) Cmp(x Amount) int {
+	if a.b == nil && x.b == nil {
+		switch {
+		case a.n < x.n:
+			return -1
+		case a.n > x.n:
+			return 1
+		}
+		return 0
+	}
+	return a.BigInt().Cmp(x.BigInt())
+}
+
+// Add returns a + x.
+func (a Amount) Add(x Amount) Amount {
+	if a.b == nil && x.b == nil {
+		sum := a.n + x.n
+		// The sum overflows only when both operands have the same sign which the sum doesn't have.
+		if (sum > a.n) == (x.n > 0) {
+			return Amount{n: sum}
+		}
+	}
+	return NewAmountFromBig(new(big.Int).Add(a.BigInt(), x.BigInt()))
+}
+
+// Sub returns a - x.
+func (a Amount) Sub(x Amount) Amount {
+	if a.b == nil && x.b == nil && x.n != math.MinInt64 {
+		return a.Add(Amount{n: -x.n})
+	}
+	return NewAmountFromBig(new(big.Int).Sub(a.BigInt(), x.BigInt()))
+}
+
+// String returns the amount in base units, e.g., "29000000".
+func (a Amount) String() string {
+	if a.b != nil {
+		return a.b.String()
+	}
+	return strconv.FormatInt(a.n, 10)
+}
+
+// Format returns the amount in display units with the given number of decimal places,
+// e.g., "0.29000000" for 29000000 satoshis and 8 decimals.
+func (a Amount) Format(decimals int) string {
+	s := a.String()
+	if decimals <= 0 {
+		return s
+	}
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	if len(s) <= decimals {
+		s = strings.Repeat("0", decimals-len(s)+1) + s
+	}
+	i := len(s) - decimals
+	return sign + s[:i] + "." + s[i:]
+}
+
+// MarshalJSON encodes the amount in base units as a JSON number if it fits into int64
+// (BitGo returns UTXO values as numbers), otherwise as a string.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	if a.b != nil {
+		return []byte(strconv.Quote(a.b.String())), nil
+	}
+	return []byte(strconv.FormatInt(a.n, 10)), nil
+}
+
+// UnmarshalJSON decodes the amount in base units from a JSON number or a string,
+// since BitGo returns account coins' values (e.g., ETH in wei) as strings.
+func (a *Amount) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	s := string(b)
+	if len(b) > 1 && b[0] == '"' {
+		var err error
+		if s, err = strconv.Unquote(s); err != nil {
+			return fmt.Errorf("bitgo: invalid amount %s", b)
+		}
+	}
+	v, err := ParseBaseAmount(s)
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
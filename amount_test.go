@@ -0,0 +1,128 @@
+package bitgo_test
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/marselester/bitgo-v2"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in       string
+		decimals int
+		want     string
+		wantErr  bool
+	}{
+		{"0.29", 8, "29000000", false},
+		{"1", 8, "100000000", false},
+		{".5", 8, "50000000", false},
+		{"0.00000001", 8, "1", false},
+		{"0.000000010", 8, "1", false},
+		{"-0.1", 8, "-10000000", false},
+		{"21000000", 8, "2100000000000000", false},
+		{"1.5", 18, "1500000000000000000", false},
+		{"123456789.123456789123456789", 18, "123456789123456789123456789", false},
+		{"0.000000001", 8, "", true},
+		{"1e-8", 8, "", true},
+		{"1.2.3", 8, "", true},
+		{"", 8, "", true},
+		{"-", 8, "", true},
+		{"0x10", 8, "", true},
+	}
+	for _, test := range tests {
+		got, err := bitgo.ParseAmount(test.in, test.decimals)
+		if (err != nil) != test.wantErr {
+			t.Errorf("ParseAmount(%q, %d) expected error %v, got %v", test.in, test.decimals, test.wantErr, err)
+			continue
+		}
+		if err == nil && got.String() != test.want {
+			t.Errorf("ParseAmount(%q, %d) = %s, want %s", test.in, test.decimals, got, test.want)
+		}
+	}
+}
+
+func TestAmountFormat(t *testing.T) {
+	tests := []struct {
+		amount   bitgo.Amount
+		decimals int
+		want     string
+	}{
+		{bitgo.NewAmount(29000000), 8, "0.29000000"},
+		{bitgo.NewAmount(1), 8, "0.00000001"},
+		{bitgo.NewAmount(203125000), 8, "2.03125000"},
+		{bitgo.NewAmount(-5), 2, "-0.05"},
+		{bitgo.NewAmount(42), 0, "42"},
+		{bitgo.Amount{}, 8, "0.00000000"},
+	}
+	for _, test := range tests {
+		if got := test.amount.Format(test.decimals); got != test.want {
+			t.Errorf("%s.Format(%d) = %s, want %s", test.amount, test.decimals, got, test.want)
+		}
+	}
+
+	wei, _ := bitgo.ParseAmount("123456789.5", 18)
+	if got := wei.Format(18); got != "123456789.500000000000000000" {
+		t.Errorf("unexpected big amount format %s", got)
+	}
+}
+
+func TestAmountArithmetic(t *testing.T) {
+	max := bitgo.NewAmount(math.MaxInt64)
+	sum := max.Add(bitgo.NewAmount(1))
+	if _, ok := sum.Int64(); ok {
+		t.Fatal("expected the sum to overflow int64")
+	}
+	if sum.String() != "9223372036854775808" {
+		t.Errorf("unexpected sum %s", sum)
+	}
+	if got := sum.Sub(bitgo.NewAmount(1)); got != max {
+		t.Errorf("expected %s, got %s", max, got)
+	}
+	if sum.Cmp(max) != 1 || max.Cmp(sum) != -1 || max.Cmp(max) != 0 {
+		t.Error("unexpected comparison")
+	}
+
+	min := bitgo.NewAmount(math.MinInt64)
+	if got := bitgo.NewAmount(0).Sub(min); got.String() != "9223372036854775808" {
+		t.Errorf("unexpected difference %s", got)
+	}
+	if got := bitgo.NewAmount(100).Sub(bitgo.NewAmount(30)); got != bitgo.NewAmount(70) {
+		t.Errorf("expected 70, got %s", got)
+	}
+	if got := bitgo.NewAmountFromBig(big.NewInt(5)); got != bitgo.NewAmount(5) {
+		t.Errorf("expected int64 amount, got %#v", got)
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	var v struct {
+		Value bitgo.Amount `json:"value"`
+		Wei   bitgo.Amount `json:"wei"`
+		Min   bitgo.Amount `json:"min,omitzero"`
+	}
+	err := json.Unmarshal([]byte(`{"value":203125000,"wei":"123456789123456789123456789"}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Value != bitgo.NewAmount(203125000) || v.Wei.String() != "123456789123456789123456789" {
+		t.Fatalf("unexpected amounts %s %s", v.Value, v.Wei)
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"value":203125000,"wei":"123456789123456789123456789"}`
+	if string(b) != want {
+		t.Errorf("expected %s, got %s", want, b)
+	}
+
+	for _, in := range []string{`{"value":1.5}`, `{"value":"abc"}`, `{"value":true}`} {
+		if err = json.Unmarshal([]byte(in), &v); err == nil {
+			t.Errorf("expected an error for %s", in)
+		}
+	}
+}
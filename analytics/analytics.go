@@ -0,0 +1,261 @@
+// Package analytics summarizes unspents of a wallet: value histogram, breakdown by chain and script type,
+// age distribution, dust and uneconomical unspents at a given fee rate.
+//
+//	a := analytics.New(analytics.Options{FeeRate: 20000})
+//	err := c.Wallet.Unspents(ctx, walletID, nil, a.AddList)
+//	report := a.Report()
+//	err = report.WriteTable(os.Stdout, 8)
+package analytics
+
+import (
+	"sort"
+	"time"
+
+	"github.com/marselester/bitgo-v2"
+)
+
+// Defaults used when Options fields are not set.
+var (
+	// DefaultBuckets are upper bounds of value histogram buckets in satoshis.
+	DefaultBuckets = []bitgo.Amount{
+		bitgo.NewAmount(1000),
+		bitgo.NewAmount(10000),
+		bitgo.NewAmount(100000),
+		bitgo.NewAmount(1000000),
+		bitgo.NewAmount(10000000),
+		bitgo.NewAmount(100000000),
+	}
+	// DefaultAgeBuckets are upper bounds of age distribution buckets.
+	DefaultAgeBuckets = []time.Duration{
+		24 * time.Hour,
+		7 * 24 * time.Hour,
+		30 * 24 * time.Hour,
+		90 * 24 * time.Hour,
+		365 * 24 * time.Hour,
+	}
+	// DefaultDustThreshold is a value in satoshis below which an unspent is dust.
+	DefaultDustThreshold = bitgo.NewAmount(546)
+)
+
+// Options configures the analysis.
+type Options struct {
+	// Buckets are ascending upper bounds (exclusive) of value histogram buckets.
+	// Values at or above the last bound fall into an extra unbounded bucket.
+	Buckets []bitgo.Amount
+	// AgeBuckets are ascending upper bounds (exclusive) of age distribution buckets.
+	// Older unspents fall into an extra unbounded bucket.
+	AgeBuckets []time.Duration
+	// DustThreshold is a value below which an unspent is counted as dust.
+	DustThreshold bitgo.Amount
+	// FeeRate is a fee rate in satoshis/KB used to find uneconomical unspents,
+	// i.e., the ones which cost more in fees to spend than they are worth.
+	// Zero means uneconomical unspents are not reported.
+	FeeRate int64
+	// Now is used to compute the age of unspents, it defaults to time.Now.
+	Now func() time.Time
+}
+
+// Stats are a number of unspents and their total value.
+type Stats struct {
+	Count int          `json:"count"`
+	Value bitgo.Amount `json:"value"`
+}
+
+// add counts the unspent's value.
+func (s *Stats) add(v bitgo.Amount) {
+	s.Count++
+	s.Value = s.Value.Add(v)
+}
+
+// ValueBucket is a bucket of the value histogram with unspents in [Min, Max) range.
+type ValueBucket struct {
+	Min bitgo.Amount `json:"min"`
+	// Max is zero for the last unbounded bucket, it's encoded as "max":0 in JSON too.
+	Max bitgo.Amount `json:"max"`
+	Stats
+}
+
+// ChainStats are stats of unspents on the chain.
+type ChainStats struct {
+	Chain      bitgo.Chain `json:"chain"`
+	ScriptType string      `json:"scriptType"`
+	Change     bool        `json:"change"`
+	Stats
+}
+
+// ScriptTypeStats are stats of unspents of the script type, e.g., "p2wsh".
+type ScriptTypeStats struct {
+	ScriptType string `json:"scriptType"`
+	Stats
+}
+
+// AgeBucket is a bucket of the age distribution with unspents created within [Min, Max) ago.
+type AgeBucket struct {
+	Min time.Duration `json:"min"`
+	// Max is zero for the last unbounded bucket, it's encoded as "max":0 in JSON too.
+	Max time.Duration `json:"max"`
+	Stats
+}
+
+// Report is a summary of the analyzed unspents.
+type Report struct {
+	// Total are all the unspents.
+	Total Stats `json:"total"`
+	// Unconfirmed are the unspents which are not in a block yet.
+	Unconfirmed Stats `json:"unconfirmed"`
+	// Histogram is a distribution of unspents by value.
+	Histogram []ValueBucket `json:"histogram"`
+	// ByChain are unspents by chain sorted by chain.
+	ByChain []ChainStats `json:"byChain"`
+	// ByScriptType are unspents by script type sorted by script type, unknown chains are reported as "unknown".
+	ByScriptType []ScriptTypeStats `json:"byScriptType"`
+	// Age is a distribution of unspents by age, unspents without date are counted in UnknownAge.
+	Age        []AgeBucket `json:"age"`
+	UnknownAge Stats       `json:"unknownAge"`
+	// Dust are unspents below the dust threshold.
+	DustThreshold bitgo.Amount `json:"dustThreshold"`
+	Dust          Stats        `json:"dust"`
+	// Uneconomical are unspents whose value doesn't cover the fee to spend them at the fee rate.
+	FeeRate      int64 `json:"feeRate,omitempty"`
+	Uneconomical Stats `json:"uneconomical"`
+}
+
+// Analyzer accumulates stats of unspents, it is not safe for concurrent use.
+type Analyzer struct {
+	opts    Options
+	now     time.Time
+	report  Report
+	chains  map[bitgo.Chain]*ChainStats
+	scripts map[string]*ScriptTypeStats
+}
+
+// New returns an Analyzer configured with the options.
+func New(opts Options) *Analyzer {
+	if opts.Buckets == nil {
+		opts.Buckets = DefaultBuckets
+	}
+	if opts.AgeBuckets == nil {
+		opts.AgeBuckets = DefaultAgeBuckets
+	}
+	if opts.DustThreshold.IsZero() {
+		opts.DustThreshold = DefaultDustThreshold
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	a := Analyzer{
+		opts:    opts,
+		now:     opts.Now(),
+		chains:  make(map[bitgo.Chain]*ChainStats),
+		scripts: make(map[string]*ScriptTypeStats),
+	}
+	a.report.DustThreshold = opts.DustThreshold
+	a.report.FeeRate = opts.FeeRate
+
+	min := bitgo.Amount{}
+	for _, max := range opts.Buckets {
+		a.report.Histogram = append(a.report.Histogram, ValueBucket{Min: min, Max: max})
+		min = max
+	}
+	a.report.Histogram = append(a.report.Histogram, ValueBucket{Min: min})
+
+	var minAge time.Duration
+	for _, maxAge := range opts.AgeBuckets {
+		a.report.Age = append(a.report.Age, AgeBucket{Min: minAge, Max: maxAge})
+		minAge = maxAge
+	}
+	a.report.Age = append(a.report.Age, AgeBucket{Min: minAge})
+	return &a
+}
+
+// AddList adds a page of unspents, so it can be passed to Wallet.Unspents as a callback.
+func (a *Analyzer) AddList(list *bitgo.UnspentList) {
+	for i := range list.Unspents {
+		a.Add(list.Unspents[i])
+	}
+}
+
+// Add adds the unspent to the stats.
+func (a *Analyzer) Add(u bitgo.Unspent) {
+	r := &a.report
+	r.Total.add(u.Value)
+	if !u.IsConfirmed() {
+		r.Unconfirmed.add(u.Value)
+	}
+
+	h := r.Histogram
+	i := sort.Search(len(h)-1, func(i int) bool {
+		return u.Value.Cmp(h[i].Max) < 0
+	})
+	h[i].add(u.Value)
+
+	cs, ok := a.chains[u.Chain]
+	if !ok {
+		cs = &ChainStats{Chain: u.Chain, ScriptType: scriptType(u.Chain), Change: u.Chain.IsChange()}
+		a.chains[u.Chain] = cs
+	}
+	cs.add(u.Value)
+	ss, ok := a.scripts[cs.ScriptType]
+	if !ok {
+		ss = &ScriptTypeStats{ScriptType: cs.ScriptType}
+		a.scripts[cs.ScriptType] = ss
+	}
+	ss.add(u.Value)
+
+	if u.Date.IsZero() {
+		r.UnknownAge.add(u.Value)
+	} else {
+		age := a.now.Sub(u.Date)
+		ages := r.Age
+		i = sort.Search(len(ages)-1, func(i int) bool {
+			return age < ages[i].Max
+		})
+		ages[i].add(u.Value)
+	}
+
+	if u.Value.Cmp(a.opts.DustThreshold) < 0 {
+		r.Dust.add(u.Value)
+	}
+	if a.opts.FeeRate > 0 && u.Value.Cmp(bitgo.NewAmount(InputFee(u.Chain, a.opts.FeeRate))) <= 0 {
+		r.Uneconomical.add(u.Value)
+	}
+}
+
+// Report returns the stats of the unspents added so far.
+func (a *Analyzer) Report() Report {
+	r := a.report
+	r.Histogram = append([]ValueBucket(nil), r.Histogram...)
+	r.Age = append([]AgeBucket(nil), r.Age...)
+
+	r.ByChain = make([]ChainStats, 0, len(a.chains))
+	for _, cs := range a.chains {
+		r.ByChain = append(r.ByChain, *cs)
+	}
+	sort.Slice(r.ByChain, func(i, j int) bool {
+		return r.ByChain[i].Chain < r.ByChain[j].Chain
+	})
+
+	r.ByScriptType = make([]ScriptTypeStats, 0, len(a.scripts))
+	for _, ss := range a.scripts {
+		r.ByScriptType = append(r.ByScriptType, *ss)
+	}
+	sort.Slice(r.ByScriptType, func(i, j int) bool {
+		return r.ByScriptType[i].ScriptType < r.ByScriptType[j].ScriptType
+	})
+	return r
+}
+
+// InputFee returns an estimated fee in satoshis to spend an unspent of the chain at the fee rate in satoshis/KB.
+// Unknown chains are estimated as P2SH, the largest input.
+func InputFee(chain bitgo.Chain, feeRate int64) int64 {
+	return feeRate * int64(chain.InputVSize()) / 1000
+}
+
+// scriptType returns the chain's script type or "unknown".
+func scriptType(c bitgo.Chain) string {
+	if s := c.ScriptType(); s != "" {
+		return s
+	}
+	return "unknown"
+}
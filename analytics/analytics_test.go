@@ -0,0 +1,110 @@
+package analytics_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marselester/bitgo-v2"
+	"github.com/marselester/bitgo-v2/analytics"
+)
+
+var now = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func unspents() *bitgo.UnspentList {
+	return &bitgo.UnspentList{Unspents: []bitgo.Unspent{
+		{Value: bitgo.NewAmount(500), Chain: bitgo.ChainP2SH, BlockHeight: 100, Date: now.Add(-time.Hour)},
+		{Value: bitgo.NewAmount(5000), Chain: bitgo.ChainP2SHChange, BlockHeight: 100, Date: now.Add(-48 * time.Hour)},
+		{Value: bitgo.NewAmount(20000), Chain: bitgo.ChainP2WSH, BlockHeight: bitgo.UnconfirmedHeight, Date: now},
+		{Value: bitgo.NewAmount(300000000), Chain: bitgo.ChainP2TR, BlockHeight: 100, Date: now.Add(-400 * 24 * time.Hour)},
+		{Value: bitgo.NewAmount(2000), Chain: bitgo.ChainP2WSHChange, BlockHeight: 100},
+	}}
+}
+
+func TestReport(t *testing.T) {
+	a := analytics.New(analytics.Options{
+		Buckets: []bitgo.Amount{bitgo.NewAmount(1000), bitgo.NewAmount(10000)},
+		FeeRate: 20000,
+		Now:     func() time.Time { return now },
+	})
+	a.AddList(unspents())
+	r := a.Report()
+
+	stats := func(name string, got analytics.Stats, count int, value int64) {
+		t.Helper()
+		if got.Count != count || got.Value.Cmp(bitgo.NewAmount(value)) != 0 {
+			t.Errorf("%s: expected %d unspents of %d, got %d of %s", name, count, value, got.Count, got.Value)
+		}
+	}
+	stats("total", r.Total, 5, 300027500)
+	stats("unconfirmed", r.Unconfirmed, 1, 20000)
+	stats("dust", r.Dust, 1, 500)
+	// At 20 sat/vbyte a p2sh input costs 5960 and a p2wsh input costs 2100 satoshis.
+	stats("uneconomical", r.Uneconomical, 3, 7500)
+
+	if len(r.Histogram) != 3 {
+		t.Fatalf("expected 3 value buckets, got %d", len(r.Histogram))
+	}
+	stats("[0, 1000)", r.Histogram[0].Stats, 1, 500)
+	stats("[1000, 10000)", r.Histogram[1].Stats, 2, 7000)
+	stats("[10000, inf)", r.Histogram[2].Stats, 2, 300020000)
+
+	if len(r.ByChain) != 5 || r.ByChain[0].Chain != bitgo.ChainP2SH || r.ByChain[4].Chain != bitgo.ChainP2TR {
+		t.Fatalf("unexpected chains %+v", r.ByChain)
+	}
+	want := []string{"p2sh", "p2tr", "p2wsh"}
+	if len(r.ByScriptType) != len(want) {
+		t.Fatalf("unexpected script types %+v", r.ByScriptType)
+	}
+	for i, s := range r.ByScriptType {
+		if s.ScriptType != want[i] {
+			t.Errorf("expected %s script type, got %s", want[i], s.ScriptType)
+		}
+	}
+	stats("p2sh", r.ByScriptType[0].Stats, 2, 5500)
+
+	stats("[0d, 1d)", r.Age[0].Stats, 2, 20500)
+	stats("[1d, 7d)", r.Age[1].Stats, 1, 5000)
+	stats("[365d, inf)", r.Age[5].Stats, 1, 300000000)
+	stats("unknown age", r.UnknownAge, 1, 2000)
+}
+
+func TestReportOutput(t *testing.T) {
+	a := analytics.New(analytics.Options{
+		FeeRate: 20000,
+		Now:     func() time.Time { return now },
+	})
+	a.AddList(unspents())
+	r := a.Report()
+
+	var buf bytes.Buffer
+	if err := r.WriteTable(&buf, 8); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"3.00027500", "dust <0.00000546", "uneconomical @20000 sat/KB", "[1.00000000, inf)", "20 p2wsh/receive", "[365d, inf)"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("expected %q in table:\n%s", s, buf.String())
+		}
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got analytics.Report
+	if err = json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Total != r.Total || len(got.Histogram) != len(r.Histogram) || got.ByChain[2].ScriptType != "p2wsh" {
+		t.Errorf("unexpected decoded report %+v", got)
+	}
+	// The last unbounded buckets encode zero max on every Go version.
+	if !bytes.Contains(b, []byte(`"min":100000000,"max":0`)) {
+		t.Errorf("expected zero max of the unbounded value bucket in %s", b)
+	}
+	if got.Age[len(got.Age)-1].Max != 0 {
+		t.Errorf("unexpected unbounded age bucket %+v", got.Age[len(got.Age)-1])
+	}
+}
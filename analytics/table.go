@@ -0,0 +1,79 @@
+package analytics
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/marselester/bitgo-v2"
+)
+
+// WriteTable writes the report as human readable tables.
+// Values are formatted in coins with the given number of decimals, e.g., 8 for BTC.
+func (r *Report) WriteTable(w io.Writer, decimals int) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	amount := func(a bitgo.Amount) string {
+		return a.Format(decimals)
+	}
+	row := func(name string, s Stats) {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t\n", name, s.Count, amount(s.Value))
+	}
+
+	fmt.Fprintln(tw, "SUMMARY\tCOUNT\tVALUE\t")
+	row("total", r.Total)
+	row("unconfirmed", r.Unconfirmed)
+	row("dust <"+amount(r.DustThreshold), r.Dust)
+	if r.FeeRate > 0 {
+		row("uneconomical @"+strconv.FormatInt(r.FeeRate, 10)+" sat/KB", r.Uneconomical)
+	}
+
+	fmt.Fprintln(tw, "\t\t\t")
+	fmt.Fprintln(tw, "VALUE\tCOUNT\tVALUE\t")
+	for _, b := range r.Histogram {
+		name := "[" + amount(b.Min) + ", "
+		if b.Max.IsZero() {
+			name += "inf)"
+		} else {
+			name += amount(b.Max) + ")"
+		}
+		row(name, b.Stats)
+	}
+
+	fmt.Fprintln(tw, "\t\t\t")
+	fmt.Fprintln(tw, "CHAIN\tCOUNT\tVALUE\t")
+	for _, c := range r.ByChain {
+		row(strconv.Itoa(int(c.Chain))+" "+c.Chain.String(), c.Stats)
+	}
+
+	fmt.Fprintln(tw, "\t\t\t")
+	fmt.Fprintln(tw, "SCRIPT TYPE\tCOUNT\tVALUE\t")
+	for _, s := range r.ByScriptType {
+		row(s.ScriptType, s.Stats)
+	}
+
+	fmt.Fprintln(tw, "\t\t\t")
+	fmt.Fprintln(tw, "AGE\tCOUNT\tVALUE\t")
+	for _, b := range r.Age {
+		name := "[" + days(b.Min) + ", "
+		if b.Max == 0 {
+			name += "inf)"
+		} else {
+			name += days(b.Max) + ")"
+		}
+		row(name, b.Stats)
+	}
+	row("unknown", r.UnknownAge)
+
+	return tw.Flush()
+}
+
+// days formats the duration in days if it is a whole number of days, e.g., "30d".
+func days(d time.Duration) string {
+	const day = 24 * time.Hour
+	if d%day == 0 {
+		return strconv.FormatInt(int64(d/day), 10) + "d"
+	}
+	return d.String()
+}
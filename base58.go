@@ -0,0 +1,78 @@
+package bitgo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"math/big"
+	"strings"
+)
+
+// base58Alphabet is the Bitcoin base58 alphabet which omits 0, O, I, and l to avoid confusion.
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var bigRadix58 = big.NewInt(58)
+
+// base58Encode encodes b into a base58 string keeping leading zero bytes as "1".
+func base58Encode(b []byte) string {
+	n := new(big.Int).SetBytes(b)
+	mod := new(big.Int)
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, bigRadix58, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, c := range b {
+		if c != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+// base58Decode decodes a base58 string. It returns false if s has characters outside of the alphabet.
+func base58Decode(s string) ([]byte, bool) {
+	n := new(big.Int)
+	for i := 0; i < len(s); i++ {
+		d := strings.IndexByte(base58Alphabet, s[i])
+		if d < 0 {
+			return nil, false
+		}
+		n.Mul(n, bigRadix58)
+		n.Add(n, big.NewInt(int64(d)))
+	}
+	zeros := 0
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	return append(make([]byte, zeros), n.Bytes()...), true
+}
+
+// base58CheckEncode appends a 4-byte double SHA-256 checksum to the payload and encodes it.
+func base58CheckEncode(payload []byte) string {
+	b := append(append([]byte(nil), payload...), checksum(payload)...)
+	return base58Encode(b)
+}
+
+// base58CheckDecode decodes a base58check string and returns the payload without the checksum.
+func base58CheckDecode(s string) ([]byte, error) {
+	b, ok := base58Decode(s)
+	if !ok || len(b) < 5 {
+		return nil, ErrAddressFormat
+	}
+	payload, sum := b[:len(b)-4], b[len(b)-4:]
+	if !bytes.Equal(checksum(payload), sum) {
+		return nil, ErrAddressChecksum
+	}
+	return payload, nil
+}
+
+// checksum returns the first 4 bytes of double SHA-256 of b.
+func checksum(b []byte) []byte {
+	h := sha256.Sum256(b)
+	h = sha256.Sum256(h[:])
+	return h[:4]
+}
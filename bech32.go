@@ -0,0 +1,163 @@
+package bitgo
+
+import (
+	"strings"
+)
+
+// bech32Charset maps 5-bit values to characters of bech32 and cashaddr encodings.
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// bech32Variant is a checksum constant which tells bech32 (BIP-173) and bech32m (BIP-350) apart.
+type bech32Variant uint32
+
+const (
+	bech32  bech32Variant = 1
+	bech32m bech32Variant = 0x2bc830a3
+)
+
+// bech32Polymod computes the BCH checksum of 5-bit values.
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+// bech32HRPExpand expands the human readable part for checksum computation.
+func bech32HRPExpand(hrp string) []byte {
+	b := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		b = append(b, hrp[i]>>5)
+	}
+	b = append(b, 0)
+	for i := 0; i < len(hrp); i++ {
+		b = append(b, hrp[i]&31)
+	}
+	return b
+}
+
+// bech32Encode encodes the human readable part and 5-bit data with a checksum of the variant.
+func bech32Encode(hrp string, data []byte, v bech32Variant) string {
+	values := append(bech32HRPExpand(hrp), data...)
+	values = append(values, make([]byte, 6)...)
+	mod := bech32Polymod(values) ^ uint32(v)
+
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, d := range data {
+		sb.WriteByte(bech32Charset[d])
+	}
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(bech32Charset[(mod>>(5*(5-i)))&31])
+	}
+	return sb.String()
+}
+
+// bech32Decode decodes a bech32 or bech32m string into the lowercase human readable part
+// and 5-bit data without the checksum.
+func bech32Decode(s string) (hrp string, data []byte, v bech32Variant, err error) {
+	if len(s) < 8 || len(s) > 90 {
+		return "", nil, 0, ErrAddressFormat
+	}
+	lower := strings.ToLower(s)
+	if s != lower && s != strings.ToUpper(s) {
+		return "", nil, 0, ErrAddressFormat
+	}
+	pos := strings.LastIndexByte(lower, '1')
+	if pos < 1 || pos+7 > len(lower) {
+		return "", nil, 0, ErrAddressFormat
+	}
+	hrp = lower[:pos]
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, 0, ErrAddressFormat
+		}
+	}
+	for i := pos + 1; i < len(lower); i++ {
+		d := strings.IndexByte(bech32Charset, lower[i])
+		if d < 0 {
+			return "", nil, 0, ErrAddressFormat
+		}
+		data = append(data, byte(d))
+	}
+
+	switch v = bech32Variant(bech32Polymod(append(bech32HRPExpand(hrp), data...))); v {
+	case bech32, bech32m:
+		return hrp, data[:len(data)-6], v, nil
+	}
+	return "", nil, 0, ErrAddressChecksum
+}
+
+// convertBits regroups bits of data from groups of size from to groups of size to.
+// When pad is false, incomplete or non-zero trailing bits are an error.
+func convertBits(data []byte, from, to uint, pad bool) ([]byte, bool) {
+	var (
+		acc  uint32
+		bits uint
+		out  []byte
+	)
+	maxv := uint32(1)<<to - 1
+	for _, d := range data {
+		if uint32(d)>>from != 0 {
+			return nil, false
+		}
+		acc = acc<<from | uint32(d)
+		bits += from
+		for bits >= to {
+			bits -= to
+			out = append(out, byte(acc>>bits&maxv))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(to-bits)&maxv))
+		}
+	} else if bits >= from || acc<<(to-bits)&maxv != 0 {
+		return nil, false
+	}
+	return out, true
+}
+
+// segwitDecode decodes a segwit address (BIP-173 and BIP-350) into a witness version and program.
+// The human readable part of the address is returned in lowercase.
+func segwitDecode(s string) (hrp string, version int, program []byte, err error) {
+	hrp, data, v, err := bech32Decode(s)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	if len(data) < 1 || data[0] > 16 {
+		return "", 0, nil, ErrAddressFormat
+	}
+	version = int(data[0])
+	// Version 0 uses bech32 checksum, later versions use bech32m.
+	if version == 0 && v != bech32 || version != 0 && v != bech32m {
+		return "", 0, nil, ErrAddressChecksum
+	}
+	program, ok := convertBits(data[1:], 5, 8, false)
+	if !ok || len(program) < 2 || len(program) > 40 {
+		return "", 0, nil, ErrAddressFormat
+	}
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return "", 0, nil, ErrAddressFormat
+	}
+	return hrp, version, program, nil
+}
+
+// segwitEncode encodes a witness version and program into a segwit address.
+func segwitEncode(hrp string, version int, program []byte) string {
+	data, _ := convertBits(program, 8, 5, true)
+	v := bech32m
+	if version == 0 {
+		v = bech32
+	}
+	return bech32Encode(hrp, append([]byte{byte(version)}, data...), v)
+}
@@ -5,29 +5,56 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 const (
 	// Default URL for API endpoints is a production environment.
-	// You can change a base URL using WithBaseURL.
+	// You can change a base URL using WithBaseURL or WithEnvironment.
 	// More about environments https://www.bitgo.com/api/v2/?shell#environments.
 	defaultBaseURL = "https://www.bitgo.com"
 	// Full list of supported currencies https://www.bitgo.com/api/v2/?shell#coin-digital-currency-support.
 	defaultCoin = "btc"
+	// Responses larger than 10 MB are rejected by default.
+	// You can change the limit using WithMaxResponseSize.
+	defaultMaxResponseSize = 10 << 20
 )
 
 // Config configures a Client. Config is set by the ConfigOption
 // values passed to NewClient.
 type Config struct {
-	httpClient  *http.Client
-	baseURL     string
-	coin        string
+	httpClient *http.Client
+	// baseURL is set by WithBaseURL, otherwise it's the environment's or the default one, see validate.
+	baseURL string
+	coin    string
+	// coinInfo describes the coin, it is set when the config is validated.
+	coinInfo CoinInfo
+	// coins are descriptions of the coins which are not in the registry.
+	coins       map[string]CoinInfo
 	accessToken string
 	logger      Logger
+	trace       *ClientTrace
+	// maxRetries is how many times a failed idempotent request is retried.
+	maxRetries int
+	// retryBackoff is a wait before the first retry, it doubles with every attempt.
+	retryBackoff time.Duration
+	// maxPages is a max number of pages fetched by list methods, zero means no limit.
+	maxPages int
+	// prefetch is a number of pages fetched ahead by list methods, zero means no prefetching.
+	prefetch int
+	// redactedFields are extra JSON fields masked in logs.
+	redactedFields []string
+	// maxResponseSize is a max size of a response body in bytes.
+	maxResponseSize int64
+	// env is set by WithEnvironment, otherwise it's inferred from baseURL.
+	env                *Environment
+	productionSpending bool
 }
 
 // ConfigOption configures how we set up the Client.
@@ -70,22 +97,57 @@ func WithLogger(l Logger) ConfigOption {
 	}
 }
 
+// WithMaxResponseSize limits a size of response body in bytes (default is 10 MB).
+// Larger responses fail with ErrResponseTooLarge error.
+func WithMaxResponseSize(n int64) ConfigOption {
+	return func(c *Config) {
+		c.maxResponseSize = n
+	}
+}
+
+// WithMaxPages limits a number of pages fetched by list methods such as Unspents
+// to stop runaway pagination. By default the number of pages is not limited.
+func WithMaxPages(n int) ConfigOption {
+	return func(c *Config) {
+		c.maxPages = n
+	}
+}
+
+// WithPrefetch makes list methods such as Unspents fetch up to n pages ahead in a goroutine
+// while the current page is being handled. By default pages are fetched one by one.
+func WithPrefetch(n int) ConfigOption {
+	return func(c *Config) {
+		c.prefetch = n
+	}
+}
+
+// WithTrace configures hooks to trace all API calls made by Client.
+// The hooks can be overridden per call with WithClientTrace context.
+func WithTrace(trace *ClientTrace) ConfigOption {
+	return func(c *Config) {
+		c.trace = trace
+	}
+}
+
 // Client manages communication with the BitGo REST-ful API.
 type Client struct {
 	config Config
+	// err is a configuration error returned by every request.
+	err    error
 	Wallet *walletService
 }
 
 // NewClient returns a Client which can be configured with config options.
 // By default requests are sent to https://www.bitgo.com, currency is "btc",
 // and logs are discarded.
+// Use Err to check whether the coin is known and compatible with the environment.
 func NewClient(options ...ConfigOption) *Client {
 	c := Client{
 		config: Config{
-			httpClient: http.DefaultClient,
-			baseURL:    defaultBaseURL,
-			coin:       defaultCoin,
-			logger:     &NoopLogger{},
+			httpClient:      http.DefaultClient,
+			coin:            defaultCoin,
+			logger:          &NoopLogger{},
+			maxResponseSize: defaultMaxResponseSize,
 		},
 	}
 
@@ -94,14 +156,58 @@ func NewClient(options ...ConfigOption) *Client {
 	for _, opt := range options {
 		opt(&c.config)
 	}
+	c.err = c.config.validate()
 	return &c
 }
 
+// validate checks that the coin is known and compatible with the environment.
+// The base URL defaults to the environment's one unless WithBaseURL was used.
+func (c *Config) validate() error {
+	if c.baseURL == "" {
+		c.baseURL = defaultBaseURL
+		if c.env != nil {
+			c.baseURL = c.env.BaseURL
+		}
+	}
+	if err := c.validateCoin(); err != nil {
+		return err
+	}
+	return c.validateEnvironment()
+}
+
+// CoinInfo returns a description of the coin the Client uses.
+func (c *Client) CoinInfo() CoinInfo {
+	return c.config.coinInfo
+}
+
+// Err returns a configuration error, e.g., when a testnet coin is used in production environment.
+// Requests made by misconfigured Client fail with the same error.
+func (c *Client) Err() error {
+	return c.err
+}
+
+// Coin returns a coin-scoped view of the Client, e.g., c.Coin("bch").Wallet.Unspents(...).
+// The view shares the HTTP client (connection pool), access token, logger and other settings
+// with the Client, only digital currency is different.
+// The Client itself keeps using the currency set by WithCoin.
+func (c *Client) Coin(coin string) *Client {
+	v := Client{config: c.config}
+	v.config.coin = coin
+	v.err = v.config.validate()
+	v.Wallet = &walletService{client: &v}
+	return &v
+}
+
 // NewRequest creates Request to access BitGo API.
 // API path must not start or end with slash. Query string params are optional.
 // If specified, the value pointed to by body is JSON encoded and included
 // as the request body.
 func (c *Client) NewRequest(ctx context.Context, method, path string, queryParams url.Values, bodyParams interface{}) (*http.Request, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	start := time.Now()
+
 	var urlStr string
 	if queryParams != nil {
 		urlStr = fmt.Sprintf("%s/api/v2/%s/%s?%s", c.config.baseURL, c.config.coin, path, queryParams.Encode())
@@ -116,7 +222,9 @@ func (c *Client) NewRequest(ctx context.Context, method, path string, queryParam
 			return nil, err
 		}
 	}
-	c.config.logger.Log("level", "debug", "msg", "creating request", "method", method, "url", urlStr, "body", b)
+	if c.config.debugEnabled(ctx) {
+		c.config.log(ctx, LevelDebug, "creating request", "method", method, "url", c.config.logURL(urlStr), "body", c.config.logBody(b))
+	}
 
 	req, err := http.NewRequest(method, urlStr, bytes.NewReader(b))
 	if err != nil {
@@ -130,40 +238,145 @@ func (c *Client) NewRequest(ctx context.Context, method, path string, queryParam
 		bearer := fmt.Sprintf("Bearer %s", c.config.accessToken)
 		req.Header.Set("Authorization", bearer)
 	}
-	c.config.logger.Log("level", "debug", "msg", "request headers are set", "header", req.Header)
+	c.config.log(ctx, LevelDebug, "request headers are set", "header", RedactHeader(req.Header))
 
+	if trace := c.trace(ctx); trace.RequestBuilt != nil {
+		trace.RequestBuilt(RequestBuiltInfo{
+			Request:  c.requestInfo(method, urlStr),
+			Start:    start,
+			Duration: time.Since(start),
+			BodySize: len(b),
+		})
+	}
 	return req, nil
 }
 
 // Do uses Client's HTTP client to execute the Request and
 // unmarshals the Response into v.
 // It also handles unmarshaling errors returned by the API.
+// Failed idempotent requests are retried if configured with WithRetry.
 func (c *Client) Do(req *http.Request, v interface{}) (*http.Response, error) {
-	c.config.logger.Log("level", "debug", "msg", "sending request")
+	trace := c.trace(req.Context())
+	info := c.requestInfo(req.Method, req.URL.String())
+	return c.retry(req, trace, info, func(attempt int) (*http.Response, error) {
+		return c.do(req, v, trace, info, attempt)
+	})
+}
+
+// do makes a single attempt to execute the Request.
+func (c *Client) do(req *http.Request, v interface{}, trace *ClientTrace, info RequestInfo, attempt int) (*http.Response, error) {
+	c.config.log(req.Context(), LevelDebug, "sending request")
+	start := time.Now()
+	if trace.RequestSent != nil {
+		trace.RequestSent(RequestSentInfo{
+			Request: info,
+			Attempt: attempt,
+			Start:   start,
+		})
+	}
+	var resp *http.Response
+	received := func(statusCode int, err error) {
+		if resp != nil {
+			collectResponseMeta(req, resp, time.Since(start))
+		}
+		if trace.ResponseReceived != nil {
+			trace.ResponseReceived(ResponseReceivedInfo{
+				Request:    info,
+				Attempt:    attempt,
+				StatusCode: statusCode,
+				Latency:    time.Since(start),
+				Err:        err,
+			})
+		}
+	}
+
 	resp, err := c.config.httpClient.Do(req)
 	if err != nil {
-		c.config.logger.Log("level", "debug", "msg", "request failed", "err", err)
+		c.config.log(req.Context(), LevelError, "request failed", "err", err)
+		err = &RequestError{
+			Op:       OpSend,
+			Method:   req.Method,
+			Path:     req.URL.Path,
+			Err:      err,
+			canceled: req.Context().Err() != nil,
+		}
+		received(0, err)
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		c.config.logger.Log("level", "debug", "msg", "invalid body", "status", resp.Status, "err", err)
+	reqErr := func(op string, err error) error {
+		return &RequestError{
+			Op:             op,
+			Method:         req.Method,
+			Path:           req.URL.Path,
+			RequestID:      headerRequestID(resp.Header),
+			HTTPStatusCode: resp.StatusCode,
+			Err:            err,
+			canceled:       req.Context().Err() != nil,
+		}
+	}
+	if resp.ContentLength > c.config.maxResponseSize {
+		err = reqErr(OpRead, ErrResponseTooLarge)
+		c.config.log(req.Context(), LevelError, "invalid body", "status", resp.Status, "err", err)
+		received(resp.StatusCode, err)
 		return resp, err
 	}
-	c.config.logger.Log("level", "debug", "msg", "server response", "status", resp.Status, "header", resp.Header, "body", body)
+	r := &maxBytesReader{r: resp.Body, n: c.config.maxResponseSize}
 
+	// Successful responses are decoded as they are read,
+	// only a limited part of the body is kept for debug logs if they are enabled.
 	if resp.StatusCode == http.StatusOK {
-		err = json.Unmarshal(body, v)
+		debug := c.config.debugEnabled(req.Context())
+		var body io.Reader = r
+		logged := limitedBuffer{max: maxLoggedBodySize}
+		if debug {
+			body = io.TeeReader(r, &logged)
+		}
+		op, err := decodeJSON(body, v)
+		if debug {
+			c.config.log(req.Context(), LevelDebug, "server response", "status", resp.Status, "header", RedactHeader(resp.Header), "body", c.config.logBody(logged.Bytes()))
+		}
+		if err != nil {
+			err = reqErr(op, err)
+			c.config.log(req.Context(), LevelError, "invalid body", "status", resp.Status, "err", err)
+		}
+		received(resp.StatusCode, err)
+		if op == OpDecode && trace.DecodeFailed != nil {
+			trace.DecodeFailed(DecodeFailedInfo{
+				Request:    info,
+				StatusCode: resp.StatusCode,
+				Latency:    time.Since(start),
+				Err:        err,
+			})
+		}
 		return resp, err
 	}
 
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		var re *readError
+		if errors.As(err, &re) {
+			err = re.err
+		}
+		err = reqErr(OpRead, err)
+		c.config.log(req.Context(), LevelError, "invalid body", "status", resp.Status, "err", err)
+		received(resp.StatusCode, err)
+		return resp, err
+	}
+	if c.config.debugEnabled(req.Context()) {
+		c.config.log(req.Context(), LevelDebug, "server response", "status", resp.Status, "header", RedactHeader(resp.Header), "body", c.config.logBody(body))
+	}
+
 	e := Error{
 		HTTPStatusCode: resp.StatusCode,
 		Body:           string(body),
 	}
 	_ = json.Unmarshal(body, &e)
+	if e.RequestID == "" {
+		e.RequestID = headerRequestID(resp.Header)
+	}
+	e.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
 
 	switch resp.StatusCode {
 	case http.StatusAccepted:
@@ -179,5 +392,14 @@ func (c *Client) Do(req *http.Request, v interface{}) (*http.Response, error) {
 	default:
 		e.Type = ErrorTypeAPI
 	}
+	switch {
+	case e.IsApprovalRequired():
+		c.config.log(req.Context(), LevelInfo, "request requires approval", "status", resp.Status, "request_id", e.RequestID)
+	case e.IsRateLimited():
+		c.config.log(req.Context(), LevelWarn, "rate limited", "status", resp.Status, "request_id", e.RequestID)
+	default:
+		c.config.log(req.Context(), LevelError, "request failed", "status", resp.Status, "request_id", e.RequestID, "err", e)
+	}
+	received(resp.StatusCode, e)
 	return resp, e
 }
@@ -87,6 +87,7 @@ func TestErrorResponse(t *testing.T) {
 
 			c := bitgo.NewClient(
 				bitgo.WithBaseURL(srv.URL),
+				bitgo.WithProductionSpending(),
 			)
 			_, err := c.Wallet.Consolidate(context.Background(), "", nil)
 			if err != test.want {
@@ -95,3 +96,37 @@ func TestErrorResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestCoin(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer swordfish" {
+			t.Errorf("access token is not shared")
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c := bitgo.NewClient(
+		bitgo.WithBaseURL(srv.URL),
+		bitgo.WithProductionSpending(),
+		bitgo.WithCoin("btc"),
+		bitgo.WithAccesToken("swordfish"),
+	)
+	ctx := context.Background()
+	if _, err := c.Coin("bch").Wallet.Consolidate(ctx, "585951a5df8380e0e3063e9f", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Wallet.Consolidate(ctx, "585951a5df8380e0e3063e9f", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"/api/v2/bch/wallet/585951a5df8380e0e3063e9f/consolidateunspents",
+		"/api/v2/btc/wallet/585951a5df8380e0e3063e9f/consolidateunspents",
+	}
+	if len(paths) != len(want) || paths[0] != want[0] || paths[1] != want[1] {
+		t.Fatalf("should be %v, not %v", want, paths)
+	}
+}
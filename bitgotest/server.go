@@ -0,0 +1,862 @@
+// Package bitgotest provides a stateful in-memory fake of BitGo API and BitGo Express
+// to test code built on the bitgo client offline.
+//
+//	fake := bitgotest.NewServer()
+//	fake.AddWallet("tbtc", "585951a5df8380e0e3063e9f", "root")
+//	fake.AddUnspents("585951a5df8380e0e3063e9f", bitgotest.Dust(100, 546)...)
+//	c := fake.Client(t)
+package bitgotest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marselester/bitgo-v2"
+	"github.com/marselester/bitgo-v2/rawtx"
+)
+
+const (
+	// UnconfirmedHeight is a block height BitGo reports for unconfirmed unspents.
+	UnconfirmedHeight = bitgo.UnconfirmedHeight
+	// DefaultPageSize is a number of unspents returned per page when limit param is not set.
+	DefaultPageSize = 100
+
+	// Consolidation defaults as documented in https://www.bitgo.com/api/v2/#consolidate-wallet-unspents.
+	defaultConsolidateLimit = 25
+	maxConsolidateLimit     = 200
+	// Rough size estimate of 2-of-3 multisig P2SH transaction parts in bytes.
+	txOverheadSize = 10
+	txInputSize    = 297
+	txOutputSize   = 34
+	// defaultFeeRate in satoshis/KB is used when consolidation params have no fee rate.
+	defaultFeeRate = 10000
+)
+
+// Wallet is a fake wallet state.
+type Wallet struct {
+	ID   string `json:"id"`
+	Coin string `json:"coin"`
+	// Passphrase is required to spend from the wallet when it's not empty.
+	Passphrase string          `json:"-"`
+	Unspents   []bitgo.Unspent `json:"-"`
+	Addresses  []Address       `json:"-"`
+	Transfers  []Transfer      `json:"-"`
+	Balance    int64           `json:"balance"`
+}
+
+// Address is a receive address of a fake wallet.
+type Address struct {
+	ID      string `json:"id"`
+	Address string `json:"address"`
+	Chain   int    `json:"chain"`
+	Index   int    `json:"index"`
+	Coin    string `json:"coin"`
+	Wallet  string `json:"wallet"`
+}
+
+// Transfer is a transaction made by a fake wallet.
+type Transfer struct {
+	ID     string `json:"id"`
+	Coin   string `json:"coin"`
+	Wallet string `json:"wallet"`
+	TxID   string `json:"txid"`
+	Type   string `json:"type"`
+	// Value is the net change of the wallet balance (negative fee for consolidations).
+	Value int64  `json:"value"`
+	Fee   int64  `json:"feeString,string"`
+	State string `json:"state"`
+	Date  string `json:"date"`
+	// Inputs and Outputs are the unspents IDs spent and created by the transfer.
+	Inputs  []string `json:"inputs"`
+	Outputs []string `json:"outputs"`
+}
+
+// Fault is an error response the server injects instead of handling a request.
+type Fault struct {
+	// Method matches request method, empty string matches any method.
+	Method string
+	// Path is a suffix of request URL path, e.g., "consolidateunspents".
+	// Empty string matches any path.
+	Path string
+	// StatusCode is a response status code, e.g., 202, 400, 401, 429, 500.
+	StatusCode int
+	// Body is a response body. If empty, BitGo-like JSON error is generated.
+	Body string
+	// Count is how many requests fail, zero means every matching request.
+	Count int
+}
+
+// Server is a fake BitGo API http.Handler which keeps wallets, unspents,
+// addresses and transfers in memory.
+// It serves both BitGo API and BitGo Express endpoints used by the client.
+type Server struct {
+	// AccessToken is checked in Authorization header when it's not empty.
+	AccessToken string
+	// BlockHeight is the current chain tip used to calculate confirmations.
+	BlockHeight int64
+	// Now returns the current time, time.Now is used if nil.
+	Now func() time.Time
+
+	mu      sync.Mutex
+	wallets map[string]*Wallet
+	faults  []*Fault
+	// seq is used to generate unique IDs.
+	seq int
+}
+
+// NewServer returns a fake BitGo server without any wallets.
+func NewServer() *Server {
+	return &Server{
+		BlockHeight: 1000,
+		wallets:     make(map[string]*Wallet),
+	}
+}
+
+// Client starts an HTTP server of the fake which is closed when the test finishes,
+// and returns a tbtc client of that server. The options are applied after the defaults,
+// e.g., bitgo.WithCoin changes the coin.
+func (s *Server) Client(t testing.TB, options ...bitgo.ConfigOption) *bitgo.Client {
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	options = append([]bitgo.ConfigOption{
+		bitgo.WithBaseURL(srv.URL),
+		bitgo.WithCoin("tbtc"),
+	}, options...)
+	return bitgo.NewClient(options...)
+}
+
+// AddWallet creates an empty wallet. Spending requires the passphrase if it's not empty.
+func (s *Server) AddWallet(coin, walletID, passphrase string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.wallets[walletID] = &Wallet{
+		ID:         walletID,
+		Coin:       coin,
+		Passphrase: passphrase,
+	}
+}
+
+// AddUnspents adds unspents to the wallet. Empty IDs and dates are generated.
+func (s *Server) AddUnspents(walletID string, unspents ...bitgo.Unspent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.wallets[walletID]
+	if !ok {
+		panic(fmt.Sprintf("bitgotest: wallet %s not found", walletID))
+	}
+	for _, u := range unspents {
+		if u.ID == "" {
+			u.ID = s.txid() + ":0"
+		}
+		if u.Date.IsZero() {
+			u.Date = s.now().UTC().Truncate(time.Millisecond)
+		}
+		u.Wallet = w.ID
+		w.Unspents = append(w.Unspents, u)
+		w.Balance += satoshis(u.Value)
+	}
+	sortUnspents(w.Unspents)
+}
+
+// Wallet returns a copy of the wallet state.
+func (s *Server) Wallet(walletID string) (Wallet, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.wallets[walletID]
+	if !ok {
+		return Wallet{}, false
+	}
+	c := *w
+	c.Unspents = append([]bitgo.Unspent(nil), w.Unspents...)
+	c.Addresses = append([]Address(nil), w.Addresses...)
+	c.Transfers = append([]Transfer(nil), w.Transfers...)
+	return c, true
+}
+
+// InjectFault makes the server respond with an error to matching requests.
+// Faults are checked in the order they were injected.
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// Dust returns n unspents worth value satoshis each confirmed at block height 1.
+func Dust(n int, value int64) []bitgo.Unspent {
+	uu := make([]bitgo.Unspent, n)
+	for i := range uu {
+		uu[i] = bitgo.Unspent{
+			Value:       bitgo.NewAmount(value),
+			BlockHeight: 1,
+			Index:       i,
+		}
+	}
+	return uu
+}
+
+// ServeHTTP routes BitGo API v2 requests /api/v2/{coin}/wallet/{id}/...
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if f := s.fault(r); f != nil {
+		body := f.Body
+		if body == "" {
+			body = errorBody(f.StatusCode, http.StatusText(f.StatusCode))
+		}
+		writeRaw(w, f.StatusCode, body)
+		return
+	}
+	if s.AccessToken != "" && r.Header.Get("Authorization") != "Bearer "+s.AccessToken {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	// Path is /api/v2/{coin}/wallet/{id}[/{action}].
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 5 || parts[0] != "api" || parts[1] != "v2" || parts[3] != "wallet" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	coin, walletID := parts[2], parts[4]
+	wallet, ok := s.wallets[walletID]
+	if !ok || wallet.Coin != coin {
+		writeError(w, http.StatusNotFound, "wallet not found")
+		return
+	}
+
+	action := strings.Join(parts[5:], "/")
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, wallet)
+	case action == "unspents" && r.Method == http.MethodGet:
+		s.unspents(w, r, wallet)
+	case action == "consolidateunspents" && r.Method == http.MethodPost:
+		s.consolidate(w, r, wallet)
+	case action == "sendmany" && r.Method == http.MethodPost:
+		s.sendMany(w, r, wallet)
+	case action == "addresses" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]interface{}{"coin": coin, "addresses": wallet.Addresses})
+	case action == "address" && r.Method == http.MethodPost:
+		s.createAddress(w, wallet)
+	case action == "transfer" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]interface{}{"coin": coin, "transfers": wallet.Transfers})
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// fault returns the first injected fault matching the request.
+func (s *Server) fault(r *http.Request) *Fault {
+	for i, f := range s.faults {
+		if f.Method != "" && f.Method != r.Method {
+			continue
+		}
+		if !strings.HasSuffix(r.URL.Path, f.Path) {
+			continue
+		}
+
+		if f.Count > 0 {
+			f.Count--
+			if f.Count == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+// unspents lists the wallet's unspents sorted by ID with nextBatchPrevId pagination.
+func (s *Server) unspents(w http.ResponseWriter, r *http.Request, wallet *Wallet) {
+	q := r.URL.Query()
+	f, err := parseFilter(q.Get("minValue"), q.Get("maxValue"), q.Get("minHeight"), q.Get("minConfirms"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	f.ids = idSet(q["unspentIds"])
+	limit := DefaultPageSize
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+	prevID := q.Get("prevId")
+
+	list := unspentList{
+		Coin:     wallet.Coin,
+		Unspents: []unspent{},
+	}
+	for _, u := range wallet.Unspents {
+		if prevID != "" && u.ID <= prevID {
+			continue
+		}
+		if !f.match(u, s.BlockHeight) {
+			continue
+		}
+		if len(list.Unspents) == limit {
+			list.NextBatchPrevID = list.Unspents[limit-1].ID
+			break
+		}
+		list.Unspents = append(list.Unspents, newUnspent(u))
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// unspentList is a page of unspents in BitGo's JSON format.
+type unspentList struct {
+	NextBatchPrevID string    `json:"nextBatchPrevId,omitempty"`
+	Coin            string    `json:"coin"`
+	Unspents        []unspent `json:"unspents"`
+}
+
+// unspent is an unspent in BitGo's JSON format, bitgo.Unspent is encoded with Go field names.
+type unspent struct {
+	ID            string       `json:"id"`
+	Address       string       `json:"address"`
+	Value         bitgo.Amount `json:"value"`
+	BlockHeight   int64        `json:"blockHeight"`
+	Date          string       `json:"date"`
+	Wallet        string       `json:"wallet"`
+	FromWallet    string       `json:"fromWallet,omitempty"`
+	Chain         bitgo.Chain  `json:"chain"`
+	Index         int          `json:"index"`
+	RedeemScript  string       `json:"redeemScript,omitempty"`
+	WitnessScript string       `json:"witnessScript,omitempty"`
+	IsSegwit      bool         `json:"isSegwit"`
+}
+
+func newUnspent(u bitgo.Unspent) unspent {
+	return unspent{
+		ID:            u.ID,
+		Address:       u.Address,
+		Value:         u.Value,
+		BlockHeight:   u.BlockHeight,
+		Date:          u.Date.Format(time.RFC3339Nano),
+		Wallet:        u.Wallet,
+		FromWallet:    u.FromWallet,
+		Chain:         u.Chain,
+		Index:         u.Index,
+		RedeemScript:  u.RedeemScript,
+		WitnessScript: u.WitnessScript,
+		IsSegwit:      u.IsSegwit,
+	}
+}
+
+// consolidate merges the wallet's unspents selected according to WalletConsolidateParams.
+func (s *Server) consolidate(w http.ResponseWriter, r *http.Request, wallet *Wallet) {
+	params := bitgo.WalletConsolidateParams{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if wallet.Passphrase != "" && params.WalletPassphrase != wallet.Passphrase {
+		writeError(w, http.StatusUnauthorized, "unable to decrypt keychain with the given wallet passphrase")
+		return
+	}
+	if params.MaxFeePercentage > 0 && params.MinValue.Sign() > 0 {
+		writeError(w, http.StatusBadRequest, "cannot combine maxFeePercentage with minValue")
+		return
+	}
+
+	minValue, minOK := params.MinValue.Int64()
+	maxValue, maxOK := params.MaxValue.Int64()
+	if !minOK || !maxOK {
+		writeError(w, http.StatusBadRequest, "invalid minValue or maxValue")
+		return
+	}
+
+	limit := params.Limit
+	if limit == 0 {
+		limit = defaultConsolidateLimit
+	}
+	if limit < 0 || limit > maxConsolidateLimit {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxConsolidateLimit))
+		return
+	}
+	numOutputs := params.NumUnspentsToMake
+	if numOutputs == 0 {
+		numOutputs = 1
+	}
+	feeRate := int64(params.FeeRate)
+	if feeRate == 0 {
+		feeRate = defaultFeeRate
+	}
+
+	f := filter{
+		minValue:    minValue,
+		maxValue:    maxValue,
+		minHeight:   int64(params.MinHeight),
+		minConfirms: int64(params.MinConfirms),
+		ids:         idSet(params.Unspents),
+	}
+	if !hasUnspents(wallet, f.ids) {
+		writeError(w, http.StatusBadRequest, "unspent not found in the wallet")
+		return
+	}
+	var (
+		selected []bitgo.Unspent
+		kept     []bitgo.Unspent
+	)
+	for _, u := range wallet.Unspents {
+		ok := len(selected) < limit && f.match(u, s.BlockHeight)
+		if ok && params.MaxFeePercentage > 0 {
+			inputFee := feeRate * txInputSize / 1000
+			ok = inputFee*100 <= satoshis(u.Value)*int64(params.MaxFeePercentage)
+		}
+		if ok {
+			selected = append(selected, u)
+		} else {
+			kept = append(kept, u)
+		}
+	}
+	if len(selected) <= numOutputs {
+		writeNamedError(w, http.StatusBadRequest, bitgo.ErrorNameNoUnspents, "No unspents available for consolidation")
+		return
+	}
+
+	var total int64
+	inputs := make([]string, len(selected))
+	for i, u := range selected {
+		total += satoshis(u.Value)
+		inputs[i] = u.ID
+	}
+	size := int64(txOverheadSize + txInputSize*len(selected) + txOutputSize*numOutputs)
+	fee := feeRate * size / 1000
+	if fee >= total {
+		writeNamedError(w, http.StatusBadRequest, bitgo.ErrorNameInsufficientBalance, "insufficient funds to pay the consolidation fee")
+		return
+	}
+
+	change := total - fee
+	outputs := make([]rawtx.Output, numOutputs)
+	addresses := make([]Address, numOutputs)
+	for i := range outputs {
+		outputs[i].Value = bitgo.NewAmount(change / int64(numOutputs))
+		if i == 0 {
+			outputs[i].Value = bitgo.NewAmount(change/int64(numOutputs) + change%int64(numOutputs))
+		}
+		addresses[i] = s.newAddress(wallet, bitgo.ChainP2SHChange)
+		outputs[i].Script = s.addressScript(wallet, addresses[i].Address)
+	}
+	txid, txHex, err := newTx(wallet.Coin, selected, outputs)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	date := s.now().UTC().Truncate(time.Millisecond)
+	now := date.Format(time.RFC3339)
+	outputIDs := make([]string, numOutputs)
+	for i, out := range outputs {
+		u := bitgo.Unspent{
+			ID:          fmt.Sprintf("%s:%d", txid, i),
+			Address:     addresses[i].Address,
+			Value:       out.Value,
+			BlockHeight: UnconfirmedHeight,
+			Date:        date,
+			Wallet:      wallet.ID,
+			FromWallet:  wallet.ID,
+			Chain:       bitgo.Chain(addresses[i].Chain),
+			Index:       addresses[i].Index,
+		}
+		kept = append(kept, u)
+		outputIDs[i] = u.ID
+	}
+	sortUnspents(kept)
+	wallet.Unspents = kept
+	wallet.Balance -= fee
+	wallet.Transfers = append(wallet.Transfers, Transfer{
+		ID:      s.id(),
+		Coin:    wallet.Coin,
+		Wallet:  wallet.ID,
+		TxID:    txid,
+		Type:    "send",
+		Value:   -fee,
+		Fee:     fee,
+		State:   "signed",
+		Date:    now,
+		Inputs:  inputs,
+		Outputs: outputIDs,
+	})
+
+	writeJSON(w, http.StatusOK, bitgo.TxInfo{
+		TxID:   txid,
+		Tx:     txHex,
+		Status: "signed",
+	})
+}
+
+// sendMany pays the recipients spending the pinned unspents or the largest ones,
+// and keeps the change in the wallet.
+func (s *Server) sendMany(w http.ResponseWriter, r *http.Request, wallet *Wallet) {
+	params := bitgo.SendManyParams{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if wallet.Passphrase != "" && params.WalletPassphrase != wallet.Passphrase {
+		writeError(w, http.StatusUnauthorized, "unable to decrypt keychain with the given wallet passphrase")
+		return
+	}
+	if len(params.Recipients) == 0 {
+		writeError(w, http.StatusBadRequest, "recipients are required")
+		return
+	}
+	var amount int64
+	for _, rcpt := range params.Recipients {
+		n, ok := rcpt.Amount.Int64()
+		// The total must fit into int64 too.
+		if !ok || n <= 0 || amount > math.MaxInt64-n {
+			writeError(w, http.StatusBadRequest, "invalid recipient amount")
+			return
+		}
+		amount += n
+	}
+	feeRate := int64(params.FeeRate)
+	if feeRate == 0 {
+		feeRate = defaultFeeRate
+	}
+	numOutputs := len(params.Recipients) + 1
+	fee := func(numInputs int) int64 {
+		return feeRate * int64(txOverheadSize+txInputSize*numInputs+txOutputSize*numOutputs) / 1000
+	}
+
+	var (
+		selected []bitgo.Unspent
+		kept     []bitgo.Unspent
+		total    int64
+	)
+	if len(params.Unspents) > 0 {
+		pinned := make(map[string]bool)
+		for _, id := range params.Unspents {
+			pinned[id] = true
+		}
+		for _, u := range wallet.Unspents {
+			if pinned[u.ID] {
+				selected = append(selected, u)
+				total += satoshis(u.Value)
+				delete(pinned, u.ID)
+			} else {
+				kept = append(kept, u)
+			}
+		}
+		if len(pinned) > 0 {
+			writeError(w, http.StatusBadRequest, "unspent not found in the wallet")
+			return
+		}
+	} else {
+		kept = append(kept, wallet.Unspents...)
+		sort.SliceStable(kept, func(i, j int) bool {
+			return kept[i].Value.Cmp(kept[j].Value) > 0
+		})
+		for len(kept) > 0 && total < amount+fee(len(selected)) {
+			selected = append(selected, kept[0])
+			total += satoshis(kept[0].Value)
+			kept = kept[1:]
+		}
+	}
+	if total < amount+fee(len(selected)) {
+		writeNamedError(w, http.StatusBadRequest, bitgo.ErrorNameInsufficientBalance, "insufficient funds")
+		return
+	}
+
+	outputs := make([]rawtx.Output, len(params.Recipients))
+	for i, rcpt := range params.Recipients {
+		a, err := bitgo.ParseAddress(wallet.Coin, rcpt.Address)
+		if err == nil {
+			outputs[i].Script, err = a.Script()
+		}
+		if err != nil {
+			writeNamedError(w, http.StatusBadRequest, bitgo.ErrorNameInvalidAddress, "invalid address "+rcpt.Address)
+			return
+		}
+		outputs[i].Value = rcpt.Amount
+	}
+	change := total - amount - fee(len(selected))
+	var changeAddress Address
+	if change > 0 {
+		changeAddress = s.newAddress(wallet, bitgo.ChainP2SHChange)
+		outputs = append(outputs, rawtx.Output{
+			Value:  bitgo.NewAmount(change),
+			Script: s.addressScript(wallet, changeAddress.Address),
+		})
+	}
+	txid, txHex, err := newTx(wallet.Coin, selected, outputs)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	date := s.now().UTC().Truncate(time.Millisecond)
+	inputs := make([]string, len(selected))
+	for i, u := range selected {
+		inputs[i] = u.ID
+	}
+	var outputIDs []string
+	if change > 0 {
+		u := bitgo.Unspent{
+			ID:          fmt.Sprintf("%s:%d", txid, len(params.Recipients)),
+			Address:     changeAddress.Address,
+			Value:       bitgo.NewAmount(change),
+			BlockHeight: UnconfirmedHeight,
+			Date:        date,
+			Wallet:      wallet.ID,
+			FromWallet:  wallet.ID,
+			Chain:       bitgo.Chain(changeAddress.Chain),
+			Index:       changeAddress.Index,
+		}
+		kept = append(kept, u)
+		outputIDs = append(outputIDs, u.ID)
+	}
+	sortUnspents(kept)
+	wallet.Unspents = kept
+	wallet.Balance -= amount + fee(len(selected))
+	wallet.Transfers = append(wallet.Transfers, Transfer{
+		ID:      s.id(),
+		Coin:    wallet.Coin,
+		Wallet:  wallet.ID,
+		TxID:    txid,
+		Type:    "send",
+		Value:   -(amount + fee(len(selected))),
+		Fee:     fee(len(selected)),
+		State:   "signed",
+		Date:    date.Format(time.RFC3339),
+		Inputs:  inputs,
+		Outputs: outputIDs,
+	})
+
+	writeJSON(w, http.StatusOK, bitgo.TxInfo{
+		TxID:   txid,
+		Tx:     txHex,
+		Status: "signed",
+	})
+}
+
+// createAddress creates a new receive address.
+func (s *Server) createAddress(w http.ResponseWriter, wallet *Wallet) {
+	writeJSON(w, http.StatusOK, s.newAddress(wallet, bitgo.ChainP2SH))
+}
+
+// newAddress creates a P2SH address on the given chain.
+// It pays to a hash of the address ID since the fake wallet has no keys.
+func (s *Server) newAddress(wallet *Wallet, chain bitgo.Chain) Address {
+	a := Address{
+		ID:     s.id(),
+		Chain:  int(chain),
+		Index:  len(wallet.Addresses),
+		Coin:   wallet.Coin,
+		Wallet: wallet.ID,
+	}
+	script := append(append([]byte{0xa9, 20}, bitgo.Hash160([]byte(a.ID))...), 0x87)
+	if addr, err := bitgo.ScriptAddress(wallet.Coin, script); err == nil {
+		a.Address = addr.String()
+	} else {
+		// Account based coins have no scripts.
+		a.Address = "2N" + s.txid()[:32]
+	}
+	wallet.Addresses = append(wallet.Addresses, a)
+	return a
+}
+
+// addressScript returns the output script of the wallet's address.
+func (s *Server) addressScript(wallet *Wallet, address string) []byte {
+	a, err := bitgo.ParseAddress(wallet.Coin, address)
+	if err != nil {
+		return nil
+	}
+	script, _ := a.Script()
+	return script
+}
+
+// newTx serializes a transaction spending the unspents, and returns its txid and hex.
+// The transaction is not signed since the fake wallet has no keys.
+func newTx(coin string, unspents []bitgo.Unspent, outputs []rawtx.Output) (txid, txHex string, err error) {
+	tx := rawtx.Tx{Coin: coin, Version: 1, Outputs: outputs}
+	for _, u := range unspents {
+		o, err := u.Outpoint()
+		if err != nil {
+			return "", "", err
+		}
+		tx.Inputs = append(tx.Inputs, rawtx.Input{Outpoint: o, Sequence: 0xffffffff})
+	}
+	raw, err := tx.Encode()
+	if err != nil {
+		return "", "", err
+	}
+	decoded, err := rawtx.Decode(coin, raw)
+	if err != nil {
+		return "", "", err
+	}
+	return decoded.TxID, hex.EncodeToString(raw), nil
+}
+
+// id returns a unique object ID.
+func (s *Server) id() string {
+	s.seq++
+	return fmt.Sprintf("%024x", s.seq)
+}
+
+// txid returns a unique transaction ID.
+func (s *Server) txid() string {
+	s.seq++
+	h := sha256.Sum256([]byte(strconv.Itoa(s.seq)))
+	return hex.EncodeToString(h[:])
+}
+
+func (s *Server) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+// filter selects unspents by value, height, confirmations and IDs.
+type filter struct {
+	minValue    int64
+	maxValue    int64
+	minHeight   int64
+	minConfirms int64
+	// ids are the only unspents to select if not empty.
+	ids map[string]bool
+}
+
+// idSet returns a set of the unspent IDs, nil if there are none.
+func idSet(ids []string) map[string]bool {
+	if len(ids) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+// hasUnspents reports whether all the unspents in the set belong to the wallet.
+func hasUnspents(wallet *Wallet, ids map[string]bool) bool {
+	found := 0
+	for _, u := range wallet.Unspents {
+		if ids[u.ID] {
+			found++
+		}
+	}
+	return found == len(ids)
+}
+
+func parseFilter(minValue, maxValue, minHeight, minConfirms string) (filter, error) {
+	f := filter{}
+	for _, p := range []struct {
+		name  string
+		value string
+		dst   *int64
+	}{
+		{"minValue", minValue, &f.minValue},
+		{"maxValue", maxValue, &f.maxValue},
+		{"minHeight", minHeight, &f.minHeight},
+		{"minConfirms", minConfirms, &f.minConfirms},
+	} {
+		if p.value == "" {
+			continue
+		}
+		v, err := strconv.ParseInt(p.value, 10, 64)
+		if err != nil {
+			return f, fmt.Errorf("invalid %s", p.name)
+		}
+		*p.dst = v
+	}
+	return f, nil
+}
+
+// match reports whether the unspent passes the filter given the chain tip height.
+func (f filter) match(u bitgo.Unspent, tip int64) bool {
+	if f.ids != nil && !f.ids[u.ID] {
+		return false
+	}
+	if f.minValue > 0 && satoshis(u.Value) < f.minValue {
+		return false
+	}
+	if f.maxValue > 0 && satoshis(u.Value) > f.maxValue {
+		return false
+	}
+	if f.minHeight > 0 && (u.BlockHeight < f.minHeight || !u.IsConfirmed()) {
+		return false
+	}
+	if f.minConfirms > 0 && u.Confirmations(tip) < f.minConfirms {
+		return false
+	}
+	return true
+}
+
+// satoshis returns the amount of a wallet unspent in satoshis.
+// The fake server supports only UTXO coins whose amounts fit into int64,
+// so it panics on a fixture which doesn't fit, see AddUnspents.
+// Amounts of request bodies are validated by the handlers instead.
+func satoshis(a bitgo.Amount) int64 {
+	n, ok := a.Int64()
+	if !ok {
+		panic(fmt.Sprintf("bitgotest: amount %s is too large", a))
+	}
+	return n
+}
+
+func sortUnspents(uu []bitgo.Unspent) {
+	sort.Slice(uu, func(i, j int) bool {
+		return uu[i].ID < uu[j].ID
+	})
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeRaw(w, statusCode, string(b))
+}
+
+func writeError(w http.ResponseWriter, statusCode int, msg string) {
+	writeRaw(w, statusCode, errorBody(statusCode, msg))
+}
+
+// writeNamedError writes an error with BitGo's error name, e.g., bitgo.ErrorNameInsufficientBalance.
+func writeNamedError(w http.ResponseWriter, statusCode int, name, msg string) {
+	writeRaw(w, statusCode, namedErrorBody(name, msg))
+}
+
+// errorBody returns a BitGo-like JSON error named after the status code.
+func errorBody(statusCode int, msg string) string {
+	return namedErrorBody(strings.Replace(http.StatusText(statusCode), " ", "", -1), msg)
+}
+
+func namedErrorBody(name, msg string) string {
+	b, _ := json.Marshal(map[string]string{
+		"error":     msg,
+		"message":   msg,
+		"name":      name,
+		"requestId": "fakebitgo",
+	})
+	return string(b)
+}
+
+func writeRaw(w http.ResponseWriter, statusCode int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	fmt.Fprintln(w, body)
+}
@@ -0,0 +1,275 @@
+package bitgotest_test
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/marselester/bitgo-v2"
+	"github.com/marselester/bitgo-v2/bitgotest"
+	"github.com/marselester/bitgo-v2/coinselect"
+	"github.com/marselester/bitgo-v2/consolidation"
+	"github.com/marselester/bitgo-v2/rawtx"
+)
+
+const walletID = "585951a5df8380e0e3063e9f"
+
+func TestUnspentsPagination(t *testing.T) {
+	fake := bitgotest.NewServer()
+	fake.AddWallet("tbtc", walletID, "")
+	fake.AddUnspents(walletID, bitgotest.Dust(250, 546)...)
+	c := fake.Client(t)
+
+	pages, total := 0, 0
+	seen := make(map[string]bool)
+	err := c.Wallet.Unspents(context.Background(), walletID, url.Values{}, func(list *bitgo.UnspentList) {
+		pages++
+		for _, u := range list.Unspents {
+			if seen[u.ID] {
+				t.Fatalf("unspent %s is listed twice", u.ID)
+			}
+			seen[u.ID] = true
+			total++
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pages != 3 || total != 250 {
+		t.Errorf("expected 250 unspents in 3 pages, got %d in %d", total, pages)
+	}
+}
+
+func TestConsolidate(t *testing.T) {
+	fake := bitgotest.NewServer()
+	fake.AddWallet("tbtc", walletID, "root")
+	fake.AddUnspents(walletID, bitgotest.Dust(30, 1000)...)
+	fake.AddUnspents(walletID, bitgotest.Dust(5, 100000)...)
+	c := fake.Client(t)
+
+	params := bitgo.WalletConsolidateParams{
+		WalletPassphrase: "root",
+		MaxValue:         bitgo.NewAmount(1000),
+		Limit:            20,
+		FeeRate:          10,
+	}
+	tx, err := c.Wallet.Consolidate(context.Background(), walletID, &params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, _ := fake.Wallet(walletID)
+	if len(w.Unspents) != 30-20+5+1 {
+		t.Fatalf("expected 16 unspents after consolidation, got %d", len(w.Unspents))
+	}
+	if len(w.Transfers) != 1 || w.Transfers[0].TxID != tx.TxID || len(w.Transfers[0].Inputs) != 20 {
+		t.Fatalf("unexpected transfers %+v", w.Transfers)
+	}
+	wantBalance := int64(30*1000+5*100000) - w.Transfers[0].Fee
+	if w.Balance != wantBalance {
+		t.Errorf("expected balance %d, got %d", wantBalance, w.Balance)
+	}
+
+	decoded, err := rawtx.DecodeTxInfo("tbtc", tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Inputs) != 20 || len(decoded.Outputs) != 1 || decoded.Outputs[0].Address.String() != w.Addresses[0].Address {
+		t.Errorf("unexpected consolidation tx %+v", decoded)
+	}
+	if decoded.OutputValue().Cmp(bitgo.NewAmount(20*1000-w.Transfers[0].Fee)) != 0 {
+		t.Errorf("unexpected consolidated value %s", decoded.OutputValue())
+	}
+
+	params.WalletPassphrase = "wrong"
+	_, err = c.Wallet.Consolidate(context.Background(), walletID, &params)
+	if e, ok := err.(bitgo.Error); !ok || !e.IsUnauthorized() {
+		t.Errorf("expected unauthorized error, got %v", err)
+	}
+}
+
+func TestConsolidatePinned(t *testing.T) {
+	fake := bitgotest.NewServer()
+	fake.AddWallet("tbtc", walletID, "")
+	fake.AddUnspents(walletID, bitgotest.Dust(10, 1000)...)
+	fake.AddUnspents(walletID, bitgotest.Dust(1, 100000)...)
+	c := fake.Client(t)
+
+	// The large unspent is pinned too, but it is filtered out by MaxValue.
+	w, _ := fake.Wallet(walletID)
+	var pinned []string
+	for _, u := range w.Unspents {
+		if u.Value.Cmp(bitgo.NewAmount(1000)) > 0 || len(pinned) < 3 {
+			pinned = append(pinned, u.ID)
+		}
+	}
+	params := bitgo.WalletConsolidateParams{
+		MaxValue: bitgo.NewAmount(1000),
+		FeeRate:  10,
+		Unspents: pinned,
+	}
+	sim, err := consolidation.Simulate(context.Background(), c, walletID, &params, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sim.Inputs) != 3 {
+		t.Fatalf("expected 3 pinned inputs to be simulated, got %d", len(sim.Inputs))
+	}
+	if _, err = c.Wallet.Consolidate(context.Background(), walletID, &params); err != nil {
+		t.Fatal(err)
+	}
+
+	w, _ = fake.Wallet(walletID)
+	spent := make(map[string]bool)
+	for _, id := range w.Transfers[0].Inputs {
+		spent[id] = true
+	}
+	if len(spent) != 3 {
+		t.Errorf("expected 3 of pinned unspents %v to be spent, got %v", pinned, w.Transfers[0].Inputs)
+	}
+	if len(w.Unspents) != 11-3+1 {
+		t.Errorf("expected 9 unspents after consolidation, got %d", len(w.Unspents))
+	}
+
+	params.Unspents = []string{strings.Repeat("ab", 32) + ":0"}
+	_, err = c.Wallet.Consolidate(context.Background(), walletID, &params)
+	if e, ok := err.(bitgo.Error); !ok || !e.IsInvalidRequest() {
+		t.Errorf("expected unknown unspent error, got %v", err)
+	}
+	params.Unspents = []string{"ab"}
+	if _, err = c.Wallet.Consolidate(context.Background(), walletID, &params); err == nil {
+		t.Error("expected invalid unspent ID error")
+	}
+}
+
+func TestConsolidateNothingLeft(t *testing.T) {
+	fake := bitgotest.NewServer()
+	fake.AddWallet("tbtc", walletID, "")
+	fake.AddUnspents(walletID, bitgotest.Dust(1, 1000)...)
+	c := fake.Client(t)
+
+	_, err := c.Wallet.Consolidate(context.Background(), walletID, &bitgo.WalletConsolidateParams{})
+	if e, ok := err.(bitgo.Error); !ok || !e.IsInvalidRequest() || !e.IsNothingToConsolidate() {
+		t.Errorf("expected nothing to consolidate error, got %v", err)
+	}
+}
+
+func TestOverlargeAmounts(t *testing.T) {
+	fake := bitgotest.NewServer()
+	fake.AddWallet("tbtc", walletID, "")
+	fake.AddUnspents(walletID, bitgotest.Dust(10, 1000)...)
+	c := fake.Client(t)
+
+	huge := bitgo.NewAmountFromBig(new(big.Int).Lsh(big.NewInt(1), 70))
+	_, err := c.Wallet.Consolidate(context.Background(), walletID, &bitgo.WalletConsolidateParams{MinValue: huge})
+	if e, ok := err.(bitgo.Error); !ok || !e.IsInvalidRequest() {
+		t.Errorf("expected invalid min value, got %v", err)
+	}
+
+	const to = "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7"
+	tests := [][]bitgo.Amount{
+		{huge},
+		// Every amount fits into int64, but their total doesn't.
+		{bitgo.NewAmount(math.MaxInt64), bitgo.NewAmount(math.MaxInt64)},
+	}
+	for _, amounts := range tests {
+		var params bitgo.SendManyParams
+		for _, a := range amounts {
+			params.Recipients = append(params.Recipients, bitgo.Recipient{Address: to, Amount: a})
+		}
+		_, err = c.Wallet.SendMany(context.Background(), walletID, &params)
+		if e, ok := err.(bitgo.Error); !ok || !e.IsInvalidRequest() {
+			t.Errorf("%v: expected invalid recipient amount, got %v", amounts, err)
+		}
+	}
+}
+
+func TestSendMany(t *testing.T) {
+	fake := bitgotest.NewServer()
+	fake.AddWallet("tbtc", walletID, "root")
+	fake.AddUnspents(walletID, bitgotest.Dust(10, 1000)...)
+	fake.AddUnspents(walletID, bitgotest.Dust(2, 100000)...)
+	c := fake.Client(t)
+
+	// Inputs are pinned to pay with small unspents.
+	var unspents []bitgo.Unspent
+	err := c.Wallet.Unspents(context.Background(), walletID, nil, func(list *bitgo.UnspentList) {
+		unspents = append(unspents, list.Unspents...)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fee := coinselect.FeeModel{FeeRate: 10, Outputs: []int{43}}
+	sel, err := coinselect.SmallestFirst.Select(unspents, bitgo.NewAmount(5000), &fee)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := bitgo.SendManyParams{
+		WalletPassphrase: "root",
+		Recipients: []bitgo.Recipient{
+			{Address: "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", Amount: bitgo.NewAmount(5000)},
+		},
+		FeeRate:  10,
+		Unspents: sel.IDs(),
+	}
+	tx, err := c.Wallet.SendMany(context.Background(), walletID, &params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, _ := fake.Wallet(walletID)
+	if len(w.Transfers) != 1 || w.Transfers[0].TxID != tx.TxID {
+		t.Fatalf("unexpected transfers %+v", w.Transfers)
+	}
+	if got := strings.Join(w.Transfers[0].Inputs, " "); got != strings.Join(sel.IDs(), " ") {
+		t.Errorf("expected inputs %v, got %s", sel.IDs(), got)
+	}
+	wantBalance := int64(10*1000+2*100000) - 5000 - w.Transfers[0].Fee
+	if w.Balance != wantBalance {
+		t.Errorf("expected balance %d, got %d", wantBalance, w.Balance)
+	}
+
+	decoded, err := rawtx.DecodeTxInfo("tbtc", tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txFee, err := decoded.Fee(unspents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if txFee.Cmp(bitgo.NewAmount(w.Transfers[0].Fee)) != 0 || decoded.Outputs[0].Address.String() != params.Recipients[0].Address {
+		t.Errorf("unexpected tx %+v with fee %s", decoded, txFee)
+	}
+
+	params.Unspents = sel.IDs()
+	if _, err = c.Wallet.SendMany(context.Background(), walletID, &params); err == nil {
+		t.Error("expected error when spending the spent unspents")
+	}
+}
+
+func TestInjectFault(t *testing.T) {
+	fake := bitgotest.NewServer()
+	fake.AddWallet("tbtc", walletID, "")
+	fake.AddUnspents(walletID, bitgotest.Dust(3, 1000)...)
+	fake.InjectFault(bitgotest.Fault{
+		Method:     http.MethodGet,
+		Path:       "unspents",
+		StatusCode: http.StatusTooManyRequests,
+		Count:      1,
+	})
+	c := fake.Client(t)
+
+	f := func(*bitgo.UnspentList) {}
+	err := c.Wallet.Unspents(context.Background(), walletID, url.Values{}, f)
+	if e, ok := err.(bitgo.Error); !ok || !e.IsRateLimited() {
+		t.Fatalf("expected rate limit error, got %v", err)
+	}
+	if err = c.Wallet.Unspents(context.Background(), walletID, url.Values{}, f); err != nil {
+		t.Fatalf("fault must be injected once: %v", err)
+	}
+}
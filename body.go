@@ -0,0 +1,117 @@
+package bitgo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxLoggedBodySize is how many bytes of a successful response body are logged.
+const maxLoggedBodySize = 64 << 10
+
+var (
+	// ErrResponseTooLarge is wrapped in RequestError when a response body exceeds the limit set by WithMaxResponseSize.
+	ErrResponseTooLarge = errors.New("bitgo: response body is too large")
+	// ErrResponseTruncated is wrapped in RequestError when a response body ends before JSON is complete.
+	ErrResponseTruncated = errors.New("bitgo: response body is truncated")
+)
+
+// decodeJSON stream-decodes a single JSON value from r into v.
+// It returns an operation which failed (OpRead or OpDecode) along with the error.
+func decodeJSON(r io.Reader, v interface{}) (string, error) {
+	dec := json.NewDecoder(r)
+	if err := dec.Decode(v); err != nil {
+		return classifyDecodeError(err)
+	}
+	// Anything but whitespace after the JSON value makes the body invalid.
+	if _, err := dec.Token(); err != io.EOF {
+		if err == nil {
+			return OpDecode, fmt.Errorf("unexpected data after top-level value")
+		}
+		return classifyDecodeError(err)
+	}
+	return "", nil
+}
+
+// classifyDecodeError tells JSON errors from body read errors.
+func classifyDecodeError(err error) (string, error) {
+	var (
+		syntaxErr    *json.SyntaxError
+		typeErr      *json.UnmarshalTypeError
+		unmarshalErr *json.InvalidUnmarshalError
+	)
+	switch {
+	case errors.Is(err, ErrResponseTooLarge):
+		return OpRead, err
+	case err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF):
+		return OpDecode, fmt.Errorf("%w: %v", ErrResponseTruncated, err)
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr), errors.As(err, &unmarshalErr):
+		return OpDecode, err
+	}
+	// Body read errors are wrapped by maxBytesReader to tell them apart
+	// from errors returned by json.Unmarshaler implementations.
+	var re *readError
+	if errors.As(err, &re) {
+		return OpRead, re.err
+	}
+	return OpDecode, err
+}
+
+// readError wraps errors of the underlying body reader.
+type readError struct {
+	err error
+}
+
+func (e *readError) Error() string {
+	return e.err.Error()
+}
+
+func (e *readError) Unwrap() error {
+	return e.err
+}
+
+// maxBytesReader reads at most n bytes from r and fails with ErrResponseTooLarge if there is more.
+type maxBytesReader struct {
+	r io.Reader
+	// n is how many bytes can be read.
+	n int64
+}
+
+func (l *maxBytesReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	if int64(n) > l.n {
+		n = int(l.n)
+		l.n = 0
+		return n, ErrResponseTooLarge
+	}
+	l.n -= int64(n)
+	if err != nil && err != io.EOF && !errors.Is(err, io.ErrUnexpectedEOF) {
+		err = &readError{err: err}
+	}
+	return n, err
+}
+
+// limitedBuffer keeps at most max bytes written to it and discards the rest.
+type limitedBuffer struct {
+	max int
+	b   []byte
+}
+
+func (w *limitedBuffer) Write(p []byte) (int, error) {
+	if room := w.max - len(w.b); room > 0 {
+		if len(p) < room {
+			room = len(p)
+		}
+		w.b = append(w.b, p[:room]...)
+	}
+	return len(p), nil
+}
+
+// Bytes returns the kept bytes.
+func (w *limitedBuffer) Bytes() []byte {
+	return w.b
+}
@@ -0,0 +1,112 @@
+package bitgo_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/marselester/bitgo-v2"
+)
+
+func TestResponseSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		wantOp  string
+		wantErr error
+	}{
+		{
+			name: "content length exceeds limit",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"txid":"` + strings.Repeat("a", 100) + `"}`))
+			},
+			wantOp:  bitgo.OpRead,
+			wantErr: bitgo.ErrResponseTooLarge,
+		},
+		{
+			name: "chunked body exceeds limit",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"txid":"`))
+				w.(http.Flusher).Flush()
+				w.Write([]byte(strings.Repeat("a", 100) + `"}`))
+			},
+			wantOp:  bitgo.OpRead,
+			wantErr: bitgo.ErrResponseTooLarge,
+		},
+		{
+			name: "error body exceeds limit",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, strings.Repeat("a", 100), http.StatusBadGateway)
+			},
+			wantOp:  bitgo.OpRead,
+			wantErr: bitgo.ErrResponseTooLarge,
+		},
+		{
+			name: "truncated body",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"txid":"5885a7e6`))
+			},
+			wantOp:  bitgo.OpDecode,
+			wantErr: bitgo.ErrResponseTruncated,
+		},
+		{
+			name: "empty body",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+			},
+			wantOp:  bitgo.OpDecode,
+			wantErr: bitgo.ErrResponseTruncated,
+		},
+		{
+			name: "trailing data",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"txid":"5885a7e6"} {}`))
+			},
+			wantOp:  bitgo.OpDecode,
+			wantErr: bitgo.ErrDecode,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := httptest.NewServer(test.handler)
+			defer srv.Close()
+
+			c := bitgo.NewClient(
+				bitgo.WithBaseURL(srv.URL),
+				bitgo.WithProductionSpending(),
+				bitgo.WithMaxResponseSize(64),
+			)
+			_, err := c.Wallet.Consolidate(context.Background(), "", nil)
+			var reqErr *bitgo.RequestError
+			if !errors.As(err, &reqErr) || reqErr.Op != test.wantOp {
+				t.Fatalf("expected %s request error, got %#v", test.wantOp, err)
+			}
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestResponseWithinLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"txid":"5885a7e6"}` + "\n"))
+	}))
+	defer srv.Close()
+
+	c := bitgo.NewClient(
+		bitgo.WithBaseURL(srv.URL),
+		bitgo.WithProductionSpending(),
+		bitgo.WithMaxResponseSize(20),
+	)
+	tx, err := c.Wallet.Consolidate(context.Background(), "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.TxID != "5885a7e6" {
+		t.Fatalf("unexpected tx %#v", tx)
+	}
+}
@@ -0,0 +1,93 @@
+package bitgo
+
+import (
+	"strings"
+)
+
+// Cashaddr version byte types, the size bits are zero for 160-bit hashes.
+const (
+	cashAddrP2PKH byte = 0 << 3
+	cashAddrP2SH  byte = 1 << 3
+)
+
+// cashAddrPolymod computes the 40-bit BCH checksum of 5-bit values.
+func cashAddrPolymod(values []byte) uint64 {
+	gen := [5]uint64{0x98f2bc8e61, 0x79b76d99e2, 0xf33e5fb3c4, 0xae2eabe2a8, 0x1e4f43e470}
+	c := uint64(1)
+	for _, v := range values {
+		c0 := byte(c >> 35)
+		c = (c&0x07ffffffff)<<5 ^ uint64(v)
+		for i := 0; i < 5; i++ {
+			if (c0>>i)&1 == 1 {
+				c ^= gen[i]
+			}
+		}
+	}
+	return c ^ 1
+}
+
+// cashAddrPrefixExpand returns the lower 5 bits of each prefix character followed by a zero separator.
+func cashAddrPrefixExpand(prefix string) []byte {
+	b := make([]byte, 0, len(prefix)+1)
+	for i := 0; i < len(prefix); i++ {
+		b = append(b, prefix[i]&31)
+	}
+	return append(b, 0)
+}
+
+// cashAddrEncode encodes the version byte and hash into a cashaddr with the prefix, e.g., "bitcoincash:q...".
+func cashAddrEncode(prefix string, version byte, hash []byte) string {
+	data, _ := convertBits(append([]byte{version}, hash...), 8, 5, true)
+	values := append(cashAddrPrefixExpand(prefix), data...)
+	mod := cashAddrPolymod(append(values, make([]byte, 8)...))
+
+	var sb strings.Builder
+	sb.WriteString(prefix)
+	sb.WriteByte(':')
+	for _, d := range data {
+		sb.WriteByte(bech32Charset[d])
+	}
+	for i := 0; i < 8; i++ {
+		sb.WriteByte(bech32Charset[(mod>>(5*(7-i)))&31])
+	}
+	return sb.String()
+}
+
+// cashAddrDecode decodes a cashaddr into its lowercase prefix, version byte and hash.
+// The defaultPrefix is assumed when the address has no prefix.
+func cashAddrDecode(s, defaultPrefix string) (prefix string, version byte, hash []byte, err error) {
+	lower := strings.ToLower(s)
+	if s != lower && s != strings.ToUpper(s) {
+		return "", 0, nil, ErrAddressFormat
+	}
+	prefix, payload, ok := strings.Cut(lower, ":")
+	if !ok {
+		prefix, payload = defaultPrefix, lower
+	}
+	if prefix == "" || len(payload) <= 8 {
+		return "", 0, nil, ErrAddressFormat
+	}
+
+	data := make([]byte, len(payload))
+	for i := 0; i < len(payload); i++ {
+		d := strings.IndexByte(bech32Charset, payload[i])
+		if d < 0 {
+			return "", 0, nil, ErrAddressFormat
+		}
+		data[i] = byte(d)
+	}
+	if cashAddrPolymod(append(cashAddrPrefixExpand(prefix), data...)) != 0 {
+		return "", 0, nil, ErrAddressChecksum
+	}
+
+	b, ok := convertBits(data[:len(data)-8], 5, 8, false)
+	if !ok || len(b) < 1 {
+		return "", 0, nil, ErrAddressFormat
+	}
+	version, hash = b[0], b[1:]
+	// Only 160-bit hashes are used by P2PKH and P2SH addresses.
+	if version&7 != 0 || len(hash) != 20 {
+		return "", 0, nil, ErrAddressFormat
+	}
+	return prefix, version, hash, nil
+}
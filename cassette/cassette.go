@@ -0,0 +1,234 @@
+// Package cassette records HTTP interactions with BitGo API into JSON files (cassettes)
+// and replays them in tests of code built on the bitgo client.
+//
+// Access tokens and wallet passphrases are redacted with bitgo.RedactJSON, bitgo.RedactHeader and bitgo.RedactURL
+// before anything is written to disk.
+//
+//	rec := cassette.NewRecorder("testdata/consolidate.json", nil)
+//	c := bitgo.NewClient(bitgo.WithHTTPClient(&http.Client{Transport: rec}))
+//	...
+//	err := rec.Save()
+//
+// Later tests replay the cassette without network access.
+//
+//	rep, err := cassette.NewReplayer("testdata/consolidate.json")
+//	c := bitgo.NewClient(bitgo.WithHTTPClient(&http.Client{Transport: rep}))
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/marselester/bitgo-v2"
+)
+
+// Request is a recorded HTTP request.
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Path   string      `json:"path"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response is a recorded HTTP response.
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Interaction is a recorded request/response pair.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette is a list of recorded interactions.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Load reads a cassette from a JSON file.
+func Load(filename string) (*Cassette, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	c := Cassette{}
+	if err = json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("cassette: failed to decode %s: %v", filename, err)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette to a JSON file.
+// Interactions are expected to be redacted already.
+func (c *Cassette) Save(filename string) error {
+	b, err := json.MarshalIndent(c, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, append(b, '\n'), 0644)
+}
+
+// Recorder is an http.RoundTripper that sends requests using the underlying transport
+// and records redacted request/response pairs.
+type Recorder struct {
+	filename  string
+	transport http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder returns a Recorder which saves interactions to filename.
+// If transport is nil, http.DefaultTransport is used.
+func NewRecorder(filename string, transport http.RoundTripper) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Recorder{
+		filename:  filename,
+		transport: transport,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	i := Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    bitgo.RedactURL(req.URL),
+			Path:   req.URL.Path,
+			Header: bitgo.RedactHeader(req.Header),
+			Body:   string(bitgo.RedactJSON(reqBody)),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     bitgo.RedactHeader(resp.Header),
+			Body:       string(bitgo.RedactJSON(respBody)),
+		},
+	}
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, i)
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+// Save writes recorded interactions to the cassette file.
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cassette.Save(r.filename)
+}
+
+// Replayer is an http.RoundTripper that serves responses from a cassette.
+// A request is matched by method, path and body (redacted the same way as when it was recorded).
+// Interactions are replayed in the recorded order, e.g.,
+// pages of unspents are served one after another for the same request.
+type Replayer struct {
+	mu       sync.Mutex
+	cassette *Cassette
+	replayed []bool
+}
+
+// NewReplayer returns a Replayer serving interactions from the cassette file.
+func NewReplayer(filename string) (*Replayer, error) {
+	c, err := Load(filename)
+	if err != nil {
+		return nil, err
+	}
+	return NewCassetteReplayer(c), nil
+}
+
+// NewCassetteReplayer returns a Replayer serving interactions from the cassette.
+func NewCassetteReplayer(c *Cassette) *Replayer {
+	return &Replayer{
+		cassette: c,
+		replayed: make([]bool, len(c.Interactions)),
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+	body = bitgo.RedactJSON(body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, rec := range r.cassette.Interactions {
+		if r.replayed[i] || rec.Request.Method != req.Method || rec.Request.Path != req.URL.Path {
+			continue
+		}
+		if !bodyEqual([]byte(rec.Request.Body), body) {
+			continue
+		}
+		r.replayed[i] = true
+
+		header := rec.Response.Header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", rec.Response.StatusCode, http.StatusText(rec.Response.StatusCode)),
+			StatusCode:    rec.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(strings.NewReader(rec.Response.Body)),
+			ContentLength: int64(len(rec.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("cassette: no interaction for %s %s", req.Method, req.URL.Path)
+}
+
+// bodyEqual compares request bodies ignoring JSON formatting.
+func bodyEqual(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var x, y interface{}
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return false
+	}
+	xb, _ := json.Marshal(x)
+	yb, _ := json.Marshal(y)
+	return bytes.Equal(xb, yb)
+}
@@ -0,0 +1,100 @@
+package cassette_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/marselester/bitgo-v2"
+	"github.com/marselester/bitgo-v2/cassette"
+)
+
+func TestRecordReplay(t *testing.T) {
+	content, err := ioutil.ReadFile(filepath.Join("..", "testdata", "consolidateunspents.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(content)
+	}))
+	defer srv.Close()
+
+	filename := filepath.Join(t.TempDir(), "consolidate.json")
+	params := &bitgo.WalletConsolidateParams{
+		WalletPassphrase: "root",
+		FeeRate:          5000,
+	}
+
+	rec := cassette.NewRecorder(filename, nil)
+	c := bitgo.NewClient(
+		bitgo.WithBaseURL(srv.URL),
+		bitgo.WithProductionSpending(),
+		bitgo.WithAccesToken("swordfish"),
+		bitgo.WithHTTPClient(&http.Client{Transport: rec}),
+	)
+	want, err := c.Wallet.Consolidate(context.Background(), "585951a5df8380e0e3063e9f", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"swordfish", `"root"`} {
+		if bytes.Contains(saved, []byte(secret)) {
+			t.Errorf("cassette contains secret %s:\n%s", secret, saved)
+		}
+	}
+
+	rep, err := cassette.NewReplayer(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = bitgo.NewClient(
+		bitgo.WithBaseURL("http://bitgo.invalid"),
+		bitgo.WithProductionSpending(),
+		bitgo.WithHTTPClient(&http.Client{Transport: rep}),
+	)
+	got, err := c.Wallet.Consolidate(context.Background(), "585951a5df8380e0e3063e9f", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *want {
+		t.Fatalf("should be %#v, not %#v", want, got)
+	}
+
+	// The interaction was already replayed.
+	if _, err = c.Wallet.Consolidate(context.Background(), "585951a5df8380e0e3063e9f", params); err == nil {
+		t.Fatal("expected no interaction error")
+	}
+}
+
+func TestReplayMismatch(t *testing.T) {
+	rep := cassette.NewCassetteReplayer(&cassette.Cassette{
+		Interactions: []cassette.Interaction{
+			{
+				Request: cassette.Request{
+					Method: http.MethodPost,
+					Path:   "/api/v2/btc/wallet/585951a5df8380e0e3063e9f/consolidateunspents",
+					Body:   `{"feeRate":5000}`,
+				},
+				Response: cassette.Response{StatusCode: http.StatusOK, Body: `{}`},
+			},
+		},
+	})
+	c := bitgo.NewClient(
+		bitgo.WithHTTPClient(&http.Client{Transport: rep}),
+	)
+	_, err := c.Wallet.Consolidate(context.Background(), "585951a5df8380e0e3063e9f", &bitgo.WalletConsolidateParams{FeeRate: 1000})
+	if err == nil {
+		t.Fatal("expected body mismatch error")
+	}
+}
@@ -0,0 +1,134 @@
+package bitgo
+
+import (
+	"strconv"
+)
+
+// Chain is an address chain of a BitGo wallet. It defines the script type of an address
+// and whether the address receives coins or change.
+// For more details, see https://github.com/BitGo/unspents/blob/master/src/codes.ts.
+type Chain int
+
+// Known address chains, even chains are external (receive) and odd ones are internal (change).
+const (
+	ChainP2SH             Chain = 0
+	ChainP2SHChange       Chain = 1
+	ChainP2SHP2WSH        Chain = 10
+	ChainP2SHP2WSHChange  Chain = 11
+	ChainP2WSH            Chain = 20
+	ChainP2WSHChange      Chain = 21
+	ChainP2TR             Chain = 30
+	ChainP2TRChange       Chain = 31
+	ChainP2TRMusig2       Chain = 40
+	ChainP2TRMusig2Change Chain = 41
+)
+
+// Script types of the chains as named by BitGo API.
+const (
+	ScriptTypeP2SH       = "p2sh"
+	ScriptTypeP2SHP2WSH  = "p2shP2wsh"
+	ScriptTypeP2WSH      = "p2wsh"
+	ScriptTypeP2TR       = "p2tr"
+	ScriptTypeP2TRMusig2 = "p2trMusig2"
+)
+
+// chainScriptTypes maps external chains to their script types.
+var chainScriptTypes = map[Chain]string{
+	ChainP2SH:       ScriptTypeP2SH,
+	ChainP2SHP2WSH:  ScriptTypeP2SHP2WSH,
+	ChainP2WSH:      ScriptTypeP2WSH,
+	ChainP2TR:       ScriptTypeP2TR,
+	ChainP2TRMusig2: ScriptTypeP2TRMusig2,
+}
+
+// chainInputVSizes are estimated virtual sizes in vbytes of inputs spending 2-of-3 multisig outputs.
+// Taproot chains are spent with a 2-of-2 script path (p2tr) or a MuSig2 key path (p2trMusig2).
+var chainInputVSizes = map[Chain]int{
+	ChainP2SH:       298,
+	ChainP2SHP2WSH:  140,
+	ChainP2WSH:      105,
+	ChainP2TR:       108,
+	ChainP2TRMusig2: 58,
+}
+
+// chainOutputVSizes are virtual sizes in vbytes of outputs paying to addresses of the chains.
+var chainOutputVSizes = map[Chain]int{
+	ChainP2SH:       32,
+	ChainP2SHP2WSH:  32,
+	ChainP2WSH:      43,
+	ChainP2TR:       43,
+	ChainP2TRMusig2: 43,
+}
+
+// IsValid reports whether the chain is known.
+func (c Chain) IsValid() bool {
+	_, ok := chainScriptTypes[c&^1]
+	return ok && c >= 0
+}
+
+// IsChange reports whether the chain is used for change addresses.
+func (c Chain) IsChange() bool {
+	return c%2 == 1
+}
+
+// IsSegwit reports whether the chain's script is spent with a witness (wrapped segwit, native segwit, and taproot).
+func (c Chain) IsSegwit() bool {
+	return c.IsValid() && c >= ChainP2SHP2WSH
+}
+
+// IsTaproot reports whether the chain's addresses are taproot outputs (bech32m).
+func (c Chain) IsTaproot() bool {
+	return c.IsValid() && c >= ChainP2TR
+}
+
+// ScriptType returns a script type of the chain, e.g., "p2shP2wsh", or an empty string if the chain is unknown.
+func (c Chain) ScriptType() string {
+	if !c.IsValid() {
+		return ""
+	}
+	return chainScriptTypes[c&^1]
+}
+
+// InputVSize returns an estimated virtual size in vbytes of a transaction input
+// which spends a BitGo 2-of-3 multisig unspent of the chain.
+// Unknown chains are estimated as P2SH, the largest input, so fees aren't underestimated.
+func (c Chain) InputVSize() int {
+	if !c.IsValid() {
+		return chainInputVSizes[ChainP2SH]
+	}
+	return chainInputVSizes[c&^1]
+}
+
+// OutputVSize returns a virtual size in vbytes of a transaction output
+// which pays to an address of the chain, or zero if the chain is unknown.
+func (c Chain) OutputVSize() int {
+	if !c.IsValid() {
+		return 0
+	}
+	return chainOutputVSizes[c&^1]
+}
+
+// VarIntSize returns the size in bytes of n encoded as Bitcoin's variable length integer,
+// e.g., a number of transaction inputs or outputs.
+func VarIntSize(n int) int {
+	switch {
+	case n < 0xfd:
+		return 1
+	case n <= 0xffff:
+		return 3
+	default:
+		return 5
+	}
+}
+
+// String returns the chain's script type and whether it is change, e.g., "p2wsh/change".
+func (c Chain) String() string {
+	s := c.ScriptType()
+	if s == "" {
+		return "chain(" + strconv.Itoa(int(c)) + ")"
+	}
+	if c.IsChange() {
+		return s + "/change"
+	}
+	return s + "/receive"
+}
@@ -0,0 +1,59 @@
+package bitgo_test
+
+import (
+	"testing"
+
+	"github.com/marselester/bitgo-v2"
+)
+
+func TestChain(t *testing.T) {
+	tests := []struct {
+		chain      bitgo.Chain
+		valid      bool
+		change     bool
+		segwit     bool
+		taproot    bool
+		scriptType string
+		str        string
+	}{
+		{bitgo.ChainP2SH, true, false, false, false, "p2sh", "p2sh/receive"},
+		{bitgo.ChainP2SHChange, true, true, false, false, "p2sh", "p2sh/change"},
+		{bitgo.ChainP2SHP2WSHChange, true, true, true, false, "p2shP2wsh", "p2shP2wsh/change"},
+		{bitgo.ChainP2WSH, true, false, true, false, "p2wsh", "p2wsh/receive"},
+		{bitgo.ChainP2TRChange, true, true, true, true, "p2tr", "p2tr/change"},
+		{bitgo.ChainP2TRMusig2, true, false, true, true, "p2trMusig2", "p2trMusig2/receive"},
+		{2, false, false, false, false, "", "chain(2)"},
+		{-1, false, false, false, false, "", "chain(-1)"},
+		{12, false, false, false, false, "", "chain(12)"},
+		{99, false, true, false, false, "", "chain(99)"},
+	}
+	for _, test := range tests {
+		c := test.chain
+		if c.IsValid() != test.valid || c.ScriptType() != test.scriptType || c.String() != test.str {
+			t.Errorf("chain %d: unexpected valid %t, script type %q, string %q", c, c.IsValid(), c.ScriptType(), c)
+		}
+		if c.InputVSize() <= 0 || test.valid != (c.OutputVSize() > 0) {
+			t.Errorf("chain %d: unexpected input vsize %d, output vsize %d", c, c.InputVSize(), c.OutputVSize())
+		}
+		// Unknown chains are estimated as P2SH, the largest input.
+		if !test.valid && c.InputVSize() != bitgo.ChainP2SH.InputVSize() {
+			t.Errorf("chain %d: input vsize %d, want P2SH %d", c, c.InputVSize(), bitgo.ChainP2SH.InputVSize())
+		}
+		// Unknown chains are neither segwit nor taproot.
+		if c.IsSegwit() != test.segwit || c.IsTaproot() != test.taproot {
+			t.Errorf("chain %d: unexpected segwit %t, taproot %t", c, c.IsSegwit(), c.IsTaproot())
+		}
+		if test.valid && c.IsChange() != test.change {
+			t.Errorf("chain %d: unexpected change %t", c, c.IsChange())
+		}
+	}
+}
+
+func TestVarIntSize(t *testing.T) {
+	tests := map[int]int{0: 1, 0xfc: 1, 0xfd: 3, 0xffff: 3, 0x10000: 5}
+	for n, want := range tests {
+		if got := bitgo.VarIntSize(n); got != want {
+			t.Errorf("VarIntSize(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
@@ -3,27 +3,32 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/marselester/bitgo-v2"
+	"github.com/marselester/bitgo-v2/consolidation"
 )
 
 func main() {
 	baseURL := flag.String("host", "http://0.0.0.0:3080", "BitGo Express API server base URL.")
 	accessToken := flag.String("token", "", "BitGo access token.")
+	envName := flag.String("env", "", "BitGo environment the host belongs to (production, test, express, express-test).")
+	allowProduction := flag.Bool("allow-production", false, "Allow consolidation in a mainnet environment or of a mainnet coin.")
 	coin := flag.String("coin", "btc", "Coin identifier.")
 	walletID := flag.String("wallet", "", "BitGo wallet ID.")
 	walletPassphrase := flag.String("passphrase", "", "Passphrase of the wallet.")
 	numUnspentsToMake := flag.Int("target", 1, "Number of outputs created by the consolidation transaction.")
 	limit := flag.Int("limit", 25, "Number of unspents to select (max is 200).")
-	minValue := flag.Float64("min-value", 0, "Ignore unspents smaller than this amount of bitcoins.")
-	maxValue := flag.Float64("max-value", 0, "Ignore unspents larger than this amount of bitcoins.")
+	minValue := flag.String("min-value", "", "Ignore unspents smaller than this amount of coins, e.g., 0.0001.")
+	maxValue := flag.String("max-value", "", "Ignore unspents larger than this amount of coins, e.g., 0.29.")
 	minHeight := flag.Int("min-height", 0, "The minimum height of unspents on the block chain to use.")
 	feeRate := flag.Int("fee-rate", 0, "The desired fee rate for the transaction in satoshis/KB.")
 	feeTxConfirmTarget := flag.Int(
@@ -37,6 +42,8 @@ func main() {
 	enforceMinConfirmsForChange := flag.Bool("enforce-min-confirms-for-change", false, "Apply the required confirmations set in min-confirms for change outputs.")
 	maxIter := flag.Int("max-iter", 1, "Maximum number of consolidation iterations to perform.")
 	waitIter := flag.Duration("wait-iter", time.Second, "Wait between consolidation iterations.")
+	dryRun := flag.Bool("dry-run", false, "Print estimated fee and net value of the consolidation without sending it (requires fee-rate).")
+	tipHeight := flag.Int64("tip-height", 0, "Current block height to check min-confirms locally in dry run (change is exempt unless enforced).")
 	debug := flag.Bool("debug", false, "Enable debug mode.")
 	flag.Parse()
 
@@ -54,23 +61,46 @@ func main() {
 
 	var logger bitgo.Logger
 	if *debug {
-		logger = bitgo.LoggerFunc(stdLogger)
+		h := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})
+		logger = bitgo.NewSlogLogger(slog.New(h))
 	} else {
 		logger = &bitgo.NoopLogger{}
 	}
-	client := bitgo.NewClient(
+	options := []bitgo.ConfigOption{
 		bitgo.WithBaseURL(*baseURL),
 		bitgo.WithCoin(*coin),
 		bitgo.WithAccesToken(*accessToken),
 		bitgo.WithLogger(logger),
-	)
+	}
+	if *envName != "" {
+		env, ok := bitgo.LookupEnvironment(*envName)
+		if !ok {
+			log.Fatalf("consolidate: unknown environment %q", *envName)
+		}
+		options = append(options, bitgo.WithEnvironment(env))
+	}
+	if *allowProduction {
+		options = append(options, bitgo.WithProductionSpending())
+	}
+	client := bitgo.NewClient(options...)
+	if err := client.Err(); err != nil {
+		log.Fatalf("consolidate: %v", err)
+	}
 
+	minAmount, err := parseAmount(*minValue, client.CoinInfo().Decimals)
+	if err != nil {
+		log.Fatalf("consolidate: min-value: %v", err)
+	}
+	maxAmount, err := parseAmount(*maxValue, client.CoinInfo().Decimals)
+	if err != nil {
+		log.Fatalf("consolidate: max-value: %v", err)
+	}
 	params := &bitgo.WalletConsolidateParams{
 		WalletPassphrase:            *walletPassphrase,
 		NumUnspentsToMake:           *numUnspentsToMake,
 		Limit:                       *limit,
-		MinValue:                    toSatoshis(*minValue),
-		MaxValue:                    toSatoshis(*maxValue),
+		MinValue:                    minAmount,
+		MaxValue:                    maxAmount,
 		MinHeight:                   *minHeight,
 		FeeRate:                     *feeRate,
 		FeeTxConfirmTarget:          *feeTxConfirmTarget,
@@ -78,6 +108,21 @@ func main() {
 		MinConfirms:                 *minConfirms,
 		EnforceMinConfirmsForChange: *enforceMinConfirmsForChange,
 	}
+	if err = params.ValidateCoin(client.CoinInfo()); err != nil {
+		log.Fatalf("consolidate: %v", err)
+	}
+
+	if *dryRun {
+		opts := consolidation.Options{Tip: *tipHeight}
+		sim, err := consolidation.Simulate(ctx, client, *walletID, params, &opts)
+		if err != nil {
+			log.Fatalf("consolidate: dry run: %v", err)
+		}
+		if err = sim.WriteTable(os.Stdout, client.CoinInfo().Decimals); err != nil {
+			log.Fatalf("consolidate: dry run: %v", err)
+		}
+		return
+	}
 
 	for i := 0; i < *maxIter; i++ {
 		tx, err := client.Wallet.Consolidate(ctx, *walletID, params)
@@ -93,23 +138,24 @@ func main() {
 			break
 		}
 
-		if apiErr, ok := err.(bitgo.Error); ok {
+		var apiErr bitgo.Error
+		if errors.As(err, &apiErr) {
+			// Unspents are consolidated as much as params allow, that's not a failure.
+			if apiErr.IsNothingToConsolidate() {
+				log.Printf("consolidate: nothing left to consolidate: %v", apiErr)
+				break
+			}
 			log.Fatalf("consolidate: failed to coalesce unspents, %d: %v", apiErr.HTTPStatusCode, apiErr)
 		}
 		log.Fatalf("consolidate: failed to coalesce unspents: %v", err)
 	}
 }
 
-// stdLogger prints logs to standard error.
-func stdLogger(keyvals ...interface{}) error {
-	log.Printf("%q", keyvals)
-	return nil
-}
-
-// satoshi is the smallest unit of bitcoin.
-const satoshi = 0.00000001
-
-// toSatoshis converts bitcoins to satoshis.
-func toSatoshis(amount float64) int64 {
-	return int64(amount / satoshi)
+// parseAmount parses an amount of coins in display units, e.g., 0.29 BTC.
+// Empty string means zero amount.
+func parseAmount(s string, decimals int) (bitgo.Amount, error) {
+	if s == "" {
+		return bitgo.Amount{}, nil
+	}
+	return bitgo.ParseAmount(s, decimals)
 }
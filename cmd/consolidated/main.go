@@ -4,27 +4,33 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/marselester/bitgo-v2"
+	"github.com/marselester/bitgo-v2/consolidation"
+	"github.com/marselester/bitgo-v2/rawtx"
 )
 
 func main() {
 	baseURL := flag.String("host", "http://0.0.0.0:3080", "BitGo Express API server base URL.")
 	accessToken := flag.String("token", "", "BitGo access token.")
+	envName := flag.String("env", "", "BitGo environment the host belongs to (production, test, express, express-test).")
+	allowProduction := flag.Bool("allow-production", false, "Allow consolidation in a mainnet environment or of a mainnet coin.")
 	coin := flag.String("coin", "btc", "Coin identifier.")
 	walletID := flag.String("wallet", "", "BitGo wallet ID.")
 	walletPassphrase := flag.String("passphrase", "", "Passphrase of the wallet.")
 	numUnspentsToMake := flag.Int("target", 1, "Number of outputs created by the consolidation transaction.")
 	limit := flag.Int("limit", 25, "Number of unspents to select (max is 200).")
-	minValue := flag.Float64("min-value", 0, "Ignore unspents smaller than this amount of bitcoins.")
-	maxValue := flag.Float64("max-value", 0, "Ignore unspents larger than this amount of bitcoins.")
+	minValue := flag.String("min-value", "", "Ignore unspents smaller than this amount of coins, e.g., 0.0001.")
+	maxValue := flag.String("max-value", "", "Ignore unspents larger than this amount of coins, e.g., 0.29.")
 	minHeight := flag.Int("min-height", 0, "The minimum height of unspents on the block chain to use.")
 	feeRate := flag.Int("fee-rate", 0, "The desired fee rate for the transaction in satoshis/KB.")
 	feeTxConfirmTarget := flag.Int(
@@ -39,6 +45,13 @@ func main() {
 	maxIter := flag.Int("max-iter", 1, "Maximum number of consolidation iterations to perform.")
 	waitIter := flag.Duration("wait-iter", time.Second, "Wait between consolidation iterations.")
 	schedule := flag.Duration("schedule", time.Hour, "How often to schedule consolidation (one at a time).")
+	audit := flag.Bool("audit", false, "Decode and log every consolidation transaction: inputs, outputs, vsize and fee.")
+	auditFeeRate := flag.Int64(
+		"audit-fee-rate",
+		0,
+		`Fee rate in satoshis/KB to find the unspents BitGo is expected to spend when fee-rate is not set,
+so the audited fee is known.`,
+	)
 	debug := flag.Bool("debug", false, "Enable debug mode.")
 	flag.Parse()
 
@@ -54,25 +67,52 @@ func main() {
 		cancel()
 	}()
 
+	level := slog.LevelInfo
+	if *debug {
+		level = slog.LevelDebug
+	}
+	slogger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
 	var logger bitgo.Logger
 	if *debug {
-		logger = bitgo.LoggerFunc(stdLogger)
+		logger = bitgo.NewSlogLogger(slogger)
 	} else {
 		logger = &bitgo.NoopLogger{}
 	}
-	client := bitgo.NewClient(
+	options := []bitgo.ConfigOption{
 		bitgo.WithBaseURL(*baseURL),
 		bitgo.WithCoin(*coin),
 		bitgo.WithAccesToken(*accessToken),
 		bitgo.WithLogger(logger),
-	)
+	}
+	if *envName != "" {
+		env, ok := bitgo.LookupEnvironment(*envName)
+		if !ok {
+			log.Fatalf("consolidated: unknown environment %q", *envName)
+		}
+		options = append(options, bitgo.WithEnvironment(env))
+	}
+	if *allowProduction {
+		options = append(options, bitgo.WithProductionSpending())
+	}
+	client := bitgo.NewClient(options...)
+	if err := client.Err(); err != nil {
+		log.Fatalf("consolidated: %v", err)
+	}
 
+	minAmount, err := parseAmount(*minValue, client.CoinInfo().Decimals)
+	if err != nil {
+		log.Fatalf("consolidated: min-value: %v", err)
+	}
+	maxAmount, err := parseAmount(*maxValue, client.CoinInfo().Decimals)
+	if err != nil {
+		log.Fatalf("consolidated: max-value: %v", err)
+	}
 	params := &bitgo.WalletConsolidateParams{
 		WalletPassphrase:            *walletPassphrase,
 		NumUnspentsToMake:           *numUnspentsToMake,
 		Limit:                       *limit,
-		MinValue:                    toSatoshis(*minValue),
-		MaxValue:                    toSatoshis(*maxValue),
+		MinValue:                    minAmount,
+		MaxValue:                    maxAmount,
 		MinHeight:                   *minHeight,
 		FeeRate:                     *feeRate,
 		FeeTxConfirmTarget:          *feeTxConfirmTarget,
@@ -80,16 +120,34 @@ func main() {
 		MinConfirms:                 *minConfirms,
 		EnforceMinConfirmsForChange: *enforceMinConfirmsForChange,
 	}
+	if err = params.ValidateCoin(client.CoinInfo()); err != nil {
+		log.Fatalf("consolidated: %v", err)
+	}
 
 	for {
 		select {
 		// Schedule periodic consolidation.
 		case <-time.After(*schedule):
 			for i := 0; i < *maxIter; i++ {
+				// Values of the unspents BitGo is expected to spend are needed to audit the fee.
+				var candidates []bitgo.Unspent
+				if *audit {
+					opts := consolidation.Options{FeeRate: *auditFeeRate}
+					sim, err := consolidation.Simulate(ctx, client, *walletID, params, &opts)
+					if err != nil {
+						slogger.Warn("consolidated: audit fee will be unknown", "wallet", *walletID, "err", err)
+					} else {
+						candidates = sim.Inputs
+					}
+				}
+
 				tx, err := client.Wallet.Consolidate(ctx, *walletID, params)
 				// Print consolidated transaction ID.
 				if err == nil {
 					fmt.Printf("%s\n", tx.TxID)
+					if *audit {
+						auditTx(slogger, client.CoinInfo(), tx, candidates)
+					}
 					time.Sleep(*waitIter)
 					continue
 				}
@@ -99,7 +157,13 @@ func main() {
 					break
 				}
 
-				if apiErr, ok := err.(bitgo.Error); ok {
+				var apiErr bitgo.Error
+				if errors.As(err, &apiErr) {
+					// Wait for the next schedule when unspents are consolidated as much as params allow.
+					if apiErr.IsNothingToConsolidate() {
+						log.Printf("consolidated: nothing left to consolidate: %v", apiErr)
+						break
+					}
 					log.Printf("consolidated: failed to coalesce unspents, %d: %v", apiErr.HTTPStatusCode, apiErr)
 				}
 				log.Printf("consolidated: failed to coalesce unspents: %v", err)
@@ -111,16 +175,40 @@ func main() {
 	}
 }
 
-// stdLogger prints logs to standard error.
-func stdLogger(keyvals ...interface{}) error {
-	log.Printf("%q", keyvals)
-	return nil
-}
+// auditTx logs the decoded consolidation transaction.
+// The fee is logged only when all the inputs are among the candidate unspents.
+func auditTx(logger *slog.Logger, coin bitgo.CoinInfo, info *bitgo.TxInfo, candidates []bitgo.Unspent) {
+	logger = logger.With("txid", info.TxID)
+	tx, err := rawtx.DecodeTxInfo(coin.Ticker, info)
+	if err != nil {
+		logger.Error("consolidated: audit failed to decode transaction", "err", err)
+		return
+	}
 
-// satoshi is the smallest unit of bitcoin.
-const satoshi = 0.00000001
+	fee := "unknown"
+	if v, err := tx.Fee(candidates); err == nil {
+		fee = v.Format(coin.Decimals)
+	} else {
+		logger.Warn("consolidated: audit fee is unknown", "err", err)
+	}
+	logger.Info("consolidated: audit", "inputs", len(tx.Inputs), "outputs", len(tx.Outputs), "vsize", tx.VSize, "fee", fee)
+	for _, in := range tx.Inputs {
+		logger.Info("consolidated: audit input", "outpoint", in.Outpoint.String())
+	}
+	for i, out := range tx.Outputs {
+		addr := "nonstandard"
+		if out.Address != nil {
+			addr = out.Address.String()
+		}
+		logger.Info("consolidated: audit output", "index", i, "address", addr, "value", out.Value.Format(coin.Decimals))
+	}
+}
 
-// toSatoshis converts bitcoins to satoshis.
-func toSatoshis(amount float64) int64 {
-	return int64(amount / satoshi)
+// parseAmount parses an amount of coins in display units, e.g., 0.29 BTC.
+// Empty string means zero amount.
+func parseAmount(s string, decimals int) (bitgo.Amount, error) {
+	if s == "" {
+		return bitgo.Amount{}, nil
+	}
+	return bitgo.ParseAmount(s, decimals)
 }
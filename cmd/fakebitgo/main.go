@@ -0,0 +1,55 @@
+// Fakebitgo runs an in-memory fake of BitGo Express API, so you can point consolidate and utxo programs at it.
+package main
+
+import (
+	"flag"
+	"log"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/marselester/bitgo-v2"
+	"github.com/marselester/bitgo-v2/bitgotest"
+)
+
+func main() {
+	addr := flag.String("addr", "0.0.0.0:3080", "Address to listen on.")
+	accessToken := flag.String("token", "", "BitGo access token required from clients (any token is accepted if empty).")
+	coin := flag.String("coin", "btc", "Coin identifier of the fake wallet.")
+	walletID := flag.String("wallet", "585951a5df8380e0e3063e9f", "Fake wallet ID.")
+	walletPassphrase := flag.String("passphrase", "", "Passphrase of the fake wallet.")
+	numUnspents := flag.Int("unspents", 1000, "Number of unspents in the fake wallet.")
+	maxValue := flag.Int64("max-value", 100000, "Max value of a generated unspent in satoshis.")
+	blockHeight := flag.Int64("height", 1000, "Current block height of the fake chain.")
+	faultStatus := flag.Int("fault-status", 0, "Respond with this status code (e.g. 202, 400, 401, 429, 500) instead of handling requests.")
+	faultPath := flag.String("fault-path", "", "Inject the fault only to requests whose path ends with this suffix.")
+	faultCount := flag.Int("fault-count", 0, "How many requests fail with the fault, zero means every request.")
+	flag.Parse()
+
+	fake := bitgotest.NewServer()
+	fake.AccessToken = *accessToken
+	fake.BlockHeight = *blockHeight
+	fake.AddWallet(*coin, *walletID, *walletPassphrase)
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	unspents := make([]bitgo.Unspent, *numUnspents)
+	for i := range unspents {
+		unspents[i] = bitgo.Unspent{
+			Value:       bitgo.NewAmount(1 + r.Int63n(*maxValue)),
+			BlockHeight: 1 + r.Int63n(*blockHeight),
+			Index:       i,
+		}
+	}
+	fake.AddUnspents(*walletID, unspents...)
+
+	if *faultStatus != 0 {
+		fake.InjectFault(bitgotest.Fault{
+			Path:       *faultPath,
+			StatusCode: *faultStatus,
+			Count:      *faultCount,
+		})
+	}
+
+	log.Printf("fakebitgo: %s wallet %s with %d unspents is listening on %s", *coin, *walletID, *numUnspents, *addr)
+	log.Fatal(http.ListenAndServe(*addr, fake))
+}
@@ -0,0 +1,171 @@
+// Psbt exchanges BitGo transactions with offline signers as partially signed Bitcoin transactions (BIP174).
+//
+// Run "psbt build [flags]" to prebuild a payment, verify it against the recipient, the wallet xpubs and the max fee,
+// and print it as a base64 PSBT,
+// and "psbt submit [flags] < signed.psbt" to send the PSBT signed with the user key to BitGo for co-signing.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/marselester/bitgo-v2"
+	"github.com/marselester/bitgo-v2/psbt"
+	"github.com/marselester/bitgo-v2/rawtx"
+	"github.com/marselester/bitgo-v2/verify"
+)
+
+func main() {
+	baseURL := flag.String("host", "http://0.0.0.0:3080", "BitGo API server base URL.")
+	accessToken := flag.String("token", "", "BitGo access token.")
+	envName := flag.String("env", "", "BitGo environment the host belongs to (production, test, express, express-test).")
+	allowProduction := flag.Bool("allow-production", false, "Allow submitting transactions in a mainnet environment or of a mainnet coin.")
+	coin := flag.String("coin", "btc", "Coin identifier.")
+	walletID := flag.String("wallet", "", "BitGo wallet ID.")
+	to := flag.String("to", "", "Recipient address (build mode).")
+	amount := flag.String("amount", "", "Amount of coins to send, e.g., 0.001 (build mode).")
+	feeRate := flag.Int("fee-rate", 0, "The desired fee rate for the transaction in satoshis/KB (build mode).")
+	maxFee := flag.String("max-fee", "", "The maximum fee of the transaction in coins, e.g., 0.0002 (build mode).")
+	xpubs := flag.String("xpubs", "", "Comma separated trusted user, backup and BitGo xpubs of the wallet (build mode).")
+	prevTxs := flag.String("prev-txs", "", "Comma separated hex encoded transactions which created the unspents, required for P2SH ones and added to segwit inputs too (build mode).")
+	comment := flag.String("comment", "", "Metadata stored with the transfer (submit mode).")
+	debug := flag.Bool("debug", false, "Enable debug mode.")
+
+	if len(os.Args) < 2 || os.Args[1] != "build" && os.Args[1] != "submit" {
+		log.Fatal("psbt: usage: psbt build|submit [flags]")
+	}
+	mode := os.Args[1]
+	flag.CommandLine.Parse(os.Args[2:])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Listen to INT/TERM to gracefully stop the request.
+	go func() {
+		sigchan := make(chan os.Signal, 1)
+		signal.Notify(sigchan, syscall.SIGINT, syscall.SIGTERM)
+		<-sigchan
+
+		log.Print("psbt: stopping...")
+		cancel()
+	}()
+
+	var logger bitgo.Logger
+	if *debug {
+		h := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})
+		logger = bitgo.NewSlogLogger(slog.New(h))
+	} else {
+		logger = &bitgo.NoopLogger{}
+	}
+	options := []bitgo.ConfigOption{
+		bitgo.WithBaseURL(*baseURL),
+		bitgo.WithCoin(*coin),
+		bitgo.WithAccesToken(*accessToken),
+		bitgo.WithLogger(logger),
+	}
+	if *envName != "" {
+		env, ok := bitgo.LookupEnvironment(*envName)
+		if !ok {
+			log.Fatalf("psbt: unknown environment %q", *envName)
+		}
+		options = append(options, bitgo.WithEnvironment(env))
+	}
+	if *allowProduction {
+		options = append(options, bitgo.WithProductionSpending())
+	}
+	client := bitgo.NewClient(options...)
+	if err := client.Err(); err != nil {
+		log.Fatalf("psbt: %v", err)
+	}
+
+	if mode == "build" {
+		value, err := bitgo.ParseAmount(*amount, client.CoinInfo().Decimals)
+		if err != nil {
+			log.Fatalf("psbt: amount: %v", err)
+		}
+		intent := verify.Intent{Recipients: []bitgo.Recipient{{Address: *to, Amount: value}}}
+		if intent.MaxFee, err = bitgo.ParseAmount(*maxFee, client.CoinInfo().Decimals); err != nil {
+			log.Fatalf("psbt: max fee: %v", err)
+		}
+		// The keychains are not fetched from BitGo, a compromised proxy could forge them along with the change address.
+		var keys []psbt.Key
+		for _, s := range strings.Split(*xpubs, ",") {
+			k, err := bitgo.ParseExtendedKey(strings.TrimSpace(s))
+			if err != nil {
+				log.Fatalf("psbt: xpubs: %v", err)
+			}
+			intent.Keys = append(intent.Keys, k)
+			keys = append(keys, psbt.Key{XPub: k})
+		}
+
+		// Legacy P2SH signatures don't commit to the spent values, so the signer checks them in the previous transactions.
+		var prev []*rawtx.Tx
+		if *prevTxs != "" {
+			for _, s := range strings.Split(*prevTxs, ",") {
+				tx, err := rawtx.DecodeString(client.CoinInfo().Ticker, strings.TrimSpace(s))
+				if err != nil {
+					log.Fatalf("psbt: prev txs: %v", err)
+				}
+				prev = append(prev, tx)
+			}
+			// The PSBT carries the previous transactions, so the signer checks the values of P2SH inputs.
+			intent.AllowLegacyInputs = true
+		}
+
+		params := bitgo.SendManyParams{
+			Recipients: intent.Recipients,
+			FeeRate:    *feeRate,
+		}
+		prebuild, err := client.Wallet.Prebuild(ctx, *walletID, &params)
+		if err != nil {
+			log.Fatalf("psbt: failed to prebuild: %v", err)
+		}
+		// The change addresses are verified against the xpubs and described in the PSBT for the signer.
+		var change []bitgo.WalletAddress
+		for _, addr := range prebuild.TxInfo.ChangeAddresses {
+			a, err := client.Wallet.Address(ctx, *walletID, addr)
+			if err != nil {
+				log.Fatalf("psbt: failed to fetch change address %s: %v", addr, err)
+			}
+			change = append(change, *a)
+		}
+		if err = verify.Tx(client.CoinInfo().Ticker, prebuild, &intent, change); err != nil {
+			log.Fatalf("psbt: prebuilt transaction doesn't match the payment: %v", err)
+		}
+		p, err := psbt.FromPrebuild(client.CoinInfo().Ticker, prebuild, change, prev, keys)
+		if err != nil {
+			log.Fatalf("psbt: %v", err)
+		}
+		fmt.Println(p)
+		return
+	}
+
+	signed, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		log.Fatalf("psbt: %v", err)
+	}
+	p, err := psbt.DecodeString(client.CoinInfo().Ticker, string(signed))
+	if err != nil {
+		log.Fatalf("psbt: %v", err)
+	}
+	txHex, err := p.Extract()
+	if err != nil {
+		log.Fatalf("psbt: %v", err)
+	}
+	params := bitgo.SubmitParams{
+		HalfSigned: bitgo.HalfSignedTx{TxHex: txHex},
+		Comment:    *comment,
+	}
+	tx, err := client.Wallet.Submit(ctx, *walletID, &params)
+	if err != nil {
+		log.Fatalf("psbt: failed to submit: %v", err)
+	}
+	fmt.Println(tx.TxID)
+}
@@ -1,33 +1,57 @@
 // List bitcoin unspent transaction outputs (UTXOs).
+//
+// Run "utxo report [flags]" to print a summary of the wallet's unspents
+// (value histogram, chains, age, dust) instead of listing them.
 package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
-	"net/url"
+	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/marselester/bitgo-v2"
+	"github.com/marselester/bitgo-v2/analytics"
 )
 
 func main() {
 	baseURL := flag.String("host", "http://0.0.0.0:3080", "BitGo API server base URL.")
 	accessToken := flag.String("token", "", "BitGo access token.")
+	envName := flag.String("env", "", "BitGo environment the host belongs to (production, test, express, express-test).")
 	coin := flag.String("coin", "btc", "Coin identifier.")
 	walletID := flag.String("wallet", "", "BitGo wallet ID.")
 	prevID := flag.String("prev-id", "", "Continue iterating unspents from this ID as provided by nextBatchPrevId in the previous list.")
-	minSize := flag.Float64("min-size", 0, "Ignore unspents smaller than this amount of bitcoins.")
-	maxSize := flag.Float64("max-size", 0, "Ignore unspents larger than this amount of bitcoins.")
+	pageSize := flag.Int("page-size", 0, "Number of unspents per page (API default if zero).")
+	prefetch := flag.Int("prefetch", 0, "Number of pages to download ahead while the current page is printed (no prefetching if zero).")
+	minSize := flag.String("min-size", "", "Ignore unspents smaller than this amount of coins, e.g., 0.0001.")
+	maxSize := flag.String("max-size", "", "Ignore unspents larger than this amount of coins, e.g., 0.29.")
 	minHeight := flag.Int("min-height", 0, "Ignore unspents confirmed at a lower block height than the given height.")
 	minConfirms := flag.Int("min-confirms", 0, "Ignore unspents that have fewer than the given confirmations.")
 	waitSeconds := flag.Int("wait", 15, "How many seconds to wait after failed download attempt.")
 	debug := flag.Bool("debug", false, "Enable debug mode.")
-	flag.Parse()
+	format := flag.String("format", "table", "Report format: table or json (report mode).")
+	buckets := flag.String("buckets", "", "Comma separated upper bounds of value histogram buckets in coins, e.g., 0.001,0.01,0.1 (report mode).")
+	feeRate := flag.Int64("fee-rate", 0, "Fee rate in satoshis/KB to count uneconomical unspents (report mode).")
+	dust := flag.String("dust", "", "Dust threshold in coins, 0.00000546 if empty (report mode).")
+
+	// Subcommand "report" summarizes unspents instead of listing them.
+	report := len(os.Args) > 1 && os.Args[1] == "report"
+	if report {
+		flag.CommandLine.Parse(os.Args[2:])
+	} else {
+		flag.Parse()
+	}
+	if *format != "table" && *format != "json" {
+		log.Fatalf("utxo: unknown report format %q", *format)
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -43,83 +67,154 @@ func main() {
 
 	var logger bitgo.Logger
 	if *debug {
-		logger = bitgo.LoggerFunc(stdLogger)
+		h := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})
+		logger = bitgo.NewSlogLogger(slog.New(h))
 	} else {
 		logger = &bitgo.NoopLogger{}
 	}
-	client := bitgo.NewClient(
+	options := []bitgo.ConfigOption{
 		bitgo.WithBaseURL(*baseURL),
 		bitgo.WithCoin(*coin),
 		bitgo.WithAccesToken(*accessToken),
 		bitgo.WithLogger(logger),
-	)
+	}
+	if *envName != "" {
+		env, ok := bitgo.LookupEnvironment(*envName)
+		if !ok {
+			log.Fatalf("utxo: unknown environment %q", *envName)
+		}
+		options = append(options, bitgo.WithEnvironment(env))
+	}
+	client := bitgo.NewClient(options...)
+	if err := client.Err(); err != nil {
+		log.Fatalf("utxo: %v", err)
+	}
 
-	params := url.Values{}
-	if *prevID != "" {
-		params.Set("prevId", *prevID)
+	decimals := client.CoinInfo().Decimals
+	minValue, err := parseAmount(*minSize, decimals)
+	if err != nil {
+		log.Fatalf("utxo: min-size: %v", err)
+	}
+	maxValue, err := parseAmount(*maxSize, decimals)
+	if err != nil {
+		log.Fatalf("utxo: max-size: %v", err)
 	}
-	if *minSize > 0 {
-		params.Set("minValue", fmt.Sprintf("%d", toSatoshis(*minSize)))
+	params := bitgo.UnspentsParams{
+		MinValue:    minValue,
+		MaxValue:    maxValue,
+		MinHeight:   int64(*minHeight),
+		MinConfirms: *minConfirms,
 	}
-	if *maxSize > 0 {
-		params.Set("maxValue", fmt.Sprintf("%d", toSatoshis(*maxSize)))
+	if err := params.Validate(); err != nil {
+		log.Fatalf("utxo: %v", err)
 	}
-	if *minHeight > 0 {
-		params.Set("minHeight", fmt.Sprintf("%d", *minHeight))
+	if err := params.ValidateCoin(client.CoinInfo()); err != nil {
+		log.Fatalf("utxo: %v", err)
 	}
-	if *minConfirms > 0 {
-		params.Set("minConfirms", fmt.Sprintf("%d", *minConfirms))
+
+	var analyzer *analytics.Analyzer
+	if report {
+		aopts := analytics.Options{FeeRate: *feeRate}
+		if aopts.Buckets, err = parseBuckets(*buckets, decimals); err != nil {
+			log.Fatalf("utxo: buckets: %v", err)
+		}
+		if aopts.DustThreshold, err = parseAmount(*dust, decimals); err != nil {
+			log.Fatalf("utxo: dust: %v", err)
+		}
+		analyzer = analytics.New(aopts)
 	}
 
 	downloaded := 0
-	nextBatchPrevID := ""
+	opts := bitgo.ListOptions{
+		PageSize: *pageSize,
+		Cursor:   *prevID,
+		Prefetch: *prefetch,
+	}
 	for {
-		err := client.Wallet.Unspents(ctx, *walletID, params, func(list *bitgo.UnspentList) {
-			downloaded += len(list.Unspents)
-			log.Printf("utxo: fetched %d unspents", downloaded)
-
-			for _, utxo := range list.Unspents {
-				fmt.Printf("%0.8f\n", toBitcoins(utxo.Value))
+		it := client.Wallet.UnspentsIter(ctx, *walletID, params.Values(), &opts)
+		page := 0
+		for it.Next() {
+			if it.Page() != page {
+				page = it.Page()
+				log.Printf("utxo: fetched %d unspents, downloading page %d", downloaded, page)
 			}
+			downloaded++
 
-			nextBatchPrevID = list.NextBatchPrevID
-		})
+			if report {
+				analyzer.Add(it.Value())
+			} else {
+				fmt.Println(it.Value().Value.Format(decimals))
+			}
+		}
+		it.Close()
+		err := it.Err()
 		// Stop when we downloaded everything without errors or
 		// when a context was cancelled (user hit Ctrl+C).
 		if err == nil || ctx.Err() != nil {
+			log.Printf("utxo: fetched %d unspents", downloaded)
 			break
 		}
 
-		if apiErr, ok := err.(bitgo.Error); ok {
+		// Retrying doesn't help when the server's cursors can't be trusted,
+		// it would start over from the same cursor.
+		var perr *bitgo.PaginationError
+		if errors.As(err, &perr) {
+			log.Fatalf("utxo: fetched %d unspents: %v", downloaded, perr)
+		}
+
+		var apiErr bitgo.Error
+		if errors.As(err, &apiErr) {
 			log.Printf("utxo: failed to list unspents, %d: %v", apiErr.HTTPStatusCode, apiErr)
 		} else {
 			log.Printf("utxo: failed to list unspents: %v", err)
 		}
 
-		// We shall wait a bit and then try again.
-		log.Printf("utxo: retrying in %d seconds...", *waitSeconds)
+		// We shall wait a bit and then try again from where we stopped.
+		log.Printf("utxo: retrying from %q in %d seconds...", it.Cursor(), *waitSeconds)
 		time.Sleep(time.Duration(*waitSeconds) * time.Second)
-		if nextBatchPrevID != "" {
-			params.Set("prevId", nextBatchPrevID)
-		}
+		opts.Cursor = it.Cursor()
 	}
-}
 
-// stdLogger prints logs to standard error.
-func stdLogger(keyvals ...interface{}) error {
-	log.Printf("%q", keyvals)
-	return nil
+	if report {
+		r := analyzer.Report()
+		if *format == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(r)
+		} else {
+			err = r.WriteTable(os.Stdout, decimals)
+		}
+		if err != nil {
+			log.Fatalf("utxo: report: %v", err)
+		}
+	}
 }
 
-// satoshi is the smallest unit of bitcoin.
-const satoshi = 0.00000001
-
-// toBitcoins converts satoshis to bitcoins.
-func toBitcoins(amount int64) float64 {
-	return float64(amount) * satoshi
+// parseAmount parses an amount of coins in display units, e.g., 0.29 BTC.
+// Empty string means zero amount.
+func parseAmount(s string, decimals int) (bitgo.Amount, error) {
+	if s == "" {
+		return bitgo.Amount{}, nil
+	}
+	return bitgo.ParseAmount(s, decimals)
 }
 
-// toSatoshis converts bitcoins to satoshis.
-func toSatoshis(amount float64) int64 {
-	return int64(amount / satoshi)
+// parseBuckets parses comma separated amounts of coins in ascending order.
+// Empty string means default buckets.
+func parseBuckets(s string, decimals int) ([]bitgo.Amount, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var bb []bitgo.Amount
+	for _, f := range strings.Split(s, ",") {
+		a, err := bitgo.ParseAmount(strings.TrimSpace(f), decimals)
+		if err != nil {
+			return nil, err
+		}
+		if len(bb) > 0 && a.Cmp(bb[len(bb)-1]) <= 0 {
+			return nil, fmt.Errorf("bucket %s is not greater than previous one", f)
+		}
+		bb = append(bb, a)
+	}
+	return bb, nil
 }
@@ -0,0 +1,235 @@
+package bitgo
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CoinFamily tells how a coin keeps balances.
+type CoinFamily string
+
+// Coin families.
+const (
+	// FamilyUTXO coins keep balances as unspent transaction outputs (btc, bch, ltc),
+	// so they support unspents listing and consolidation.
+	FamilyUTXO CoinFamily = "utxo"
+	// FamilyAccount coins keep balances in accounts (eth, xrp, xlm).
+	FamilyAccount CoinFamily = "account"
+)
+
+// AddressFormat is an encoding of a coin's addresses.
+type AddressFormat string
+
+// Address formats.
+const (
+	// AddressBase58Check is a legacy P2PKH/P2SH address, e.g., 3Ai1JZ8pdJb2ksieUV8FsxSNVJCpoPi8W6.
+	AddressBase58Check AddressFormat = "base58check"
+	// AddressBech32 is a segwit v0 address, e.g., bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4.
+	AddressBech32 AddressFormat = "bech32"
+	// AddressBech32m is a segwit v1+ (taproot) address, e.g., bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0.
+	AddressBech32m AddressFormat = "bech32m"
+	// AddressCashAddr is a Bitcoin Cash address, e.g., bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a.
+	AddressCashAddr AddressFormat = "cashaddr"
+	// AddressHex is a hex encoded account address, e.g., 0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed.
+	AddressHex AddressFormat = "hex"
+	// AddressRipple is an XRP address in Ripple's base58 alphabet, e.g., rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh.
+	AddressRipple AddressFormat = "ripple"
+	// AddressStrKey is a Stellar account ID, e.g., GBH4TZYZ4IRCPO44CBOLFUHULU2WGALXTAVESQA6432MBJMABBB4GIYI.
+	AddressStrKey AddressFormat = "strkey"
+	// AddressAccountName is an EOS account name, e.g., bitgo1234512.
+	AddressAccountName AddressFormat = "name"
+	// AddressBase32 is an Algorand address, e.g., VCMJKWOY5P5P7SKMZFFOCEROPJCZOTIJMNIYNUCKH7LRO45JMJP6UYBIJA.
+	AddressBase32 AddressFormat = "base32"
+)
+
+// CoinInfo describes a digital currency supported by BitGo.
+type CoinInfo struct {
+	// Ticker is a coin identifier used in API paths, e.g., "btc" or "tbtc".
+	Ticker string
+	// Name is a human readable name, e.g., "Bitcoin".
+	Name string
+	// Testnet is true for testnet coins.
+	Testnet bool
+	// Counterpart is a ticker of the coin in the other network,
+	// i.e., a testnet ticker of a mainnet coin and vice versa.
+	Counterpart string
+	// Family tells whether the coin is UTXO or account based.
+	Family CoinFamily
+	// Decimals is a number of decimal places of the display units, e.g., 8 for BTC.
+	Decimals int
+	// AddressFormats are the address encodings the coin accepts.
+	AddressFormats []AddressFormat
+	// Segwit is true when the coin supports segregated witness unspents.
+	Segwit bool
+	// FeeTargeting is true when the fee rate can be chosen by a confirmation target (FeeTxConfirmTarget).
+	FeeTargeting bool
+}
+
+// HasAddressFormat reports whether the coin accepts addresses in the format.
+func (ci CoinInfo) HasAddressFormat(f AddressFormat) bool {
+	for _, af := range ci.AddressFormats {
+		if af == f {
+			return true
+		}
+	}
+	return false
+}
+
+// mainnetCoins describe mainnet coins, Counterpart is the ticker of their testnet coin.
+// Most testnet tickers are prefixed with "t", but not all of them, e.g., "hteth".
+var mainnetCoins = []CoinInfo{
+	{Ticker: "btc", Name: "Bitcoin", Counterpart: "tbtc", Family: FamilyUTXO, Decimals: 8, AddressFormats: []AddressFormat{AddressBase58Check, AddressBech32, AddressBech32m}, Segwit: true, FeeTargeting: true},
+	{Ticker: "bch", Name: "Bitcoin Cash", Counterpart: "tbch", Family: FamilyUTXO, Decimals: 8, AddressFormats: []AddressFormat{AddressBase58Check, AddressCashAddr}},
+	{Ticker: "bsv", Name: "Bitcoin SV", Counterpart: "tbsv", Family: FamilyUTXO, Decimals: 8, AddressFormats: []AddressFormat{AddressBase58Check}},
+	{Ticker: "btg", Name: "Bitcoin Gold", Counterpart: "tbtg", Family: FamilyUTXO, Decimals: 8, AddressFormats: []AddressFormat{AddressBase58Check, AddressBech32}, Segwit: true},
+	{Ticker: "ltc", Name: "Litecoin", Counterpart: "tltc", Family: FamilyUTXO, Decimals: 8, AddressFormats: []AddressFormat{AddressBase58Check, AddressBech32}, Segwit: true},
+	{Ticker: "zec", Name: "Zcash", Counterpart: "tzec", Family: FamilyUTXO, Decimals: 8, AddressFormats: []AddressFormat{AddressBase58Check}},
+	{Ticker: "dash", Name: "Dash", Counterpart: "tdash", Family: FamilyUTXO, Decimals: 8, AddressFormats: []AddressFormat{AddressBase58Check}},
+	{Ticker: "eth", Name: "Ethereum", Counterpart: "hteth", Family: FamilyAccount, Decimals: 18, AddressFormats: []AddressFormat{AddressHex}},
+	{Ticker: "xrp", Name: "Ripple", Counterpart: "txrp", Family: FamilyAccount, Decimals: 6, AddressFormats: []AddressFormat{AddressRipple}},
+	{Ticker: "xlm", Name: "Stellar", Counterpart: "txlm", Family: FamilyAccount, Decimals: 7, AddressFormats: []AddressFormat{AddressStrKey}},
+	{Ticker: "eos", Name: "EOS", Counterpart: "teos", Family: FamilyAccount, Decimals: 4, AddressFormats: []AddressFormat{AddressAccountName}},
+	{Ticker: "trx", Name: "Tron", Counterpart: "ttrx", Family: FamilyAccount, Decimals: 6, AddressFormats: []AddressFormat{AddressBase58Check}},
+	{Ticker: "algo", Name: "Algorand", Counterpart: "talgo", Family: FamilyAccount, Decimals: 6, AddressFormats: []AddressFormat{AddressBase32}},
+}
+
+// coinRegistry maps tickers of mainnet and testnet coins to their descriptions.
+var coinRegistry = newCoinRegistry(mainnetCoins)
+
+// newCoinRegistry returns a registry of mainnet coins and their testnet counterparts.
+func newCoinRegistry(coins []CoinInfo) map[string]CoinInfo {
+	r := make(map[string]CoinInfo, len(coins)*2)
+	for _, main := range coins {
+		test := main
+		test.Ticker = main.Counterpart
+		test.Name = "Testnet " + main.Name
+		test.Testnet = true
+		test.Counterpart = main.Ticker
+
+		r[main.Ticker] = main
+		r[test.Ticker] = test
+	}
+	return r
+}
+
+// LookupCoin returns a description of a coin by its ticker, e.g., "btc" or "tbtc".
+// The second result is false when the coin is not in the registry.
+func LookupCoin(ticker string) (CoinInfo, bool) {
+	ci, ok := coinRegistry[strings.ToLower(ticker)]
+	return ci, ok
+}
+
+// Coins returns descriptions of all the coins in the registry sorted by ticker.
+func Coins() []CoinInfo {
+	cc := make([]CoinInfo, 0, len(coinRegistry))
+	for _, ci := range coinRegistry {
+		cc = append(cc, ci)
+	}
+	sort.Slice(cc, func(i, j int) bool {
+		return cc[i].Ticker < cc[j].Ticker
+	})
+	return cc
+}
+
+// CoinDecimals returns a number of decimal places of the coin's display units,
+// e.g., 8 for "btc" and "tbtc", or 18 for "eth".
+// The second result is false when the coin is unknown.
+func CoinDecimals(coin string) (int, bool) {
+	ci, ok := LookupCoin(coin)
+	return ci.Decimals, ok
+}
+
+// WithCoinInfo describes a coin which is not in the registry (see Coins), e.g., a newly listed one,
+// so that Client can be used with it. It doesn't change the coin set by WithCoin.
+func WithCoinInfo(info CoinInfo) ConfigOption {
+	return func(c *Config) {
+		if c.coins == nil {
+			c.coins = make(map[string]CoinInfo)
+		}
+		c.coins[strings.ToLower(info.Ticker)] = info
+	}
+}
+
+// ErrUnknownCoin is returned by Client.Err when the coin is neither in the registry nor described with WithCoinInfo.
+var ErrUnknownCoin = errors.New("bitgo: unknown coin")
+
+// ErrUnsupportedByCoin is returned when a call or its params don't apply to the client's coin,
+// e.g., listing unspents of an account based coin.
+var ErrUnsupportedByCoin = errors.New("bitgo: not supported by the coin")
+
+// lookupCoin returns a description of the coin from WithCoinInfo options or the registry.
+func (c *Config) lookupCoin(coin string) (CoinInfo, bool) {
+	if ci, ok := c.coins[strings.ToLower(coin)]; ok {
+		return ci, true
+	}
+	return LookupCoin(coin)
+}
+
+// validateCoin checks that the configured coin is in the registry or described with WithCoinInfo.
+// A coin newly listed by BitGo can be described with WithCoinInfo before the registry is updated.
+func (c *Config) validateCoin() error {
+	ci, ok := c.lookupCoin(c.coin)
+	if !ok {
+		return fmt.Errorf("%w %q, it can be described with WithCoinInfo option", ErrUnknownCoin, c.coin)
+	}
+	c.coinInfo = ci
+	return nil
+}
+
+// requireUTXO returns ErrUnsupportedByCoin if the client's coin is not UTXO based.
+func (c *Config) requireUTXO(call string) error {
+	if c.coinInfo.Family != FamilyUTXO {
+		return fmt.Errorf("%w: %s requires a UTXO coin, %s is %s based", ErrUnsupportedByCoin, call, c.coinInfo.Ticker, c.coinInfo.Family)
+	}
+	return nil
+}
+
+// ValidateCoin checks that the params apply to the coin, e.g., fee targeting isn't used with bch.
+func (p *WalletConsolidateParams) ValidateCoin(coin CoinInfo) error {
+	if coin.Family != FamilyUTXO {
+		return fmt.Errorf("%w: %s is %s based and has no unspents to consolidate", ErrUnsupportedByCoin, coin.Ticker, coin.Family)
+	}
+	if p.FeeTxConfirmTarget > 0 && !coin.FeeTargeting {
+		return fmt.Errorf("%w: %s doesn't support feeTxConfirmTarget", ErrUnsupportedByCoin, coin.Ticker)
+	}
+	return nil
+}
+
+// ValidateCoin checks that the params apply to the coin, e.g., pinned unspents aren't used with eth.
+// Recipient addresses are parsed with ParseAddress when the coin supports local address validation.
+func (p *SendManyParams) ValidateCoin(coin CoinInfo) error {
+	if len(p.Unspents) > 0 && coin.Family != FamilyUTXO {
+		return fmt.Errorf("%w: %s is %s based and has no unspents to spend", ErrUnsupportedByCoin, coin.Ticker, coin.Family)
+	}
+	if p.FeeTxConfirmTarget > 0 && !coin.FeeTargeting {
+		return fmt.Errorf("%w: %s doesn't support feeTxConfirmTarget", ErrUnsupportedByCoin, coin.Ticker)
+	}
+	for _, r := range p.Recipients {
+		_, err := parseAddress(coin, r.Address)
+		if err != nil && !errors.Is(err, ErrUnsupportedByCoin) {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateCoin checks that the params apply to the coin, e.g., segwit filters aren't used with bch.
+func (p *UnspentsParams) ValidateCoin(coin CoinInfo) error {
+	if coin.Family != FamilyUTXO {
+		return fmt.Errorf("%w: %s is %s based and has no unspents", ErrUnsupportedByCoin, coin.Ticker, coin.Family)
+	}
+	if p.Segwit != nil && *p.Segwit && !coin.Segwit {
+		return fmt.Errorf("%w: %s has no segwit unspents", ErrUnsupportedByCoin, coin.Ticker)
+	}
+	for _, chain := range p.Chains {
+		if chain.IsSegwit() && !coin.Segwit {
+			return fmt.Errorf("%w: %s has no segwit chain %d", ErrUnsupportedByCoin, coin.Ticker, chain)
+		}
+		if chain.IsTaproot() && !coin.HasAddressFormat(AddressBech32m) {
+			return fmt.Errorf("%w: %s has no taproot chain %d", ErrUnsupportedByCoin, coin.Ticker, chain)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package bitgo_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marselester/bitgo-v2"
+)
+
+func TestLookupCoin(t *testing.T) {
+	btc, ok := bitgo.LookupCoin("BTC")
+	if !ok || btc.Testnet || btc.Counterpart != "tbtc" || btc.Decimals != 8 || !btc.Segwit || !btc.FeeTargeting {
+		t.Fatalf("unexpected btc %#v", btc)
+	}
+	tbch, ok := bitgo.LookupCoin("tbch")
+	if !ok || !tbch.Testnet || tbch.Counterpart != "bch" || tbch.Segwit || !tbch.HasAddressFormat(bitgo.AddressCashAddr) {
+		t.Fatalf("unexpected tbch %#v", tbch)
+	}
+	if eth, _ := bitgo.LookupCoin("hteth"); eth.Family != bitgo.FamilyAccount || eth.Decimals != 18 {
+		t.Fatalf("unexpected hteth %#v", eth)
+	}
+	if _, ok = bitgo.LookupCoin("foo"); ok {
+		t.Error("expected unknown coin")
+	}
+
+	coins := bitgo.Coins()
+	for i := 1; i < len(coins); i++ {
+		if coins[i-1].Ticker >= coins[i].Ticker {
+			t.Fatalf("coins are not sorted: %s, %s", coins[i-1].Ticker, coins[i].Ticker)
+		}
+	}
+}
+
+func TestCoinFamily(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	c := bitgo.NewClient(
+		bitgo.WithBaseURL(srv.URL),
+		bitgo.WithCoin("hteth"),
+	)
+	ctx := context.Background()
+
+	err := c.Wallet.Unspents(ctx, "", nil, func(*bitgo.UnspentList) {})
+	if !errors.Is(err, bitgo.ErrUnsupportedByCoin) {
+		t.Errorf("expected unspents to be unsupported, got %v", err)
+	}
+	it := c.Wallet.UnspentsIter(ctx, "", nil, nil)
+	if it.Next() || !errors.Is(it.Err(), bitgo.ErrUnsupportedByCoin) {
+		t.Errorf("expected unspents iterator to be unsupported, got %v", it.Err())
+	}
+	if _, err = c.Wallet.Consolidate(ctx, "", nil); !errors.Is(err, bitgo.ErrUnsupportedByCoin) {
+		t.Errorf("expected consolidation to be unsupported, got %v", err)
+	}
+
+	params := &bitgo.WalletConsolidateParams{FeeTxConfirmTarget: 3}
+	if _, err = c.Coin("tbch").Wallet.Consolidate(ctx, "", params); !errors.Is(err, bitgo.ErrUnsupportedByCoin) {
+		t.Errorf("expected fee targeting to be unsupported, got %v", err)
+	}
+	if _, err = c.Coin("tbtc").Wallet.Consolidate(ctx, "", params); err != nil {
+		t.Errorf("expected fee targeting to be supported, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected unsupported calls to fail without requests, got %d requests", requests)
+	}
+}
+
+func TestUnknownCoin(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c := bitgo.NewClient(
+		bitgo.WithBaseURL(srv.URL),
+		bitgo.WithCoin("btcc"),
+	)
+	if err := c.Err(); !errors.Is(err, bitgo.ErrUnknownCoin) {
+		t.Fatalf("expected unknown coin error, got %v", err)
+	}
+	if err := c.Wallet.Unspents(context.Background(), "", nil, func(*bitgo.UnspentList) {}); !errors.Is(err, bitgo.ErrUnknownCoin) {
+		t.Errorf("expected unknown coin error, got %v", err)
+	}
+	if requests != 0 {
+		t.Errorf("expected no requests, got %d", requests)
+	}
+
+	// A described coin is known.
+	c = bitgo.NewClient(
+		bitgo.WithBaseURL(srv.URL),
+		bitgo.WithCoinInfo(bitgo.CoinInfo{Ticker: "TDOGE", Testnet: true, Family: bitgo.FamilyUTXO, Decimals: 8}),
+		bitgo.WithCoin("tdoge"),
+	)
+	if err := c.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Wallet.Unspents(context.Background(), "", nil, func(*bitgo.UnspentList) {}); err != nil {
+		t.Errorf("expected unspents to be listed, got %v", err)
+	}
+}
+
+func TestUnspentsParamsValidateCoin(t *testing.T) {
+	yes := true
+	bch, _ := bitgo.LookupCoin("bch")
+	btc, _ := bitgo.LookupCoin("btc")
+	tests := []struct {
+		name    string
+		params  bitgo.UnspentsParams
+		coin    bitgo.CoinInfo
+		wantErr bool
+	}{
+		{"p2sh chains", bitgo.UnspentsParams{Chains: []bitgo.Chain{0, 1}}, bch, false},
+		{"segwit chains", bitgo.UnspentsParams{Chains: []bitgo.Chain{10}}, bch, true},
+		{"segwit filter", bitgo.UnspentsParams{Segwit: &yes}, bch, true},
+		{"segwit coin", bitgo.UnspentsParams{Chains: []bitgo.Chain{10}, Segwit: &yes}, btc, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.params.ValidateCoin(test.coin)
+			if (err != nil) != test.wantErr {
+				t.Errorf("expected error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
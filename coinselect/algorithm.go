@@ -0,0 +1,198 @@
+package coinselect
+
+import (
+	"math"
+	"math/rand/v2"
+	"sort"
+
+	"github.com/marselester/bitgo-v2"
+)
+
+// Greedy algorithms add unspents in order until they pay the target and the fee.
+// Unspents worth less than the fee to spend them are skipped.
+var (
+	// LargestFirst spends the fewest inputs, so the transaction fee is low.
+	LargestFirst = Greedy{Less: func(a, b *bitgo.Unspent) bool {
+		return a.Value.Cmp(b.Value) > 0
+	}}
+	// SmallestFirst spends many small inputs, so it consolidates while paying.
+	SmallestFirst = Greedy{Less: func(a, b *bitgo.Unspent) bool {
+		return a.Value.Cmp(b.Value) < 0
+	}}
+	// OldestFirst spends the unspents with the lowest block height first, unconfirmed ones last.
+	OldestFirst = Greedy{Less: func(a, b *bitgo.Unspent) bool {
+		return height(a) < height(b)
+	}}
+)
+
+// Greedy adds unspents ordered by Less until they pay the target and the fee.
+type Greedy struct {
+	// Less reports whether the unspent a should be spent before b.
+	// The original order is kept if it is nil.
+	Less func(a, b *bitgo.Unspent) bool
+}
+
+// Select selects the unspents in order.
+func (g Greedy) Select(unspents []bitgo.Unspent, target bitgo.Amount, fee *FeeModel) (*Selection, error) {
+	pool := append([]bitgo.Unspent(nil), unspents...)
+	if g.Less != nil {
+		sort.SliceStable(pool, func(i, j int) bool {
+			return g.Less(&pool[i], &pool[j])
+		})
+	}
+	return accumulate(pool, target, fee)
+}
+
+// Random adds unspents in random order until they pay the target and the fee,
+// so the wallet's unspents are not linked in a predictable way.
+type Random struct {
+	// Rand is a source of randomness, the global source is used if nil.
+	Rand *rand.Rand
+}
+
+// Select selects the unspents in random order.
+func (r Random) Select(unspents []bitgo.Unspent, target bitgo.Amount, fee *FeeModel) (*Selection, error) {
+	pool := append([]bitgo.Unspent(nil), unspents...)
+	swap := func(i, j int) {
+		pool[i], pool[j] = pool[j], pool[i]
+	}
+	if r.Rand != nil {
+		r.Rand.Shuffle(len(pool), swap)
+	} else {
+		rand.Shuffle(len(pool), swap)
+	}
+	return accumulate(pool, target, fee)
+}
+
+// accumulate adds the unspents in order until they pay the target and the fee.
+func accumulate(pool []bitgo.Unspent, target bitgo.Amount, fee *FeeModel) (*Selection, error) {
+	t, values, err := satoshis(pool, target)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		inputs []bitgo.Unspent
+		total  int64
+	)
+	for i, u := range pool {
+		if values[i] <= fee.InputFee(u.Chain) {
+			continue
+		}
+		inputs = append(inputs, u)
+		total += values[i]
+		if s, ok := finish(inputs, total, t, fee); ok {
+			return s, nil
+		}
+	}
+	return nil, ErrInsufficientFunds
+}
+
+// DefaultMaxTries limits the search of BranchAndBound.
+const DefaultMaxTries = 100000
+
+// BranchAndBound searches for inputs which pay the target and the fee without a change output,
+// wasting at most the cost of creating and later spending the change (the excess goes to the fee).
+// It is the algorithm used by Bitcoin Core. ErrNoChangelessSolution is returned
+// if there is no such combination, then another algorithm should be used.
+type BranchAndBound struct {
+	// MaxTries limits the number of explored combinations, DefaultMaxTries if zero.
+	MaxTries int
+}
+
+// Select finds changeless inputs with the least excess.
+func (b BranchAndBound) Select(unspents []bitgo.Unspent, target bitgo.Amount, fee *FeeModel) (*Selection, error) {
+	t, values, err := satoshis(unspents, target)
+	if err != nil {
+		return nil, err
+	}
+	maxTries := b.MaxTries
+	if maxTries == 0 {
+		maxTries = DefaultMaxTries
+	}
+
+	// Inputs are compared by effective value, i.e., the value minus the fee to spend it.
+	type candidate struct {
+		u         bitgo.Unspent
+		effective int64
+	}
+	var (
+		pool      []candidate
+		available int64
+		segwit    bool
+	)
+	for i, u := range unspents {
+		if e := values[i] - fee.InputFee(u.Chain); e > 0 {
+			pool = append(pool, candidate{u: u, effective: e})
+			available += e
+			segwit = segwit || u.Chain.IsSegwit()
+		}
+	}
+	sort.SliceStable(pool, func(i, j int) bool {
+		return pool[i].effective > pool[j].effective
+	})
+	// The base fee is an upper bound for any subset of the pool.
+	low := t + fee.fee(fee.baseVSize(len(pool), segwit, false))
+	high := low + fee.changeCost()
+	if available < low {
+		return nil, ErrInsufficientFunds
+	}
+
+	var (
+		selected   []int
+		best       []int
+		bestExcess int64 = math.MaxInt64
+		tries      int
+	)
+	var search func(i int, sum, remaining int64)
+	search = func(i int, sum, remaining int64) {
+		if tries >= maxTries || bestExcess == 0 || sum > high {
+			return
+		}
+		tries++
+		if sum >= low {
+			if excess := sum - low; excess < bestExcess {
+				best = append(best[:0], selected...)
+				bestExcess = excess
+			}
+			return
+		}
+		if i == len(pool) || sum+remaining < low {
+			return
+		}
+
+		// Including an input equal to the just excluded one leads to the sums explored
+		// when that input was included.
+		prevExcluded := i > 0 && (len(selected) == 0 || selected[len(selected)-1] != i-1)
+		if !prevExcluded || pool[i].effective != pool[i-1].effective {
+			selected = append(selected, i)
+			search(i+1, sum+pool[i].effective, remaining-pool[i].effective)
+			selected = selected[:len(selected)-1]
+		}
+		search(i+1, sum, remaining-pool[i].effective)
+	}
+	search(0, 0, available)
+	if best == nil {
+		return nil, ErrNoChangelessSolution
+	}
+
+	s := Selection{Inputs: make([]bitgo.Unspent, len(best))}
+	var total int64
+	for j, i := range best {
+		s.Inputs[j] = pool[i].u
+		v, _ := pool[i].u.Value.Int64()
+		total += v
+	}
+	s.Total = bitgo.NewAmount(total)
+	s.Fee = bitgo.NewAmount(total - t)
+	s.VSize = fee.VSize(s.Inputs, false)
+	return &s, nil
+}
+
+// height returns the block height of the unspent, unconfirmed unspents are the youngest.
+func height(u *bitgo.Unspent) int64 {
+	if !u.IsConfirmed() {
+		return math.MaxInt64
+	}
+	return u.BlockHeight
+}
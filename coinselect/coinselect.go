@@ -0,0 +1,197 @@
+// Package coinselect chooses which unspents of a BitGo wallet to spend, so that the inputs
+// are picked locally instead of by BitGo. The selected unspent IDs can be pinned with SendManyParams.Unspents.
+//
+//	fee := coinselect.FeeModel{FeeRate: 20000, Outputs: []int{coinselect.OutputVSize(addr)}}
+//	sel, err := coinselect.BranchAndBound{}.Select(unspents, bitgo.NewAmount(150000), &fee)
+//	if errors.Is(err, coinselect.ErrNoChangelessSolution) {
+//		sel, err = coinselect.LargestFirst.Select(unspents, bitgo.NewAmount(150000), &fee)
+//	}
+//	params := bitgo.SendManyParams{
+//		Recipients: []bitgo.Recipient{{Address: addr.String(), Amount: bitgo.NewAmount(150000)}},
+//		FeeRate:    20000,
+//		Unspents:   sel.IDs(),
+//	}
+//
+// Unspents can be pinned for consolidation as well with WalletConsolidateParams.Unspents,
+// e.g., the dust selected by SmallestFirst.
+package coinselect
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/marselester/bitgo-v2"
+)
+
+var (
+	// ErrInsufficientFunds indicates that the unspents can't pay the target and the fee.
+	ErrInsufficientFunds = errors.New("coinselect: insufficient funds")
+	// ErrNoChangelessSolution indicates that branch and bound found no inputs
+	// which pay the target and the fee without a change output.
+	ErrNoChangelessSolution = errors.New("coinselect: no changeless solution")
+)
+
+// Algorithm selects unspents to pay the target amount and the fee estimated by the fee model.
+// The unspents are not modified.
+type Algorithm interface {
+	Select(unspents []bitgo.Unspent, target bitgo.Amount, fee *FeeModel) (*Selection, error)
+}
+
+// Selection is a result of coin selection.
+type Selection struct {
+	// Inputs are the selected unspents.
+	Inputs []bitgo.Unspent
+	// Total is the value of the inputs.
+	Total bitgo.Amount
+	// Fee is the transaction fee, it includes change below the dust threshold.
+	Fee bitgo.Amount
+	// Change is the value returned to the wallet, zero if the transaction has no change output.
+	Change bitgo.Amount
+	// VSize is the estimated virtual size of the transaction in vbytes.
+	VSize int
+}
+
+// IDs returns the IDs (txid:vout) of the selected unspents.
+func (s *Selection) IDs() []string {
+	ids := make([]string, len(s.Inputs))
+	for i, u := range s.Inputs {
+		ids[i] = u.ID
+	}
+	return ids
+}
+
+// DefaultDustThreshold is a value in satoshis below which change is added to the fee.
+const DefaultDustThreshold = 546
+
+// FeeModel estimates fees of transactions spending 2-of-3 multisig unspents of a BitGo wallet.
+// Fees are rounded up to whole satoshis.
+type FeeModel struct {
+	// FeeRate is a fee rate in satoshis/KB.
+	FeeRate int64
+	// Outputs are virtual sizes in vbytes of the recipients' outputs, see OutputVSize.
+	Outputs []int
+	// ChangeChain is a chain of the change address, ChainP2SH by default.
+	// Only its script type matters for the estimate.
+	ChangeChain bitgo.Chain
+	// DustThreshold is a value in satoshis below which change is not worth an output,
+	// DefaultDustThreshold if zero.
+	DustThreshold int64
+}
+
+// InputFee returns the fee to spend an unspent of the chain.
+// Unknown chains are estimated as P2SH, the largest input.
+func (m *FeeModel) InputFee(chain bitgo.Chain) int64 {
+	return m.fee(chain.InputVSize())
+}
+
+// VSize returns the estimated virtual size of a transaction spending the inputs
+// with or without a change output.
+func (m *FeeModel) VSize(inputs []bitgo.Unspent, change bool) int {
+	segwit := false
+	vsize := 0
+	for _, u := range inputs {
+		segwit = segwit || u.Chain.IsSegwit()
+		vsize += u.Chain.InputVSize()
+	}
+	return vsize + m.baseVSize(len(inputs), segwit, change)
+}
+
+// Fee returns the estimated fee of a transaction spending the inputs with or without a change output.
+func (m *FeeModel) Fee(inputs []bitgo.Unspent, change bool) int64 {
+	return m.fee(m.VSize(inputs, change))
+}
+
+// changeCost returns the fee to create a change output and to spend it later.
+func (m *FeeModel) changeCost() int64 {
+	return m.fee(m.changeChain().OutputVSize()) + m.InputFee(m.changeChain())
+}
+
+// baseVSize returns the size of the transaction without inputs.
+func (m *FeeModel) baseVSize(numInputs int, segwit, change bool) int {
+	numOutputs := len(m.Outputs)
+	// Version and lock time, input and output counts, and segwit marker with flag (rounded up to a vbyte).
+	vsize := 8 + bitgo.VarIntSize(numInputs)
+	if segwit {
+		vsize++
+	}
+	for _, n := range m.Outputs {
+		vsize += n
+	}
+	if change {
+		numOutputs++
+		vsize += m.changeChain().OutputVSize()
+	}
+	return vsize + bitgo.VarIntSize(numOutputs)
+}
+
+func (m *FeeModel) changeChain() bitgo.Chain {
+	if m.ChangeChain.IsValid() {
+		return m.ChangeChain
+	}
+	return bitgo.ChainP2SH
+}
+
+func (m *FeeModel) dustThreshold() int64 {
+	if m.DustThreshold > 0 {
+		return m.DustThreshold
+	}
+	return DefaultDustThreshold
+}
+
+// fee returns the fee for vsize vbytes rounded up.
+func (m *FeeModel) fee(vsize int) int64 {
+	return (m.FeeRate*int64(vsize) + 999) / 1000
+}
+
+// OutputVSize returns a virtual size in vbytes of an output paying to the address.
+func OutputVSize(a *bitgo.Address) int {
+	// Value, script length, and the script.
+	const overhead = 8 + 1
+	switch a.Type {
+	case bitgo.AddressP2PKH:
+		return overhead + 25
+	case bitgo.AddressP2SH:
+		return overhead + 23
+	default:
+		// Witness version, program length, and the program.
+		return overhead + 2 + len(a.Hash)
+	}
+}
+
+// finish completes the selection of the inputs if they pay the target and the fee.
+// Change is created only if it is above the dust threshold.
+func finish(inputs []bitgo.Unspent, total, target int64, fee *FeeModel) (*Selection, bool) {
+	s := Selection{
+		Inputs: inputs,
+		Total:  bitgo.NewAmount(total),
+	}
+	if change := total - target - fee.Fee(inputs, true); change >= fee.dustThreshold() {
+		s.Change = bitgo.NewAmount(change)
+		s.Fee = bitgo.NewAmount(total - target - change)
+		s.VSize = fee.VSize(inputs, true)
+		return &s, true
+	}
+	if total-target >= fee.Fee(inputs, false) {
+		s.Fee = bitgo.NewAmount(total - target)
+		s.VSize = fee.VSize(inputs, false)
+		return &s, true
+	}
+	return nil, false
+}
+
+// satoshis returns the target amount and the values of the unspents in satoshis.
+func satoshis(unspents []bitgo.Unspent, target bitgo.Amount) (int64, []int64, error) {
+	t, ok := target.Int64()
+	if !ok || t <= 0 {
+		return 0, nil, fmt.Errorf("coinselect: invalid target %s", target)
+	}
+	values := make([]int64, len(unspents))
+	for i, u := range unspents {
+		v, ok := u.Value.Int64()
+		if !ok || v < 0 {
+			return 0, nil, fmt.Errorf("coinselect: invalid unspent %s value %s", u.ID, u.Value)
+		}
+		values[i] = v
+	}
+	return t, values, nil
+}
@@ -0,0 +1,139 @@
+package coinselect_test
+
+import (
+	"errors"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/marselester/bitgo-v2"
+	"github.com/marselester/bitgo-v2/coinselect"
+)
+
+// At 1 sat/vbyte a P2WSH input costs 105 satoshis and the base of a transaction
+// with one P2SH recipient and segwit inputs costs 43 satoshis.
+var (
+	unspents = []bitgo.Unspent{
+		{ID: "01:0", Value: bitgo.NewAmount(100000), Chain: bitgo.ChainP2WSH, BlockHeight: 300},
+		{ID: "02:0", Value: bitgo.NewAmount(20105), Chain: bitgo.ChainP2WSH, BlockHeight: bitgo.UnconfirmedHeight},
+		{ID: "03:0", Value: bitgo.NewAmount(5000), Chain: bitgo.ChainP2WSHChange, BlockHeight: 200},
+		{ID: "04:0", Value: bitgo.NewAmount(30148), Chain: bitgo.ChainP2WSH, BlockHeight: 100},
+		{ID: "05:0", Value: bitgo.NewAmount(100), Chain: bitgo.ChainP2WSH, BlockHeight: 50},
+	}
+	target = bitgo.NewAmount(50000)
+)
+
+func newFeeModel() *coinselect.FeeModel {
+	return &coinselect.FeeModel{
+		FeeRate:     1000,
+		Outputs:     []int{coinselect.OutputVSize(&bitgo.Address{Type: bitgo.AddressP2SH})},
+		ChangeChain: bitgo.ChainP2WSHChange,
+	}
+}
+
+func TestSelect(t *testing.T) {
+	tests := map[string]struct {
+		algo   coinselect.Algorithm
+		want   string
+		change int64
+	}{
+		"branch and bound": {
+			algo: coinselect.BranchAndBound{},
+			want: "04:0 02:0",
+		},
+		"largest first": {
+			algo:   coinselect.LargestFirst,
+			want:   "01:0",
+			change: 100000 - 50000 - (105 + 8 + 1 + 1 + 32 + 43 + 1),
+		},
+		"smallest first skips uneconomical": {
+			algo:   coinselect.SmallestFirst,
+			want:   "03:0 02:0 04:0",
+			change: 55253 - 50000 - (105*3 + 8 + 1 + 1 + 32 + 43 + 1),
+		},
+		"oldest first": {
+			algo:   coinselect.OldestFirst,
+			want:   "04:0 03:0 01:0",
+			change: 135148 - 50000 - (105*3 + 8 + 1 + 1 + 32 + 43 + 1),
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fee := newFeeModel()
+			s, err := test.algo.Select(unspents, target, fee)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(s.IDs(), " "); got != test.want {
+				t.Errorf("expected %s inputs, got %s", test.want, got)
+			}
+			if s.Change.Cmp(bitgo.NewAmount(test.change)) != 0 {
+				t.Errorf("expected %d change, got %s", test.change, s.Change)
+			}
+			if s.Total.Cmp(target.Add(s.Fee).Add(s.Change)) != 0 {
+				t.Errorf("total %s doesn't match target, fee %s and change %s", s.Total, s.Fee, s.Change)
+			}
+			if min := fee.Fee(s.Inputs, !s.Change.IsZero()); s.Fee.Cmp(bitgo.NewAmount(min)) < 0 {
+				t.Errorf("fee %s is less than estimated %d", s.Fee, min)
+			}
+		})
+	}
+}
+
+func TestSelectRandom(t *testing.T) {
+	algo := coinselect.Random{Rand: rand.New(rand.NewPCG(1, 2))}
+	for i := 0; i < 20; i++ {
+		s, err := algo.Select(unspents, target, newFeeModel())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Total.Cmp(target.Add(s.Fee).Add(s.Change)) != 0 {
+			t.Fatalf("total %s doesn't match target, fee %s and change %s", s.Total, s.Fee, s.Change)
+		}
+		for _, id := range s.IDs() {
+			if id == "05:0" {
+				t.Fatal("uneconomical unspent is selected")
+			}
+		}
+	}
+}
+
+func TestSelectErrors(t *testing.T) {
+	algos := []coinselect.Algorithm{
+		coinselect.BranchAndBound{},
+		coinselect.LargestFirst,
+		coinselect.Random{},
+	}
+	for _, algo := range algos {
+		_, err := algo.Select(unspents, bitgo.NewAmount(1000000), newFeeModel())
+		if !errors.Is(err, coinselect.ErrInsufficientFunds) {
+			t.Errorf("%T: expected insufficient funds, got %v", algo, err)
+		}
+		if _, err = algo.Select(unspents, bitgo.Amount{}, newFeeModel()); err == nil {
+			t.Errorf("%T: expected invalid target error", algo)
+		}
+	}
+
+	_, err := coinselect.BranchAndBound{}.Select(unspents, bitgo.NewAmount(60000), newFeeModel())
+	if !errors.Is(err, coinselect.ErrNoChangelessSolution) {
+		t.Errorf("expected no changeless solution, got %v", err)
+	}
+}
+
+func TestOutputVSize(t *testing.T) {
+	tests := []struct {
+		addr bitgo.Address
+		want int
+	}{
+		{bitgo.Address{Type: bitgo.AddressP2PKH, Hash: make([]byte, 20)}, 34},
+		{bitgo.Address{Type: bitgo.AddressP2SH, Hash: make([]byte, 20)}, 32},
+		{bitgo.Address{Type: bitgo.AddressP2WPKH, Hash: make([]byte, 20)}, 31},
+		{bitgo.Address{Type: bitgo.AddressP2WSH, Hash: make([]byte, 32)}, 43},
+		{bitgo.Address{Type: bitgo.AddressP2TR, Hash: make([]byte, 32)}, 43},
+	}
+	for _, test := range tests {
+		if got := coinselect.OutputVSize(&test.addr); got != test.want {
+			t.Errorf("%s: expected %d vbytes, got %d", test.addr.Type, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+// Package consolidation estimates the cost of consolidating wallet unspents before calling Wallet.Consolidate.
+// It selects unspents the way BitGo does for the given WalletConsolidateParams
+// and estimates the transaction size of 2-of-3 multisig inputs (P2SH, P2SH-P2WSH, P2WSH, P2TR).
+//
+//	params := bitgo.WalletConsolidateParams{Limit: 100, MaxValue: bitgo.NewAmount(10000), FeeRate: 20000}
+//	sim, err := consolidation.Simulate(ctx, c, walletID, &params, nil)
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(sim.Fee, sim.Net, sim.FeePercentage())
+package consolidation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/marselester/bitgo-v2"
+)
+
+// Consolidation defaults as documented in https://www.bitgo.com/api/v2/#consolidate-wallet-unspents.
+const (
+	DefaultLimit = 25
+	MaxLimit     = 200
+)
+
+var (
+	// ErrNothingToConsolidate indicates that there are not enough matching unspents
+	// to make fewer outputs than inputs.
+	ErrNothingToConsolidate = errors.New("consolidation: no unspents available for consolidation")
+	// ErrUnknownFeeRate indicates that neither the params nor the options set a fee rate.
+	ErrUnknownFeeRate = errors.New("consolidation: unknown fee rate")
+)
+
+// Options configures the simulation.
+type Options struct {
+	// FeeRate in satoshis/KB is used when the params have no FeeRate,
+	// e.g., when BitGo chooses it with FeeTxConfirmTarget.
+	FeeRate int64
+	// OutputChain is a chain of the addresses receiving consolidated coins, ChainP2SH by default.
+	// Only its script type matters for the estimate.
+	OutputChain bitgo.Chain
+	// Tip is the current block height. When it is known, MinConfirms is checked locally
+	// and change unspents are exempt unless EnforceMinConfirmsForChange is set.
+	// Otherwise MinConfirms is checked by BitGo for all unspents.
+	Tip int64
+	// PageSize is a number of unspents fetched per page (API default if zero).
+	PageSize int
+}
+
+// InputGroup describes selected inputs of the same script type.
+type InputGroup struct {
+	ScriptType string
+	Count      int
+	Value      bitgo.Amount
+	// VSize is the estimated virtual size of the inputs in vbytes.
+	VSize int
+}
+
+// Simulation is an estimated outcome of the consolidation.
+type Simulation struct {
+	// Inputs are the selected unspents in the order BitGo would pick them.
+	Inputs []bitgo.Unspent
+	// InputGroups are the inputs grouped by script type.
+	InputGroups []InputGroup
+	// NumOutputs is a number of outputs created by the transaction.
+	NumOutputs int
+	// FeeRate is the fee rate in satoshis/KB used to estimate the fee.
+	FeeRate int64
+	// VSize is the estimated virtual size of the transaction in vbytes.
+	VSize int
+	// Total is the value of the inputs.
+	Total bitgo.Amount
+	// Fee is the estimated transaction fee.
+	Fee bitgo.Amount
+	// Net is the value consolidated after the fee, it is negative when the fee exceeds the inputs.
+	Net bitgo.Amount
+}
+
+// FeePercentage returns the fee as a percentage of the inputs value.
+func (s *Simulation) FeePercentage() float64 {
+	total, _ := s.Total.BigInt().Float64()
+	if total == 0 {
+		return 0
+	}
+	fee, _ := s.Fee.BigInt().Float64()
+	return fee / total * 100
+}
+
+// Simulate fetches the wallet unspents matching the params and estimates the consolidation
+// without sending anything. The opts can be nil.
+// ErrNothingToConsolidate is returned if BitGo would reject the consolidation for lack of unspents.
+func Simulate(ctx context.Context, c *bitgo.Client, walletID string, params *bitgo.WalletConsolidateParams, opts *Options) (*Simulation, error) {
+	if err := c.Err(); err != nil {
+		return nil, err
+	}
+	if params == nil {
+		params = &bitgo.WalletConsolidateParams{}
+	}
+	if opts == nil {
+		opts = &Options{}
+	}
+	if err := params.ValidateCoin(c.CoinInfo()); err != nil {
+		return nil, err
+	}
+	limit, feeRate, err := limits(params, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	q := bitgo.UnspentsParams{
+		MinValue:   params.MinValue,
+		MaxValue:   params.MaxValue,
+		MinHeight:  int64(params.MinHeight),
+		UnspentIDs: params.Unspents,
+	}
+	if opts.Tip == 0 {
+		q.MinConfirms = params.MinConfirms
+	}
+	if err = q.Validate(); err != nil {
+		return nil, err
+	}
+
+	it := c.Wallet.UnspentsIter(ctx, walletID, q.Values(), &bitgo.ListOptions{PageSize: opts.PageSize})
+	defer it.Close()
+	var inputs []bitgo.Unspent
+	for len(inputs) < limit && it.Next() {
+		u := it.Value()
+		if eligible(u, params, opts.Tip, feeRate) {
+			inputs = append(inputs, u)
+		}
+	}
+	if err = it.Err(); err != nil {
+		return nil, err
+	}
+
+	return estimate(inputs, params, opts, feeRate)
+}
+
+// Estimate selects the unspents BitGo would consolidate with the params from the already listed unspents
+// (in the order they were listed) and estimates the consolidation. The opts can be nil.
+// Unlike Simulate, MinConfirms is ignored if Options.Tip is unknown.
+func Estimate(unspents []bitgo.Unspent, params *bitgo.WalletConsolidateParams, opts *Options) (*Simulation, error) {
+	if params == nil {
+		params = &bitgo.WalletConsolidateParams{}
+	}
+	if opts == nil {
+		opts = &Options{}
+	}
+	limit, feeRate, err := limits(params, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	pinned := make(map[string]bool, len(params.Unspents))
+	for _, id := range params.Unspents {
+		pinned[id] = true
+	}
+	var inputs []bitgo.Unspent
+	for _, u := range unspents {
+		if len(inputs) == limit {
+			break
+		}
+		if len(pinned) > 0 && !pinned[u.ID] {
+			continue
+		}
+		if matches(u, params) && eligible(u, params, opts.Tip, feeRate) {
+			inputs = append(inputs, u)
+		}
+	}
+	return estimate(inputs, params, opts, feeRate)
+}
+
+// limits returns the number of unspents to select and the fee rate.
+func limits(params *bitgo.WalletConsolidateParams, opts *Options) (limit int, feeRate int64, err error) {
+	limit = params.Limit
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+	if limit < 0 || limit > MaxLimit {
+		return 0, 0, fmt.Errorf("consolidation: limit must be between 1 and %d", MaxLimit)
+	}
+	feeRate = int64(params.FeeRate)
+	if feeRate == 0 {
+		feeRate = opts.FeeRate
+	}
+	if feeRate <= 0 {
+		return 0, 0, ErrUnknownFeeRate
+	}
+	return limit, feeRate, nil
+}
+
+// matches reports whether the unspent passes the value and height filters
+// which Simulate delegates to BitGo unspents listing.
+func matches(u bitgo.Unspent, params *bitgo.WalletConsolidateParams) bool {
+	if params.MinValue.Sign() > 0 && u.Value.Cmp(params.MinValue) < 0 {
+		return false
+	}
+	if params.MaxValue.Sign() > 0 && u.Value.Cmp(params.MaxValue) > 0 {
+		return false
+	}
+	if params.MinHeight > 0 && (!u.IsConfirmed() || u.BlockHeight < int64(params.MinHeight)) {
+		return false
+	}
+	return true
+}
+
+// eligible reports whether the unspent has enough confirmations given the tip,
+// and whether its value covers the fee to spend it within MaxFeePercentage.
+func eligible(u bitgo.Unspent, params *bitgo.WalletConsolidateParams, tip, feeRate int64) bool {
+	exempt := u.Chain.IsChange() && !params.EnforceMinConfirmsForChange
+	if params.MinConfirms > 0 && tip > 0 && !exempt && u.Confirmations(tip) < int64(params.MinConfirms) {
+		return false
+	}
+	if params.MaxFeePercentage > 0 {
+		fee := big.NewInt(feeRate * int64(u.Chain.InputVSize()) / 1000 * 100)
+		max := new(big.Int).Mul(u.Value.BigInt(), big.NewInt(int64(params.MaxFeePercentage)))
+		return fee.Cmp(max) <= 0
+	}
+	return true
+}
+
+// estimate estimates the size and fee of the consolidation transaction spending the inputs.
+func estimate(inputs []bitgo.Unspent, params *bitgo.WalletConsolidateParams, opts *Options, feeRate int64) (*Simulation, error) {
+	numOutputs := params.NumUnspentsToMake
+	if numOutputs == 0 {
+		numOutputs = 1
+	}
+	if len(inputs) <= numOutputs {
+		return nil, ErrNothingToConsolidate
+	}
+	outputChain := opts.OutputChain
+	if !outputChain.IsValid() {
+		return nil, fmt.Errorf("consolidation: unknown output chain %d", outputChain)
+	}
+
+	s := Simulation{
+		Inputs:     inputs,
+		NumOutputs: numOutputs,
+		FeeRate:    feeRate,
+	}
+	groups := make(map[string]*InputGroup)
+	segwit := false
+	for _, u := range inputs {
+		s.Total = s.Total.Add(u.Value)
+		segwit = segwit || u.Chain.IsSegwit()
+
+		scriptType := u.Chain.ScriptType()
+		if scriptType == "" {
+			scriptType = "unknown"
+		}
+		g, ok := groups[scriptType]
+		if !ok {
+			g = &InputGroup{ScriptType: scriptType}
+			groups[scriptType] = g
+		}
+		g.Count++
+		g.Value = g.Value.Add(u.Value)
+		g.VSize += u.Chain.InputVSize()
+		s.VSize += u.Chain.InputVSize()
+	}
+	for _, g := range groups {
+		s.InputGroups = append(s.InputGroups, *g)
+	}
+	sort.Slice(s.InputGroups, func(i, j int) bool {
+		return s.InputGroups[i].ScriptType < s.InputGroups[j].ScriptType
+	})
+
+	// Version and lock time, input and output counts, and segwit marker with flag (rounded up to a vbyte).
+	s.VSize += 8 + bitgo.VarIntSize(len(inputs)) + bitgo.VarIntSize(numOutputs)
+	if segwit {
+		s.VSize++
+	}
+	s.VSize += numOutputs * outputChain.OutputVSize()
+
+	s.Fee = bitgo.NewAmount(feeRate * int64(s.VSize) / 1000)
+	s.Net = s.Total.Sub(s.Fee)
+	return &s, nil
+}
+
+// WriteTable writes the simulation as a human readable table.
+// Values are formatted in coins with the given number of decimals, e.g., 8 for BTC.
+func (s *Simulation) WriteTable(w io.Writer, decimals int) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "INPUTS\tCOUNT\tVSIZE\tVALUE\t")
+	for _, g := range s.InputGroups {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t\n", g.ScriptType, g.Count, g.VSize, g.Value.Format(decimals))
+	}
+	fmt.Fprintf(tw, "total\t%d\t%d\t%s\t\n", len(s.Inputs), s.VSize, s.Total.Format(decimals))
+	fmt.Fprintln(tw, "\t\t\t\t")
+	fmt.Fprintf(tw, "outputs\t%d\t\t\t\n", s.NumOutputs)
+	fmt.Fprintf(tw, "fee rate\t\t\t%d sat/KB\t\n", s.FeeRate)
+	fmt.Fprintf(tw, "fee\t\t\t%s\t\n", s.Fee.Format(decimals))
+	fmt.Fprintf(tw, "fee percentage\t\t\t%s%%\t\n", strconv.FormatFloat(s.FeePercentage(), 'f', 2, 64))
+	fmt.Fprintf(tw, "net\t\t\t%s\t\n", s.Net.Format(decimals))
+	return tw.Flush()
+}
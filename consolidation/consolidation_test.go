@@ -0,0 +1,138 @@
+package consolidation_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/marselester/bitgo-v2"
+	"github.com/marselester/bitgo-v2/bitgotest"
+	"github.com/marselester/bitgo-v2/consolidation"
+)
+
+const walletID = "585951a5df8380e0e3063e9f"
+
+func TestSimulate(t *testing.T) {
+	fake := bitgotest.NewServer()
+	fake.AddWallet("tbtc", walletID, "")
+	fake.AddUnspents(walletID, bitgotest.Dust(30, 600)...)
+	fake.AddUnspents(walletID, bitgotest.Dust(5, 100000)...)
+	c := fake.Client(t)
+
+	params := bitgo.WalletConsolidateParams{Limit: 20, MaxValue: bitgo.NewAmount(1000), FeeRate: 1000}
+	sim, err := consolidation.Simulate(context.Background(), c, walletID, &params, &consolidation.Options{PageSize: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 20 P2SH inputs of 298 vbytes, 10 bytes of overhead and a P2SH output of 32 vbytes.
+	if len(sim.Inputs) != 20 || sim.VSize != 20*298+10+32 {
+		t.Fatalf("expected 20 inputs of %d vbytes, got %d of %d", 20*298+10+32, len(sim.Inputs), sim.VSize)
+	}
+	if sim.Total.Cmp(bitgo.NewAmount(12000)) != 0 || sim.Fee.Cmp(bitgo.NewAmount(6002)) != 0 || sim.Net.Cmp(bitgo.NewAmount(5998)) != 0 {
+		t.Errorf("unexpected total %s, fee %s, net %s", sim.Total, sim.Fee, sim.Net)
+	}
+	if p := sim.FeePercentage(); p < 50 || p > 50.02 {
+		t.Errorf("unexpected fee percentage %f", p)
+	}
+
+	// BitGo consolidates the same unspents as simulated.
+	if _, err = c.Wallet.Consolidate(context.Background(), walletID, &params); err != nil {
+		t.Fatal(err)
+	}
+	w, _ := fake.Wallet(walletID)
+	spent := w.Transfers[0].Inputs
+	if len(spent) != len(sim.Inputs) {
+		t.Fatalf("expected %d spent inputs, got %d", len(sim.Inputs), len(spent))
+	}
+	for i, u := range sim.Inputs {
+		if spent[i] != u.ID {
+			t.Errorf("input %d: expected %s, got %s", i, spent[i], u.ID)
+		}
+	}
+
+	var b strings.Builder
+	if err = sim.WriteTable(&b, 8); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "0.00006002") || !strings.Contains(b.String(), "50.02%") {
+		t.Errorf("unexpected table:\n%s", b.String())
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	unspents := []bitgo.Unspent{
+		{ID: "01:0", Value: bitgo.NewAmount(1000), Chain: bitgo.ChainP2WSH, BlockHeight: 100},
+		{ID: "02:0", Value: bitgo.NewAmount(1000), Chain: bitgo.ChainP2WSHChange, BlockHeight: bitgo.UnconfirmedHeight},
+		{ID: "03:0", Value: bitgo.NewAmount(1000), Chain: bitgo.ChainP2WSH, BlockHeight: bitgo.UnconfirmedHeight},
+		{ID: "04:0", Value: bitgo.NewAmount(300), Chain: bitgo.ChainP2SH, BlockHeight: 95},
+		{ID: "05:0", Value: bitgo.NewAmount(5000), Chain: bitgo.ChainP2TR, BlockHeight: 96},
+	}
+	tests := map[string]struct {
+		params bitgo.WalletConsolidateParams
+		opts   consolidation.Options
+		want   []string
+		vsize  int
+	}{
+		"all": {
+			want:  []string{"01:0", "02:0", "03:0", "04:0", "05:0"},
+			vsize: 105*3 + 298 + 108 + 11 + 32,
+		},
+		"limit": {
+			params: bitgo.WalletConsolidateParams{Limit: 2},
+			want:   []string{"01:0", "02:0"},
+			vsize:  105*2 + 11 + 32,
+		},
+		"change is exempt from confirmations": {
+			params: bitgo.WalletConsolidateParams{MinConfirms: 1},
+			opts:   consolidation.Options{Tip: 100},
+			want:   []string{"01:0", "02:0", "04:0", "05:0"},
+			vsize:  105*2 + 298 + 108 + 11 + 32,
+		},
+		"enforce confirmations for change": {
+			params: bitgo.WalletConsolidateParams{MinConfirms: 2, EnforceMinConfirmsForChange: true},
+			opts:   consolidation.Options{Tip: 100},
+			want:   []string{"04:0", "05:0"},
+			vsize:  298 + 108 + 11 + 32,
+		},
+		"max fee percentage": {
+			params: bitgo.WalletConsolidateParams{MaxFeePercentage: 11},
+			opts:   consolidation.Options{OutputChain: bitgo.ChainP2TR},
+			want:   []string{"01:0", "02:0", "03:0", "05:0"},
+			vsize:  105*3 + 108 + 11 + 43,
+		},
+		"min height and value": {
+			params: bitgo.WalletConsolidateParams{MinHeight: 95, MinValue: bitgo.NewAmount(500)},
+			want:   []string{"01:0", "05:0"},
+			vsize:  105 + 108 + 11 + 32,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.opts.FeeRate = 1000
+			sim, err := consolidation.Estimate(unspents, &test.params, &test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, u := range sim.Inputs {
+				got = append(got, u.ID)
+			}
+			if strings.Join(got, " ") != strings.Join(test.want, " ") {
+				t.Errorf("expected %v inputs, got %v", test.want, got)
+			}
+			if sim.VSize != test.vsize {
+				t.Errorf("expected %d vbytes, got %d", test.vsize, sim.VSize)
+			}
+		})
+	}
+
+	_, err := consolidation.Estimate(unspents, &bitgo.WalletConsolidateParams{Limit: 3, NumUnspentsToMake: 3}, &consolidation.Options{FeeRate: 1000})
+	if !errors.Is(err, consolidation.ErrNothingToConsolidate) {
+		t.Errorf("expected nothing to consolidate, got %v", err)
+	}
+	_, err = consolidation.Estimate(unspents, nil, nil)
+	if !errors.Is(err, consolidation.ErrUnknownFeeRate) {
+		t.Errorf("expected unknown fee rate, got %v", err)
+	}
+}
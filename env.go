@@ -0,0 +1,131 @@
+package bitgo
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Environment is a BitGo environment the client talks to.
+// More about environments https://www.bitgo.com/api/v2/?shell#environments.
+type Environment struct {
+	// Name is a human readable name used in error messages.
+	Name string
+	// BaseURL is the default base URL of the environment.
+	BaseURL string
+	// Mainnet is true when the environment operates on real coins (btc),
+	// and false when it operates on testnet coins (tbtc).
+	Mainnet bool
+}
+
+// Known environments. BitGo Express can be run either against production or test environment,
+// so there are two local Express presets.
+var (
+	EnvProduction  = Environment{Name: "production", BaseURL: "https://www.bitgo.com", Mainnet: true}
+	EnvTest        = Environment{Name: "test", BaseURL: "https://test.bitgo.com", Mainnet: false}
+	EnvExpress     = Environment{Name: "express", BaseURL: "http://localhost:3080", Mainnet: true}
+	EnvExpressTest = Environment{Name: "express-test", BaseURL: "http://localhost:3080", Mainnet: false}
+)
+
+// LookupEnvironment returns a known environment by its name, e.g., "production" or "express-test".
+func LookupEnvironment(name string) (Environment, bool) {
+	for _, env := range []Environment{EnvProduction, EnvTest, EnvExpress, EnvExpressTest} {
+		if env.Name == name {
+			return env, true
+		}
+	}
+	return Environment{}, false
+}
+
+// ErrProductionSpending is returned by spending calls (e.g., Consolidate)
+// made in a mainnet environment or with a mainnet coin without WithProductionSpending option.
+var ErrProductionSpending = errors.New("bitgo: spending in production requires WithProductionSpending option")
+
+// hostedEnvironments maps BitGo hosts to their environments.
+var hostedEnvironments = map[string]Environment{
+	"www.bitgo.com":      EnvProduction,
+	"app.bitgo.com":      EnvProduction,
+	"bitgo.com":          EnvProduction,
+	"test.bitgo.com":     EnvTest,
+	"app.bitgo-test.com": EnvTest,
+}
+
+// WithEnvironment configures Client to use BitGo environment.
+// The base URL defaults to the environment's one, WithBaseURL takes precedence regardless of the options order,
+// e.g., when BitGo Express runs on another host.
+// The client checks that the coin and the base URL are compatible with the environment.
+func WithEnvironment(env Environment) ConfigOption {
+	return func(c *Config) {
+		c.env = &env
+	}
+}
+
+// WithProductionSpending allows spending calls (e.g., Consolidate) in a mainnet environment.
+// Without this option such calls fail with ErrProductionSpending.
+// If the environment is unknown, e.g., WithBaseURL points to a self-hosted Express,
+// the option is required for every coin except the testnet ones.
+func WithProductionSpending() ConfigOption {
+	return func(c *Config) {
+		c.productionSpending = true
+	}
+}
+
+// environment returns the configured environment or the one inferred from base URL.
+// It returns false if the environment is unknown, e.g., a custom Express URL was set without WithEnvironment.
+func (c *Config) environment() (Environment, bool) {
+	if c.env != nil {
+		return *c.env, true
+	}
+	env, ok := hostedEnvironment(c.baseURL)
+	return env, ok
+}
+
+// hostedEnvironment returns the BitGo environment the URL points to.
+func hostedEnvironment(baseURL string) (Environment, bool) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return Environment{}, false
+	}
+	env, ok := hostedEnvironments[strings.ToLower(u.Hostname())]
+	return env, ok
+}
+
+// validateEnvironment checks that the coin and base URL are compatible with the environment.
+// The coin must be validated with validateCoin first.
+func (c *Config) validateEnvironment() error {
+	env, ok := c.environment()
+	if !ok {
+		return nil
+	}
+	if hosted, ok := hostedEnvironment(c.baseURL); ok && hosted.Mainnet != env.Mainnet {
+		return fmt.Errorf("bitgo: base URL %s belongs to %s environment, not %s", c.baseURL, hosted.Name, env.Name)
+	}
+
+	testnet := c.coinInfo.Testnet
+	if env.Mainnet && testnet {
+		return fmt.Errorf("bitgo: testnet coin %q can't be used in %s environment", c.coin, env.Name)
+	}
+	if !env.Mainnet && !testnet {
+		return fmt.Errorf("bitgo: mainnet coin %q can't be used in %s environment", c.coin, env.Name)
+	}
+	return nil
+}
+
+// checkSpending returns ErrProductionSpending if a spending call is about to be made
+// in a mainnet environment without explicit permission.
+// When the environment is unknown (e.g., a custom Express URL), any coin which is not a known testnet coin
+// is assumed to be spent on mainnet.
+func (c *Config) checkSpending() error {
+	if c.productionSpending {
+		return nil
+	}
+	mainnet := !c.coinInfo.Testnet
+	if env, ok := c.environment(); ok {
+		mainnet = env.Mainnet
+	}
+	if mainnet {
+		return ErrProductionSpending
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package bitgo_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marselester/bitgo-v2"
+)
+
+func TestEnvironmentCompatibility(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []bitgo.ConfigOption
+		wantErr bool
+	}{
+		{"default", nil, false},
+		{"testnet coin in production", []bitgo.ConfigOption{bitgo.WithCoin("tbtc")}, true},
+		{"mainnet coin in test", []bitgo.ConfigOption{bitgo.WithEnvironment(bitgo.EnvTest), bitgo.WithCoin("btc")}, true},
+		{"testnet coin in test", []bitgo.ConfigOption{bitgo.WithEnvironment(bitgo.EnvTest), bitgo.WithCoin("tbch")}, false},
+		{"test URL", []bitgo.ConfigOption{bitgo.WithBaseURL("https://test.bitgo.com"), bitgo.WithCoin("ltc")}, true},
+		{"staging config hits production", []bitgo.ConfigOption{bitgo.WithEnvironment(bitgo.EnvExpressTest), bitgo.WithBaseURL("https://www.bitgo.com"), bitgo.WithCoin("tbtc")}, true},
+		{"express on another host", []bitgo.ConfigOption{bitgo.WithEnvironment(bitgo.EnvExpressTest), bitgo.WithBaseURL("http://10.0.0.1:3080"), bitgo.WithCoin("tbtc")}, false},
+		{"base URL before environment", []bitgo.ConfigOption{bitgo.WithBaseURL("https://www.bitgo.com"), bitgo.WithEnvironment(bitgo.EnvExpressTest), bitgo.WithCoin("tbtc")}, true},
+		{"express on another host before environment", []bitgo.ConfigOption{bitgo.WithBaseURL("http://10.0.0.1:3080"), bitgo.WithEnvironment(bitgo.EnvExpressTest), bitgo.WithCoin("tbtc")}, false},
+		{"custom URL", []bitgo.ConfigOption{bitgo.WithBaseURL("http://10.0.0.1:3080"), bitgo.WithCoin("tbtc")}, false},
+		{"unknown coin", []bitgo.ConfigOption{bitgo.WithCoin("tfoo")}, true},
+		{"unknown coin in test", []bitgo.ConfigOption{bitgo.WithEnvironment(bitgo.EnvTest), bitgo.WithCoin("foo")}, true},
+		{"described coin", []bitgo.ConfigOption{bitgo.WithCoinInfo(bitgo.CoinInfo{Ticker: "tfoo", Testnet: true, Family: bitgo.FamilyUTXO}), bitgo.WithCoin("tfoo")}, true},
+		{"described coin in test", []bitgo.ConfigOption{bitgo.WithEnvironment(bitgo.EnvTest), bitgo.WithCoinInfo(bitgo.CoinInfo{Ticker: "tfoo", Testnet: true}), bitgo.WithCoin("tfoo")}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := bitgo.NewClient(test.options...)
+			err := c.Err()
+			if (err != nil) != test.wantErr {
+				t.Fatalf("expected error %v, got %v", test.wantErr, err)
+			}
+			if _, reqErr := c.NewRequest(context.Background(), http.MethodGet, "wallet", nil, nil); reqErr != err {
+				t.Fatalf("request should fail with %v, got %v", err, reqErr)
+			}
+		})
+	}
+
+	if err := bitgo.NewClient().Coin("tbtc").Err(); err == nil {
+		t.Error("coin view must be validated")
+	}
+}
+
+func TestProductionSpending(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c := bitgo.NewClient(
+		bitgo.WithEnvironment(bitgo.EnvExpress),
+		bitgo.WithBaseURL(srv.URL),
+	)
+	_, err := c.Wallet.Consolidate(context.Background(), "585951a5df8380e0e3063e9f", nil)
+	if err != bitgo.ErrProductionSpending {
+		t.Fatalf("expected %v, got %v", bitgo.ErrProductionSpending, err)
+	}
+
+	c = bitgo.NewClient(
+		bitgo.WithEnvironment(bitgo.EnvExpress),
+		bitgo.WithBaseURL(srv.URL),
+		bitgo.WithProductionSpending(),
+	)
+	if _, err = c.Wallet.Consolidate(context.Background(), "585951a5df8380e0e3063e9f", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// The base URL is kept regardless of the options order.
+	c = bitgo.NewClient(
+		bitgo.WithBaseURL(srv.URL),
+		bitgo.WithEnvironment(bitgo.EnvExpressTest),
+		bitgo.WithCoin("tbtc"),
+	)
+	if _, err = c.Wallet.Consolidate(context.Background(), "585951a5df8380e0e3063e9f", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// The environment of a self-hosted Express is unknown, so the coin decides.
+	c = bitgo.NewClient(bitgo.WithBaseURL(srv.URL))
+	if _, err = c.Wallet.Consolidate(context.Background(), "585951a5df8380e0e3063e9f", nil); err != bitgo.ErrProductionSpending {
+		t.Fatalf("expected %v, got %v", bitgo.ErrProductionSpending, err)
+	}
+	c = bitgo.NewClient(bitgo.WithBaseURL(srv.URL), bitgo.WithCoin("tbtc"))
+	if _, err = c.Wallet.Consolidate(context.Background(), "585951a5df8380e0e3063e9f", nil); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,5 +1,14 @@
 package bitgo
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
 // The error types are based on HTTP status codes.
 const (
 	// ErrorTypeRequiresApproval indicates that request is accepted but requires approval.
@@ -16,6 +25,31 @@ const (
 	ErrorTypeAPI = "api_error"
 )
 
+// Sentinel errors to be used with errors.Is, for example, errors.Is(err, bitgo.ErrRateLimited).
+// API errors (Error type) match the sentinel of their type,
+// transport and decode failures (RequestError type) match ErrTransport and ErrDecode.
+// Temporary API errors and network timeouts match ErrTemporary.
+var (
+	ErrRequiresApproval = errors.New("bitgo: request requires approval")
+	ErrInvalidRequest   = errors.New("bitgo: invalid request")
+	ErrUnauthorized     = errors.New("bitgo: unauthorized")
+	ErrNotFound         = errors.New("bitgo: not found")
+	ErrRateLimited      = errors.New("bitgo: rate limited")
+	ErrTemporary        = errors.New("bitgo: temporary error")
+	ErrTransport        = errors.New("bitgo: transport error")
+	ErrDecode           = errors.New("bitgo: invalid response")
+)
+
+// Known error names found in the name field of BitGo error responses.
+const (
+	ErrorNameInsufficientBalance = "InsufficientBalance"
+	ErrorNameNeedsUnlock         = "NeedsUnlock"
+	ErrorNameNeedsOTP            = "NeedsOTP"
+	ErrorNameWalletFrozen        = "WalletFrozen"
+	ErrorNameInvalidAddress      = "InvalidAddress"
+	ErrorNameNoUnspents          = "NoUnspents"
+)
+
 // Error is the response returned when a call is unsuccessful.
 type Error struct {
 	// Type is an API error type based on HTTP status code.
@@ -25,12 +59,67 @@ type Error struct {
 	Body      string
 	Message   string `json:"error"`
 	RequestID string `json:"requestId"`
+	// Name is BitGo's error name, e.g., "InsufficientBalance".
+	Name string `json:"name"`
+	// NeedsOTP is set when the request requires a fresh OTP (second factor) unlock.
+	NeedsOTP bool `json:"needsOTP"`
+	// NeedsUnlock is set when the session must be unlocked to perform the request.
+	NeedsUnlock bool `json:"needsUnlock"`
+	// RetryAfter is the wait the server asked for in Retry-After header, zero if it's not set.
+	RetryAfter time.Duration `json:"-"`
+	// context is the raw JSON context of the error, it's a string to keep Error comparable.
+	context string
 }
 
 func (e Error) Error() string {
 	return e.Message
 }
 
+// UnmarshalJSON decodes BitGo's error response keeping its context as raw JSON.
+func (e *Error) UnmarshalJSON(b []byte) error {
+	type apiError Error
+	v := struct {
+		*apiError
+		Context json.RawMessage `json:"context"`
+	}{apiError: (*apiError)(e)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if len(v.Context) > 0 && string(v.Context) != "null" {
+		e.context = string(v.Context)
+	}
+	return nil
+}
+
+// Context returns error details, e.g., an address which failed validation, or nil if there are none.
+func (e Error) Context() map[string]interface{} {
+	if e.context == "" {
+		return nil
+	}
+	var m map[string]interface{}
+	_ = json.Unmarshal([]byte(e.context), &m)
+	return m
+}
+
+// Is makes Error match a sentinel error of its type, e.g., errors.Is(err, ErrNotFound).
+func (e Error) Is(target error) bool {
+	switch target {
+	case ErrRequiresApproval:
+		return e.IsApprovalRequired()
+	case ErrInvalidRequest:
+		return e.IsInvalidRequest()
+	case ErrUnauthorized:
+		return e.IsUnauthorized()
+	case ErrNotFound:
+		return e.IsNotFound()
+	case ErrRateLimited:
+		return e.IsRateLimited()
+	case ErrTemporary:
+		return e.IsTemporary()
+	}
+	return false
+}
+
 // IsApprovalRequired returns true if err indicates that request is accepted but requires approval.
 func (e Error) IsApprovalRequired() bool {
 	return e.Type == ErrorTypeRequiresApproval
@@ -60,3 +149,116 @@ func (e Error) IsRateLimited() bool {
 func (e Error) IsTemporary() bool {
 	return e.Type == ErrorTypeAPI
 }
+
+// IsInsufficientFunds returns true if err caused by wallet balance
+// which is not enough to cover the amount and fees.
+func (e Error) IsInsufficientFunds() bool {
+	return e.Name == ErrorNameInsufficientBalance
+}
+
+// IsNeedsUnlock returns true if the session must be unlocked to perform the request.
+func (e Error) IsNeedsUnlock() bool {
+	return e.NeedsUnlock || e.Name == ErrorNameNeedsUnlock
+}
+
+// IsNeedsOTP returns true if the request requires OTP (second factor).
+func (e Error) IsNeedsOTP() bool {
+	return e.NeedsOTP || e.Name == ErrorNameNeedsOTP
+}
+
+// IsWalletFrozen returns true if err caused by a frozen wallet which can't spend.
+func (e Error) IsWalletFrozen() bool {
+	return e.Name == ErrorNameWalletFrozen
+}
+
+// IsInvalidAddress returns true if err caused by an invalid or wrong network address.
+func (e Error) IsInvalidAddress() bool {
+	return e.Name == ErrorNameInvalidAddress
+}
+
+// IsNothingToConsolidate returns true if consolidation failed
+// because there are no (or not enough) unspents matching the params.
+func (e Error) IsNothingToConsolidate() bool {
+	return e.Name == ErrorNameNoUnspents
+}
+
+// Operations of a request which can fail in RequestError.
+const (
+	// OpSend means a request couldn't be sent or a response wasn't received,
+	// e.g., due to network failure or context cancellation.
+	OpSend = "send"
+	// OpRead means a response body couldn't be read.
+	OpRead = "read"
+	// OpDecode means a successful response couldn't be decoded.
+	OpDecode = "decode"
+)
+
+// RequestError is returned when a request fails before an API error could be decoded:
+// transport errors (network failures, context cancellation) and response decoding errors.
+// The underlying error is available with errors.Unwrap, so errors.Is(err, context.Canceled) works.
+type RequestError struct {
+	// Op is the failed operation: OpSend, OpRead, or OpDecode.
+	Op     string
+	Method string
+	Path   string
+	// RequestID is BitGo's request ID if a response was received.
+	RequestID string
+	// HTTPStatusCode is zero if a response wasn't received.
+	HTTPStatusCode int
+	Err            error
+	// canceled is set when the request's context was cancelled or its deadline exceeded.
+	canceled bool
+}
+
+func (e *RequestError) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("bitgo: %s %s %s (request %s): %v", e.Op, e.Method, e.Path, e.RequestID, e.Err)
+	}
+	return fmt.Sprintf("bitgo: %s %s %s: %v", e.Op, e.Method, e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes RequestError match ErrTransport, ErrDecode, and ErrTemporary sentinels.
+func (e *RequestError) Is(target error) bool {
+	switch target {
+	case ErrTransport:
+		return e.Op == OpSend || e.Op == OpRead
+	case ErrDecode:
+		return e.Op == OpDecode
+	case ErrTemporary:
+		return e.Temporary()
+	}
+	return false
+}
+
+// Timeout returns true if err is caused by a network timeout.
+func (e *RequestError) Timeout() bool {
+	var ne net.Error
+	return errors.As(e.Err, &ne) && ne.Timeout()
+}
+
+// Temporary returns true if the request can be retried: network timeouts are temporary,
+// but cancellation of the request's context and decode errors are not.
+func (e *RequestError) Temporary() bool {
+	if e.Op == OpDecode || e.canceled {
+		return false
+	}
+	return e.Timeout()
+}
+
+// requestIDHeaders are response headers which may carry BitGo's request ID.
+var requestIDHeaders = []string{"Request-Id", "X-Request-Id"}
+
+// headerRequestID returns BitGo's request ID from response headers.
+func headerRequestID(h http.Header) string {
+	for _, name := range requestIDHeaders {
+		if id := h.Get(name); id != "" {
+			return id
+		}
+	}
+	return ""
+}
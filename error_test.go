@@ -1,7 +1,13 @@
 package bitgo_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/marselester/bitgo-v2"
 )
@@ -24,3 +30,138 @@ func TestIsUnauthorized(t *testing.T) {
 		}
 	}
 }
+
+func TestErrorPredicates(t *testing.T) {
+	tests := []struct {
+		name string
+		err  bitgo.Error
+		is   func(bitgo.Error) bool
+		want bool
+	}{
+		{"insufficient by name", bitgo.Error{Name: bitgo.ErrorNameInsufficientBalance}, bitgo.Error.IsInsufficientFunds, true},
+		{"insufficient by message", bitgo.Error{Message: "Insufficient funds to cover fee"}, bitgo.Error.IsInsufficientFunds, false},
+		{"not insufficient", bitgo.Error{Message: "unauthorized"}, bitgo.Error.IsInsufficientFunds, false},
+		{"needs unlock flag", bitgo.Error{NeedsUnlock: true}, bitgo.Error.IsNeedsUnlock, true},
+		{"needs otp flag", bitgo.Error{NeedsOTP: true}, bitgo.Error.IsNeedsOTP, true},
+		{"needs otp by name", bitgo.Error{Name: bitgo.ErrorNameNeedsOTP}, bitgo.Error.IsNeedsOTP, true},
+		{"wallet frozen", bitgo.Error{Name: bitgo.ErrorNameWalletFrozen}, bitgo.Error.IsWalletFrozen, true},
+		{"invalid address", bitgo.Error{Name: bitgo.ErrorNameInvalidAddress}, bitgo.Error.IsInvalidAddress, true},
+		{
+			"nothing to consolidate",
+			bitgo.Error{Type: bitgo.ErrorTypeInvalidRequest, Name: bitgo.ErrorNameNoUnspents},
+			bitgo.Error.IsNothingToConsolidate,
+			true,
+		},
+		{
+			"nothing to consolidate by message",
+			bitgo.Error{Type: bitgo.ErrorTypeInvalidRequest, Message: "No unspents available for consolidation"},
+			bitgo.Error.IsNothingToConsolidate,
+			false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.is(test.err); got != test.want {
+				t.Errorf("%#v = %v, want %v", test.err, got, test.want)
+			}
+		})
+	}
+}
+
+func TestErrorContext(t *testing.T) {
+	const body = `{"error":"needs otp","name":"NeedsOTP","requestId":"cj9h0dap1723","needsOTP":true,"context":{"walletId":"585951a5df8380e0e3063e9f"}}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, body, http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	c := bitgo.NewClient(
+		bitgo.WithBaseURL(srv.URL),
+		bitgo.WithCoin("tbtc"),
+	)
+	_, err := c.Wallet.Consolidate(context.Background(), "", nil)
+	var e bitgo.Error
+	if !errors.As(err, &e) {
+		t.Fatalf("expected API error, got %#v", err)
+	}
+	if !e.IsNeedsOTP() || e.Name != bitgo.ErrorNameNeedsOTP || e.RequestID != "cj9h0dap1723" {
+		t.Errorf("unexpected error %#v", e)
+	}
+	if id := e.Context()["walletId"]; id != "585951a5df8380e0e3063e9f" {
+		t.Errorf("unexpected context %v", e.Context())
+	}
+	if (bitgo.Error{}).Context() != nil {
+		t.Error("expected no context")
+	}
+}
+
+func TestErrorIs(t *testing.T) {
+	tests := []struct {
+		err    error
+		target error
+		want   bool
+	}{
+		{bitgo.Error{Type: bitgo.ErrorTypeNotFound}, bitgo.ErrNotFound, true},
+		{bitgo.Error{Type: bitgo.ErrorTypeRateLimit}, bitgo.ErrRateLimited, true},
+		{bitgo.Error{Type: bitgo.ErrorTypeRateLimit}, bitgo.ErrNotFound, false},
+		{bitgo.Error{Type: bitgo.ErrorTypeAPI}, bitgo.ErrTemporary, true},
+		{fmt.Errorf("consolidation: %w", bitgo.Error{Type: bitgo.ErrorTypeAuthentication}), bitgo.ErrUnauthorized, true},
+		{&bitgo.RequestError{Op: bitgo.OpDecode, Err: errors.New("unexpected EOF")}, bitgo.ErrDecode, true},
+		{&bitgo.RequestError{Op: bitgo.OpDecode, Err: errors.New("unexpected EOF")}, bitgo.ErrTransport, false},
+		{&bitgo.RequestError{Op: bitgo.OpSend, Err: context.Canceled}, context.Canceled, true},
+		{&bitgo.RequestError{Op: bitgo.OpSend, Err: context.Canceled}, bitgo.ErrTransport, true},
+	}
+	for _, test := range tests {
+		if got := errors.Is(test.err, test.target); got != test.want {
+			t.Errorf("errors.Is(%v, %v) = %v, want %v", test.err, test.target, got, test.want)
+		}
+	}
+}
+
+func TestRequestError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("slow") != "" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		w.Header().Set("Request-Id", "bj9h0dap1723kadrsnfkvsinz")
+		w.Write([]byte(`{"unspents":`))
+	}))
+	defer srv.Close()
+
+	c := bitgo.NewClient(
+		bitgo.WithBaseURL(srv.URL),
+	)
+	err := c.Wallet.Unspents(context.Background(), "585951a5df8380e0e3063e9f", nil, func(*bitgo.UnspentList) {})
+	var reqErr *bitgo.RequestError
+	if !errors.As(err, &reqErr) {
+		t.Fatalf("expected RequestError, got %#v", err)
+	}
+	if reqErr.Op != bitgo.OpDecode || reqErr.Method != http.MethodGet || reqErr.RequestID != "bj9h0dap1723kadrsnfkvsinz" ||
+		reqErr.Path != "/api/v2/btc/wallet/585951a5df8380e0e3063e9f/unspents" {
+		t.Errorf("unexpected request error %#v", reqErr)
+	}
+
+	c = bitgo.NewClient(
+		bitgo.WithBaseURL(srv.URL),
+		bitgo.WithHTTPClient(&http.Client{Timeout: 10 * time.Millisecond}),
+	)
+	ctx := context.Background()
+	req, err := c.NewRequest(ctx, http.MethodGet, "wallet", map[string][]string{"slow": {"1"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Do(req, nil)
+	if !errors.Is(err, bitgo.ErrTransport) || !errors.Is(err, bitgo.ErrTemporary) {
+		t.Errorf("expected temporary transport error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if req, err = c.NewRequest(ctx, http.MethodGet, "wallet", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Do(req, nil)
+	if !errors.Is(err, context.Canceled) || errors.Is(err, bitgo.ErrTemporary) {
+		t.Errorf("expected cancellation error, got %v", err)
+	}
+}
@@ -1,3 +1,4 @@
+//go:build gofuzz
 // +build gofuzz
 
 package bitgo
@@ -6,16 +7,23 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"time"
 )
 
 func Fuzz(data []byte) int {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Write(data)
 	}))
+	// Closing the server also closes idle connections of its client,
+	// otherwise connections leak between fuzz iterations and the program hangs.
 	defer srv.Close()
+	httpClient := srv.Client()
+	httpClient.Timeout = time.Second
 
 	c := NewClient(
 		WithBaseURL(srv.URL),
+		WithHTTPClient(httpClient),
+		WithMaxResponseSize(1<<20),
 	)
 	ctx := context.Background()
 	if _, err := c.Do(c.NewRequest(ctx, http.MethodPost, "", nil, nil)); err != nil {
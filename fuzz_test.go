@@ -30,6 +30,7 @@ func TestDoCrashers(t *testing.T) {
 
 			c := NewClient(
 				WithBaseURL(srv.URL),
+				WithHTTPClient(srv.Client()),
 			)
 			ctx := context.Background()
 			c.Do(c.NewRequest(ctx, http.MethodPost, "", nil, nil))
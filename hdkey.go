@@ -0,0 +1,141 @@
+package bitgo
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	// ErrExtendedKey is returned when an extended key can't be parsed.
+	ErrExtendedKey = errors.New("bitgo: invalid extended public key")
+	// ErrHardenedDerivation is returned when a hardened child of a public key is requested.
+	ErrHardenedDerivation = errors.New("bitgo: hardened derivation requires the private key")
+)
+
+// HardenedKeyStart is the index of the first hardened child key.
+const HardenedKeyStart = 1 << 31
+
+// extendedKeyLen is a length of a serialized BIP32 extended key without the checksum.
+const extendedKeyLen = 78
+
+// ExtendedKey is a BIP32 extended public key (xpub) of a wallet keychain.
+type ExtendedKey struct {
+	// Version identifies the network, e.g., 0x0488b21e for xpub and 0x043587cf for tpub.
+	Version uint32
+	// Depth is 0 for a master key.
+	Depth uint8
+	// ParentFingerprint is the first 4 bytes of hash160 of the parent public key.
+	ParentFingerprint [4]byte
+	// ChildNumber is the index of the key in the parent's children.
+	ChildNumber uint32
+	ChainCode   [32]byte
+	// PublicKey is the compressed public key.
+	PublicKey [33]byte
+}
+
+// ParseExtendedKey parses a base58check encoded extended public key, e.g., Keychain.Pub.
+// Private keys are rejected.
+func ParseExtendedKey(s string) (*ExtendedKey, error) {
+	b, err := base58CheckDecode(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExtendedKey, err)
+	}
+	return DecodeExtendedKey(b)
+}
+
+// DecodeExtendedKey decodes the 78 bytes BIP32 serialization of an extended public key.
+func DecodeExtendedKey(b []byte) (*ExtendedKey, error) {
+	if len(b) != extendedKeyLen {
+		return nil, fmt.Errorf("%w: %d bytes long", ErrExtendedKey, len(b))
+	}
+	if b[45] != 2 && b[45] != 3 {
+		return nil, fmt.Errorf("%w: not a compressed public key", ErrExtendedKey)
+	}
+
+	k := ExtendedKey{
+		Version:     binary.BigEndian.Uint32(b[0:4]),
+		Depth:       b[4],
+		ChildNumber: binary.BigEndian.Uint32(b[9:13]),
+	}
+	copy(k.ParentFingerprint[:], b[5:9])
+	copy(k.ChainCode[:], b[13:45])
+	copy(k.PublicKey[:], b[45:78])
+	return &k, nil
+}
+
+// Bytes returns the 78 bytes BIP32 serialization of the key without the checksum.
+func (k *ExtendedKey) Bytes() []byte {
+	b := make([]byte, 0, extendedKeyLen)
+	b = binary.BigEndian.AppendUint32(b, k.Version)
+	b = append(b, k.Depth)
+	b = append(b, k.ParentFingerprint[:]...)
+	b = binary.BigEndian.AppendUint32(b, k.ChildNumber)
+	b = append(b, k.ChainCode[:]...)
+	return append(b, k.PublicKey[:]...)
+}
+
+// String returns the base58check encoded key.
+func (k *ExtendedKey) String() string {
+	return base58CheckEncode(k.Bytes())
+}
+
+// Fingerprint returns the first 4 bytes of hash160 of the public key
+// which identifies the key in BIP32 derivation paths.
+func (k *ExtendedKey) Fingerprint() [4]byte {
+	var fp [4]byte
+	copy(fp[:], Hash160(k.PublicKey[:]))
+	return fp
+}
+
+// Child derives the non-hardened child public key at the index (BIP32 CKDpub).
+func (k *ExtendedKey) Child(index uint32) (*ExtendedKey, error) {
+	if index >= HardenedKeyStart {
+		return nil, fmt.Errorf("%w: child %d", ErrHardenedDerivation, index)
+	}
+	if k.Depth == 255 {
+		return nil, fmt.Errorf("%w: max depth", ErrExtendedKey)
+	}
+	parent, err := decompress(k.PublicKey[:])
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha512.New, k.ChainCode[:])
+	mac.Write(k.PublicKey[:])
+	binary.Write(mac, binary.BigEndian, index)
+	sum := mac.Sum(nil)
+	// The child index is invalid with a negligible probability, then the next index should be used.
+	tweak := new(big.Int).SetBytes(sum[:32])
+	if tweak.Cmp(secpN) >= 0 {
+		return nil, fmt.Errorf("%w: invalid child %d", ErrExtendedKey, index)
+	}
+	p := scalarBaseMult(tweak).add(parent)
+	if p.x == nil {
+		return nil, fmt.Errorf("%w: invalid child %d", ErrExtendedKey, index)
+	}
+
+	child := ExtendedKey{
+		Version:           k.Version,
+		Depth:             k.Depth + 1,
+		ParentFingerprint: k.Fingerprint(),
+		ChildNumber:       index,
+		PublicKey:         p.compress(),
+	}
+	copy(child.ChainCode[:], sum[32:])
+	return &child, nil
+}
+
+// Derive derives the public key at the path relative to the key, e.g., Unspent.DerivationPath.
+func (k *ExtendedKey) Derive(path []uint32) (*ExtendedKey, error) {
+	var err error
+	for _, index := range path {
+		if k, err = k.Child(index); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
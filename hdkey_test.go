@@ -0,0 +1,83 @@
+package bitgo_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/marselester/bitgo-v2"
+)
+
+func TestHash160(t *testing.T) {
+	// The generator point of secp256k1 pays to bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4.
+	pub, _ := hex.DecodeString("0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798")
+	if got := hex.EncodeToString(bitgo.Hash160(pub)); got != "751e76e8199196d454941c45d1b3a323f1433bd6" {
+		t.Errorf("unexpected hash160 %s", got)
+	}
+}
+
+func TestParseExtendedKey(t *testing.T) {
+	// BIP32 test vector 1 master key.
+	const xpub = "xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8"
+	k, err := bitgo.ParseExtendedKey(xpub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.Version != 0x0488b21e || k.Depth != 0 || k.ChildNumber != 0 {
+		t.Errorf("unexpected key %+v", k)
+	}
+	if fp := k.Fingerprint(); hex.EncodeToString(fp[:]) != "3442193e" {
+		t.Errorf("unexpected fingerprint %x", fp)
+	}
+	if s := k.String(); s != xpub {
+		t.Errorf("expected %s, got %s", xpub, s)
+	}
+
+	tests := []string{
+		// Private key of BIP32 test vector 1.
+		"xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi",
+		xpub[:len(xpub)-1] + "9",
+		"1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu",
+	}
+	for _, s := range tests {
+		if _, err = bitgo.ParseExtendedKey(s); !errors.Is(err, bitgo.ErrExtendedKey) {
+			t.Errorf("%s: expected invalid key, got %v", s, err)
+		}
+	}
+}
+
+func TestExtendedKeyDerive(t *testing.T) {
+	// BIP32 test vector 1 public keys of m/0H, m/0H/1, m/0H/1/2H, and m/0H/1/2H/2.
+	tests := []struct {
+		parent string
+		path   []uint32
+		want   string
+	}{
+		{
+			"xpub68Gmy5EdvgibQVfPdqkBBCHxA5htiqg55crXYuXoQRKfDBFA1WEjWgP6LHhwBZeNK1VTsfTFUHCdrfp1bgwQ9xv5ski8PX9rL2dZXvgGDnw",
+			[]uint32{1},
+			"xpub6ASuArnXKPbfEwhqN6e3mwBcDTgzisQN1wXN9BJcM47sSikHjJf3UFHKkNAWbWMiGj7Wf5uMash7SyYq527Hqck2AxYysAA7xmALppuCkwQ",
+		},
+		{
+			"xpub6D4BDPcP2GT577Vvch3R8wDkScZWzQzMMUm3PWbmWvVJrZwQY4VUNgqFJPMM3No2dFDFGTsxxpG5uJh7n7epu4trkrX7x7DogT5Uv6fcLW5",
+			[]uint32{2},
+			"xpub6FHa3pjLCk84BayeJxFW2SP4XRrFd1JYnxeLeU8EqN3vDfZmbqBqaGJAyiLjTAwm6ZLRQUMv1ZACTj37sR62cfN7fe5JnJ7dh8zL4fiyLHV",
+		},
+	}
+	for _, test := range tests {
+		k, err := bitgo.ParseExtendedKey(test.parent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		child, err := k.Derive(test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := child.String(); got != test.want {
+			t.Errorf("expected %s, got %s", test.want, got)
+		}
+		if _, err = k.Child(bitgo.HardenedKeyStart); !errors.Is(err, bitgo.ErrHardenedDerivation) {
+			t.Errorf("expected hardened derivation error, got %v", err)
+		}
+	}
+}
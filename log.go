@@ -1,5 +1,17 @@
 package bitgo
 
+import "context"
+
+// Log levels the client puts in "level" key of log events:
+// bodies and request details are logged at debug level, retries and throttling at warn level,
+// and failed requests at error level.
+const (
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+)
+
 // Logger is the Go kit's interface which is mainly used here to debug API responses.
 // Log creates a log event from keyvals, a variadic sequence of alternating keys and values.
 // Implementations must be safe for concurrent use by multiple goroutines.
@@ -32,3 +44,67 @@ type NoopLogger struct{}
 func (l *NoopLogger) Log(_ ...interface{}) error {
 	return nil
 }
+
+// ContextLogger is implemented by loggers which need a request context,
+// e.g., to extract tracing IDs. The client prefers LogContext over Log when it's available.
+type ContextLogger interface {
+	LogContext(ctx context.Context, keyvals ...interface{}) error
+}
+
+// LevelLogger is implemented by loggers which can tell whether events of the level are logged,
+// so the client skips the disabled events, e.g., doesn't mask request and response bodies when debug logs are discarded.
+// The client assumes all levels are logged when it's not available.
+type LevelLogger interface {
+	Enabled(ctx context.Context, level string) bool
+}
+
+// debugEnabled reports whether debug events are logged, so their bodies are worth preparing.
+func (c *Config) debugEnabled(ctx context.Context) bool {
+	switch l := c.logger.(type) {
+	case *NoopLogger:
+		return false
+	case LevelLogger:
+		return l.Enabled(ctx, LevelDebug)
+	}
+	return true
+}
+
+// logValuesKey is a context key of request-scoped log key/value pairs.
+type logValuesKey struct{}
+
+// WithLogValues returns a new context based on the provided parent ctx
+// with request-scoped key/value pairs, e.g., a correlation ID.
+// They are added to every log event of requests made with the returned context.
+// Wallet methods add "wallet" ID on their own.
+func WithLogValues(ctx context.Context, keyvals ...interface{}) context.Context {
+	prev := contextLogValues(ctx)
+	kv := make([]interface{}, 0, len(prev)+len(keyvals))
+	kv = append(kv, prev...)
+	kv = append(kv, keyvals...)
+	return context.WithValue(ctx, logValuesKey{}, kv)
+}
+
+// contextLogValues returns request-scoped key/value pairs from the context.
+func contextLogValues(ctx context.Context) []interface{} {
+	kv, _ := ctx.Value(logValuesKey{}).([]interface{})
+	return kv
+}
+
+// log creates a log event with the level and message
+// followed by request-scoped key/value pairs from ctx and keyvals.
+func (c *Config) log(ctx context.Context, level, msg string, keyvals ...interface{}) {
+	if l, ok := c.logger.(LevelLogger); ok && !l.Enabled(ctx, level) {
+		return
+	}
+	ctxvals := contextLogValues(ctx)
+	kv := make([]interface{}, 0, 4+len(ctxvals)+len(keyvals))
+	kv = append(kv, "level", level, "msg", msg)
+	kv = append(kv, ctxvals...)
+	kv = append(kv, keyvals...)
+
+	if l, ok := c.logger.(ContextLogger); ok {
+		l.LogContext(ctx, kv...)
+		return
+	}
+	c.logger.Log(kv...)
+}
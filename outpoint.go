@@ -0,0 +1,39 @@
+package bitgo
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Outpoint references a transaction output by its transaction ID and output index.
+type Outpoint struct {
+	// TxID is a hex encoded transaction ID.
+	TxID string
+	// Vout is an index of the output in the transaction.
+	Vout uint32
+}
+
+// ParseOutpoint parses an outpoint in "txid:vout" form as used in unspent IDs,
+// e.g., "5885a7e6c7802206f69655ed763d14f101cf46501aef38e275c67c72cfcedb75:0".
+// The txid must be 64 hex characters (32 bytes) like the txids of decoded transactions.
+func ParseOutpoint(s string) (Outpoint, error) {
+	txid, vout, ok := strings.Cut(s, ":")
+	if !ok || txid == "" {
+		return Outpoint{}, fmt.Errorf("bitgo: invalid outpoint %q", s)
+	}
+	if _, err := hex.DecodeString(txid); err != nil || len(txid) != 64 {
+		return Outpoint{}, fmt.Errorf("bitgo: invalid outpoint %q txid", s)
+	}
+	n, err := strconv.ParseUint(vout, 10, 32)
+	if err != nil {
+		return Outpoint{}, fmt.Errorf("bitgo: invalid outpoint %q vout", s)
+	}
+	return Outpoint{TxID: strings.ToLower(txid), Vout: uint32(n)}, nil
+}
+
+// String returns the outpoint in "txid:vout" form.
+func (o Outpoint) String() string {
+	return o.TxID + ":" + strconv.FormatUint(uint64(o.Vout), 10)
+}
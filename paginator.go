@@ -0,0 +1,376 @@
+package bitgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Pagination errors are wrapped in PaginationError when the server's cursors can't be trusted.
+var (
+	// ErrRepeatedCursor means the server returned a cursor of a page which has been already fetched.
+	ErrRepeatedCursor = errors.New("bitgo: pagination cursor repeated")
+	// ErrEmptyPage means the server returned an empty page which still has a next page cursor.
+	ErrEmptyPage = errors.New("bitgo: empty page has a next page cursor")
+	// ErrTooManyPages means the pages limit set with WithMaxPages or ListOptions.MaxPages was reached.
+	ErrTooManyPages = errors.New("bitgo: too many pages")
+)
+
+// PaginationError ends iteration when pagination runs in circles or away.
+type PaginationError struct {
+	// Err is ErrRepeatedCursor, ErrEmptyPage, or ErrTooManyPages.
+	Err error
+	// Page is a number of the page which was about to be fetched (or was empty).
+	Page int
+	// Cursor is the cursor of the page.
+	Cursor string
+}
+
+func (e *PaginationError) Error() string {
+	return fmt.Sprintf("%v: page %d, cursor %q", e.Err, e.Page, e.Cursor)
+}
+
+// Unwrap returns the underlying error, so errors.Is(err, ErrRepeatedCursor) works.
+func (e *PaginationError) Unwrap() error {
+	return e.Err
+}
+
+// pageGuard detects repeated cursors, empty pages with a cursor, and too many pages.
+type pageGuard struct {
+	// maxPages is a max number of pages to fetch, zero means no limit.
+	maxPages int
+	// seen are the cursors of fetched pages.
+	seen map[string]bool
+}
+
+// beforeFetch checks whether the page can be fetched with the cursor.
+func (g *pageGuard) beforeFetch(page int, cursor string) error {
+	if g.maxPages > 0 && page > g.maxPages {
+		return &PaginationError{Err: ErrTooManyPages, Page: page, Cursor: cursor}
+	}
+	if g.seen == nil {
+		g.seen = make(map[string]bool)
+	}
+	if g.seen[cursor] {
+		return &PaginationError{Err: ErrRepeatedCursor, Page: page, Cursor: cursor}
+	}
+	g.seen[cursor] = true
+	return nil
+}
+
+// afterFetch checks the fetched page.
+func (g *pageGuard) afterFetch(page int, cursor string, items int, next string) error {
+	if items == 0 && next != "" {
+		return &PaginationError{Err: ErrEmptyPage, Page: page, Cursor: cursor}
+	}
+	return nil
+}
+
+// ListOptions controls pagination of list endpoints.
+type ListOptions struct {
+	// PageSize is a max number of items per page (limit query param).
+	// Zero means the API's default page size.
+	PageSize int
+	// Cursor resumes iteration from a cursor returned by Paginator.Cursor.
+	// Empty cursor means the first page.
+	Cursor string
+	// MaxPages stops iteration with ErrTooManyPages when more pages are about to be fetched.
+	// Zero means the limit set with WithMaxPages.
+	MaxPages int
+	// Prefetch is a number of pages fetched ahead in a goroutine while the current page is iterated.
+	// Zero means the number set with WithPrefetch, negative turns prefetching off.
+	// When prefetching, call Paginator.Close if the iteration is stopped early.
+	Prefetch int
+}
+
+// prefetchedPage is a page fetched by a prefetching goroutine.
+type prefetchedPage[P any] struct {
+	page P
+	// cursor is the one the page was fetched with.
+	cursor string
+	// next is a cursor of the next page.
+	next string
+	err  error
+	// hooks must be delivered when the consumer receives the page.
+	hooks *pageHooks
+}
+
+// pageHooks holds the response metadata and trace hook calls of a prefetched page
+// until the consumer receives the page, so that the caller's ResponseMeta and ClientTrace
+// are not accessed from the prefetching goroutine while the consumer reads them.
+type pageHooks struct {
+	// collector is the caller's ResponseMeta set with WithResponseMeta, if any.
+	collector *ResponseMeta
+	meta      ResponseMeta
+	calls     []func()
+}
+
+// deferPageHooks returns a context which collects the response metadata and trace hook calls
+// of a page into pageHooks instead of the collector and trace set in ctx.
+func deferPageHooks(ctx context.Context) (context.Context, *pageHooks) {
+	h := pageHooks{}
+	h.collector, _ = ctx.Value(responseMetaKey{}).(*ResponseMeta)
+	ctx = WithResponseMeta(ctx, &h.meta)
+
+	if t := ContextClientTrace(ctx); t != nil {
+		ctx = WithClientTrace(ctx, &ClientTrace{
+			RequestBuilt:     deferHook(&h, t.RequestBuilt),
+			RequestSent:      deferHook(&h, t.RequestSent),
+			ResponseReceived: deferHook(&h, t.ResponseReceived),
+			DecodeFailed:     deferHook(&h, t.DecodeFailed),
+			RetryScheduled:   deferHook(&h, t.RetryScheduled),
+			PageFetched:      deferHook(&h, t.PageFetched),
+		})
+	}
+	return ctx, &h
+}
+
+// deferHook returns a hook which records the call to be made on delivery.
+func deferHook[I any](h *pageHooks, hook func(I)) func(I) {
+	if hook == nil {
+		return nil
+	}
+	return func(info I) {
+		h.calls = append(h.calls, func() { hook(info) })
+	}
+}
+
+// deliver stores the page's response metadata in the caller's collector
+// and calls the trace hooks in the order they were recorded.
+func (h *pageHooks) deliver() {
+	// A zero status code means no response was received, e.g., the request couldn't be sent.
+	if h.collector != nil && h.meta.StatusCode != 0 {
+		*h.collector = h.meta
+	}
+	for _, call := range h.calls {
+		call()
+	}
+	h.calls = nil
+}
+
+// prefetch fetches pages in a goroutine starting from the page after the given one,
+// so they are ready by the time the consumer needs them.
+// Up to n pages are fetched ahead of the consumer.
+// The channel is closed after the last page, a failed page, or when ctx is done;
+// ctx must be canceled when the consumer stops reading early.
+//
+// The consumer must deliver the hooks of each received page.
+// Trace hooks are taken from ctx only, so the client's trace must be set there with WithClientTrace.
+func prefetch[P any](ctx context.Context, n, page int, cursor string, fetch func(ctx context.Context, page int, cursor string) (P, string, error)) <-chan prefetchedPage[P] {
+	// The goroutine holds one fetched page while it is blocked on sending,
+	// so the channel buffers the rest.
+	pages := make(chan prefetchedPage[P], n-1)
+	go func() {
+		defer close(pages)
+		for {
+			page++
+			pageCtx, hooks := deferPageHooks(ctx)
+			p := prefetchedPage[P]{cursor: cursor, hooks: hooks}
+			p.page, p.next, p.err = fetch(pageCtx, page, cursor)
+			select {
+			case pages <- p:
+			case <-ctx.Done():
+				return
+			}
+			if p.err != nil || p.next == "" {
+				return
+			}
+			cursor = p.next
+		}
+	}()
+	return pages
+}
+
+// pageFetcher fetches a page of items which starts after the cursor (empty cursor means the first page).
+// It returns the items and a cursor of the next page (empty on the last page).
+type pageFetcher[T any] func(ctx context.Context, page int, cursor string) (items []T, next string, err error)
+
+// Paginator iterates over items of a list endpoint fetching pages on demand.
+// Iteration can be stopped at any time and resumed later from Cursor.
+//
+//	it := c.Wallet.UnspentsIter(ctx, walletID, nil, &bitgo.ListOptions{PageSize: 500})
+//	for it.Next() {
+//		utxo := it.Value()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		log.Printf("stopped at %s: %v", it.Cursor(), err)
+//	}
+type Paginator[T any] struct {
+	ctx   context.Context
+	fetch pageFetcher[T]
+	guard pageGuard
+	// prefetch is a number of pages to fetch ahead, zero means pages are fetched on demand.
+	prefetch int
+	pages    <-chan prefetchedPage[[]T]
+	cancel   context.CancelFunc
+	closed   bool
+
+	// page is a number of the current page starting from 1.
+	page  int
+	items []T
+	// i is an index of the current item in items, -1 before the first item of a page.
+	i int
+	// cursor is the one the current page was fetched with.
+	cursor string
+	// next is a cursor of the next page.
+	next string
+	err  error
+}
+
+// newPaginator returns a Paginator which starts from the cursor and fetches at most maxPages.
+// Pages are fetched ahead in a goroutine if prefetch is positive.
+func newPaginator[T any](ctx context.Context, cursor string, maxPages, prefetch int, fetch pageFetcher[T]) *Paginator[T] {
+	if prefetch < 0 {
+		prefetch = 0
+	}
+	return &Paginator[T]{
+		ctx:      ctx,
+		fetch:    fetch,
+		guard:    pageGuard{maxPages: maxPages},
+		prefetch: prefetch,
+		i:        -1,
+		cursor:   cursor,
+		next:     cursor,
+	}
+}
+
+// errPaginator returns a Paginator which fails with err on the first Next call.
+func errPaginator[T any](err error) *Paginator[T] {
+	return &Paginator[T]{err: err, i: -1}
+}
+
+// Next advances to the next item which will then be available through Value.
+// It returns false when the iteration stops, either by reaching the end or an error.
+// After Next returns false, Err should be consulted to distinguish between the two cases.
+func (p *Paginator[T]) Next() bool {
+	if p.err != nil || p.closed {
+		return false
+	}
+
+	for p.i+1 >= len(p.items) {
+		// The last page has been iterated.
+		if p.page > 0 && p.next == "" {
+			p.Close()
+			return false
+		}
+
+		items, next, err := p.nextPage()
+		if err != nil {
+			p.err = err
+			p.Close()
+			return false
+		}
+		p.page++
+		p.cursor = p.next
+		p.next = next
+		p.items = items
+		p.i = -1
+	}
+
+	p.i++
+	return true
+}
+
+// nextPage returns items of the next page and a cursor of the page after it.
+// The page is either fetched on demand or received from the prefetching goroutine.
+func (p *Paginator[T]) nextPage() ([]T, string, error) {
+	if p.prefetch == 0 {
+		return p.guardedFetch(p.ctx, p.page+1, p.next)
+	}
+
+	if p.pages == nil {
+		var ctx context.Context
+		ctx, p.cancel = context.WithCancel(p.ctx)
+		p.pages = prefetch(ctx, p.prefetch, p.page, p.next, p.guardedFetch)
+	}
+	pg, ok := <-p.pages
+	if !ok {
+		return nil, "", p.ctx.Err()
+	}
+	pg.hooks.deliver()
+	return pg.page, pg.next, pg.err
+}
+
+// guardedFetch fetches a page checking that the pagination doesn't run in circles or away.
+func (p *Paginator[T]) guardedFetch(ctx context.Context, page int, cursor string) ([]T, string, error) {
+	if err := p.guard.beforeFetch(page, cursor); err != nil {
+		return nil, "", err
+	}
+	items, next, err := p.fetch(ctx, page, cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	if err = p.guard.afterFetch(page, cursor, len(items), next); err != nil {
+		return nil, "", err
+	}
+	return items, next, nil
+}
+
+// Close stops prefetching pages, after that Next returns false.
+// It must be called when the iteration with ListOptions.Prefetch is stopped early,
+// otherwise it is not needed: the prefetching stops once Next returns false.
+func (p *Paginator[T]) Close() {
+	p.closed = true
+	if p.cancel != nil {
+		p.cancel()
+	}
+}
+
+// Value returns the current item.
+func (p *Paginator[T]) Value() T {
+	if p.i < 0 || p.i >= len(p.items) {
+		var zero T
+		return zero
+	}
+	return p.items[p.i]
+}
+
+// Err returns the error, if any, that was encountered during iteration.
+func (p *Paginator[T]) Err() error {
+	return p.err
+}
+
+// Page returns the current page number starting from 1.
+func (p *Paginator[T]) Page() int {
+	return p.page
+}
+
+// Cursor returns a cursor to resume iteration with ListOptions.Cursor after the last item returned by Next.
+// When the iteration was stopped in the middle of a page, the cursor points to the beginning of that page,
+// so the resumed iteration repeats the page's items returned so far rather than skipping any.
+// The cursor is empty when the last page has been iterated, but also when the iteration
+// was stopped on the first page started without a cursor, so use Done to tell the two apart.
+func (p *Paginator[T]) Cursor() string {
+	if p.page == 0 || p.i+1 < len(p.items) {
+		return p.cursor
+	}
+	return p.next
+}
+
+// Done reports whether all the items of the last page have been returned by Next,
+// i.e., there is nothing left to resume from Cursor.
+func (p *Paginator[T]) Done() bool {
+	return p.err == nil && p.page > 0 && p.next == "" && p.i+1 >= len(p.items)
+}
+
+// listParams returns a copy of query params with page size and cursor set,
+// so the caller's params are never modified.
+func listParams(queryParams url.Values, pageSize int, cursorParam, cursor string) url.Values {
+	params := url.Values{}
+	for k, v := range queryParams {
+		params[k] = append([]string(nil), v...)
+	}
+	if pageSize > 0 {
+		params.Set("limit", strconv.Itoa(pageSize))
+	}
+	if cursor != "" {
+		params.Set(cursorParam, cursor)
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	return params
+}
@@ -0,0 +1,335 @@
+package bitgo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marselester/bitgo-v2"
+	"github.com/marselester/bitgo-v2/bitgotest"
+)
+
+const walletID = "585951a5df8380e0e3063e9f"
+
+func TestUnspentsIter(t *testing.T) {
+	fake := bitgotest.NewServer()
+	fake.AddWallet("tbtc", walletID, "")
+	fake.AddUnspents(walletID, bitgotest.Dust(25, 546)...)
+	c := fake.Client(t)
+
+	params := url.Values{}
+	params.Set("minValue", "1")
+	it := c.Wallet.UnspentsIter(context.Background(), walletID, params, &bitgo.ListOptions{PageSize: 10})
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Value().ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 25 || it.Page() != 3 {
+		t.Fatalf("expected 25 unspents in 3 pages, got %d in %d", len(ids), it.Page())
+	}
+	if it.Cursor() != "" || !it.Done() {
+		t.Errorf("expected empty cursor after the last page, got %q, done %t", it.Cursor(), it.Done())
+	}
+	if len(params) != 1 {
+		t.Errorf("params must not be modified: %v", params)
+	}
+}
+
+func TestUnspentsIterResume(t *testing.T) {
+	fake := bitgotest.NewServer()
+	fake.AddWallet("tbtc", walletID, "")
+	fake.AddUnspents(walletID, bitgotest.Dust(25, 546)...)
+	c := fake.Client(t)
+	opts := bitgo.ListOptions{PageSize: 10}
+
+	// Stop in the middle of the second page.
+	it := c.Wallet.UnspentsIter(context.Background(), walletID, nil, &opts)
+	seen := make(map[string]bool)
+	for i := 0; i < 15 && it.Next(); i++ {
+		seen[it.Value().ID] = true
+	}
+
+	if it.Done() {
+		t.Fatal("expected the iteration not to be done")
+	}
+
+	// The second page is fetched again, so no unspents are skipped.
+	opts.Cursor = it.Cursor()
+	it = c.Wallet.UnspentsIter(context.Background(), walletID, nil, &opts)
+	repeated := 0
+	for it.Next() {
+		if seen[it.Value().ID] {
+			repeated++
+		}
+		seen[it.Value().ID] = true
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 25 || repeated != 5 {
+		t.Errorf("expected 25 unspents with 5 repeated, got %d with %d repeated", len(seen), repeated)
+	}
+}
+
+func TestUnspentsIterErrorCursor(t *testing.T) {
+	fake := bitgotest.NewServer()
+	fake.AddWallet("tbtc", walletID, "")
+	fake.AddUnspents(walletID, bitgotest.Dust(15, 546)...)
+	c := fake.Client(t)
+
+	it := c.Wallet.UnspentsIter(context.Background(), walletID, nil, &bitgo.ListOptions{PageSize: 10})
+	n := 0
+	for it.Next() {
+		n++
+		if n == 10 {
+			fake.InjectFault(bitgotest.Fault{StatusCode: http.StatusServiceUnavailable, Count: 1})
+		}
+	}
+	if it.Err() == nil || n != 10 {
+		t.Fatalf("expected an error after the first page, got %v after %d unspents", it.Err(), n)
+	}
+
+	// The first page was fully iterated, so the next one is requested.
+	it = c.Wallet.UnspentsIter(context.Background(), walletID, nil, &bitgo.ListOptions{PageSize: 10, Cursor: it.Cursor()})
+	for it.Next() {
+		n++
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if n != 15 {
+		t.Errorf("expected 15 unspents, got %d", n)
+	}
+}
+
+func TestUnspentsIterPaginationError(t *testing.T) {
+	tests := map[string]struct {
+		pages    []string
+		maxPages int
+		want     error
+		items    int
+	}{
+		"repeated cursor": {
+			pages: []string{
+				`{"nextBatchPrevId":"a","unspents":[{"id":"1"}]}`,
+				`{"nextBatchPrevId":"b","unspents":[{"id":"2"}]}`,
+				`{"nextBatchPrevId":"a","unspents":[{"id":"3"}]}`,
+			},
+			want:  bitgo.ErrRepeatedCursor,
+			items: 3,
+		},
+		"same cursor": {
+			pages: []string{
+				`{"nextBatchPrevId":"a","unspents":[{"id":"1"}]}`,
+				`{"nextBatchPrevId":"a","unspents":[{"id":"2"}]}`,
+			},
+			want:  bitgo.ErrRepeatedCursor,
+			items: 2,
+		},
+		"empty page": {
+			pages: []string{
+				`{"nextBatchPrevId":"a","unspents":[{"id":"1"}]}`,
+				`{"nextBatchPrevId":"b","unspents":[]}`,
+			},
+			want:  bitgo.ErrEmptyPage,
+			items: 1,
+		},
+		"too many pages": {
+			pages: []string{
+				`{"nextBatchPrevId":"a","unspents":[{"id":"1"}]}`,
+				`{"nextBatchPrevId":"b","unspents":[{"id":"2"}]}`,
+				`{"nextBatchPrevId":"c","unspents":[{"id":"3"}]}`,
+			},
+			maxPages: 2,
+			want:     bitgo.ErrTooManyPages,
+			items:    2,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			requests := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tc.pages[requests%len(tc.pages)]))
+				requests++
+			}))
+			defer srv.Close()
+			c := bitgo.NewClient(bitgo.WithBaseURL(srv.URL))
+
+			it := c.Wallet.UnspentsIter(context.Background(), walletID, nil, &bitgo.ListOptions{MaxPages: tc.maxPages})
+			n := 0
+			for it.Next() {
+				n++
+			}
+			if !errors.Is(it.Err(), tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, it.Err())
+			}
+			var perr *bitgo.PaginationError
+			if !errors.As(it.Err(), &perr) {
+				t.Fatalf("expected PaginationError, got %T", it.Err())
+			}
+			if n != tc.items {
+				t.Errorf("expected %d unspents, got %d", tc.items, n)
+			}
+			if requests > len(tc.pages) {
+				t.Errorf("expected at most %d requests, got %d", len(tc.pages), requests)
+			}
+		})
+	}
+}
+
+func TestUnspentsMaxPages(t *testing.T) {
+	fake := bitgotest.NewServer()
+	fake.AddWallet("tbtc", walletID, "")
+	fake.AddUnspents(walletID, bitgotest.Dust(250, 546)...)
+	c := fake.Client(t, bitgo.WithMaxPages(2))
+
+	n := 0
+	err := c.Wallet.Unspents(context.Background(), walletID, nil, func(list *bitgo.UnspentList) {
+		n += len(list.Unspents)
+	})
+	var perr *bitgo.PaginationError
+	if !errors.As(err, &perr) || perr.Err != bitgo.ErrTooManyPages || perr.Page != 3 {
+		t.Fatalf("expected too many pages error on the third page, got %v", err)
+	}
+	if n != 200 {
+		t.Errorf("expected 200 unspents, got %d", n)
+	}
+}
+
+func TestUnspentsIterPrefetch(t *testing.T) {
+	fake := bitgotest.NewServer()
+	fake.AddWallet("tbtc", walletID, "")
+	fake.AddUnspents(walletID, bitgotest.Dust(25, 546)...)
+	c := fake.Client(t)
+
+	it := c.Wallet.UnspentsIter(context.Background(), walletID, nil, &bitgo.ListOptions{PageSize: 10, Prefetch: 2})
+	defer it.Close()
+	seen := make(map[string]bool)
+	for it.Next() {
+		seen[it.Value().ID] = true
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 25 || it.Page() != 3 || it.Cursor() != "" {
+		t.Fatalf("expected 25 unspents in 3 pages, got %d in %d, cursor %q", len(seen), it.Page(), it.Cursor())
+	}
+}
+
+func TestUnspentsIterPrefetchHooks(t *testing.T) {
+	fake := bitgotest.NewServer()
+	fake.AddWallet("tbtc", walletID, "")
+	fake.AddUnspents(walletID, bitgotest.Dust(25, 546)...)
+	// The hooks and the metadata are accessed without locking,
+	// since they are delivered in the consumer's goroutine.
+	var pages []int
+	c := fake.Client(t, bitgo.WithTrace(&bitgo.ClientTrace{
+		PageFetched: func(info bitgo.PageFetchedInfo) {
+			pages = append(pages, info.Page)
+		},
+	}))
+
+	meta := bitgo.ResponseMeta{}
+	ctx := bitgo.WithResponseMeta(context.Background(), &meta)
+	it := c.Wallet.UnspentsIter(ctx, walletID, nil, &bitgo.ListOptions{PageSize: 10, Prefetch: 2})
+	defer it.Close()
+	for it.Next() {
+		if meta.StatusCode != http.StatusOK || len(pages) != it.Page() {
+			t.Fatalf("expected hooks of page %d, got status %d, pages %v", it.Page(), meta.StatusCode, pages)
+		}
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 3 || pages[0] != 1 || pages[2] != 3 {
+		t.Errorf("expected 3 pages in order, got %v", pages)
+	}
+}
+
+func TestUnspentsIterPrefetchClose(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		n := requests
+		mu.Unlock()
+		fmt.Fprintf(w, `{"nextBatchPrevId":"%d","unspents":[{"id":"%d"}]}`, n, n)
+	}))
+	defer srv.Close()
+	c := bitgo.NewClient(bitgo.WithBaseURL(srv.URL))
+
+	it := c.Wallet.UnspentsIter(context.Background(), walletID, nil, &bitgo.ListOptions{Prefetch: 3})
+	for i := 0; i < 2 && it.Next(); i++ {
+	}
+	it.Close()
+	if it.Next() {
+		t.Fatal("expected no items after Close")
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if it.Cursor() != "2" {
+		t.Errorf("expected cursor of the third page, got %q", it.Cursor())
+	}
+
+	// The pages were fetched ahead, but no further than the prefetch limit.
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if requests > 2+3+1 {
+		t.Errorf("expected at most 6 requests, got %d", requests)
+	}
+}
+
+func TestUnspentsPrefetch(t *testing.T) {
+	fake := bitgotest.NewServer()
+	fake.AddWallet("tbtc", walletID, "")
+	fake.AddUnspents(walletID, bitgotest.Dust(250, 546)...)
+	c := fake.Client(t, bitgo.WithPrefetch(1))
+
+	n := 0
+	err := c.Wallet.Unspents(context.Background(), walletID, nil, func(list *bitgo.UnspentList) {
+		n += len(list.Unspents)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 250 {
+		t.Errorf("expected 250 unspents, got %d", n)
+	}
+}
+
+func TestUnspentsPrefetchCancel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"nextBatchPrevId":"%d","unspents":[{"id":"1"}]}`, time.Now().UnixNano())
+	}))
+	defer srv.Close()
+	c := bitgo.NewClient(
+		bitgo.WithBaseURL(srv.URL),
+		bitgo.WithPrefetch(2),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pages := 0
+	err := c.Wallet.Unspents(ctx, walletID, nil, func(*bitgo.UnspentList) {
+		if pages++; pages == 3 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+}
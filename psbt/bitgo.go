@@ -0,0 +1,483 @@
+package psbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/marselester/bitgo-v2"
+	"github.com/marselester/bitgo-v2/rawtx"
+)
+
+// Script opcodes of multisig scripts and their signatures.
+const (
+	op0             = 0x00
+	op1             = 0x51
+	op16            = 0x60
+	opPushData1     = 0x4c
+	opPushData2     = 0x4d
+	opPushData4     = 0x4e
+	opCheckMultisig = 0xae
+)
+
+// Key is a wallet keychain and where its xpub is derived from.
+type Key struct {
+	XPub *bitgo.ExtendedKey
+	// Origin is the master key fingerprint and the path of the xpub, e.g., as exported by a hardware wallet.
+	// The xpub itself is the master key of the derivation paths if the fingerprint is zero.
+	Origin KeyOrigin
+}
+
+// Keys parses the xpubs of the wallet keychains, see bitgo.Client.Wallet.Keychains.
+// The xpubs are the master keys of the derivation paths.
+func Keys(keychains []bitgo.Keychain) ([]Key, error) {
+	keys := make([]Key, len(keychains))
+	for i, kc := range keychains {
+		xpub, err := bitgo.ParseExtendedKey(kc.Pub)
+		if err != nil {
+			return nil, fmt.Errorf("keychain %s: %w", kc.ID, err)
+		}
+		keys[i].XPub = xpub
+	}
+	return keys, nil
+}
+
+// origin returns the origin of a key derived from the xpub by the path.
+func (k Key) origin(path []uint32) KeyOrigin {
+	o := KeyOrigin{Fingerprint: k.Origin.Fingerprint}
+	if o.Fingerprint == [4]byte{} {
+		o.Fingerprint = k.XPub.Fingerprint()
+	} else {
+		o.Path = append(o.Path, k.Origin.Path...)
+	}
+	o.Path = append(o.Path, path...)
+	return o
+}
+
+// FromPrebuild converts the transaction prebuilt by BitGo into a PSBT, see bitgo.Client.Wallet.Prebuild and New.
+// The change addresses are the wallet addresses of prebuild.TxInfo.ChangeAddresses, see bitgo.Client.Wallet.Address.
+func FromPrebuild(coin string, prebuild *bitgo.TxPrebuild, change []bitgo.WalletAddress, prevTxs []*rawtx.Tx, keys []Key) (*Packet, error) {
+	if prebuild == nil {
+		return nil, errors.New("psbt: prebuild is required")
+	}
+	return New(coin, prebuild.TxHex, prebuild.TxInfo.Unspents, change, prevTxs, keys)
+}
+
+// New converts an unsigned or half-signed transaction of the coin into a PSBT.
+// The unspents must include the spent outputs with their chain, index and scripts.
+// The keys are the user, backup and BitGo keychains (in the order of the wallet's multisig scripts)
+// which describe the BIP32 derivation of every input's public keys. They are optional.
+//
+// The public keys of every input's multisig script must be derived from the keys at the unspent's
+// derivation path, otherwise ErrKeyMismatch is returned.
+//
+// The change addresses describe the outputs which pay back to the wallet,
+// so the signer can tell them apart from the payments.
+// Their scripts and derivations are filled in only if the keys are given and derive the addresses,
+// otherwise ErrKeyMismatch is returned. Taproot change outputs are not described.
+//
+// The prevTxs are the transactions which created the unspents.
+// An input has the previous transaction as its non-witness UTXO if it is given,
+// and segwit inputs also have their witness UTXOs.
+// P2SH inputs require the previous transactions,
+// because a legacy signature doesn't commit to the spent value and the signer must check it.
+// ErrUnsupportedScript is returned if the previous transaction of a P2SH unspent is missing.
+// Some signers ask for the previous transactions of segwit inputs too, e.g., hardware wallets.
+//
+// The signatures of a half-signed transaction are kept as partial signatures.
+func New(coin, txHex string, unspents []bitgo.Unspent, change []bitgo.WalletAddress, prevTxs []*rawtx.Tx, keys []Key) (*Packet, error) {
+	tx, err := rawtx.DecodeString(coin, txHex)
+	if err != nil {
+		return nil, err
+	}
+	byOutpoint := make(map[bitgo.Outpoint]*bitgo.Unspent, len(unspents))
+	for i := range unspents {
+		o, err := unspents[i].Outpoint()
+		if err != nil {
+			return nil, err
+		}
+		byOutpoint[o] = &unspents[i]
+	}
+	byTxID := make(map[string]*rawtx.Tx, len(prevTxs))
+	for _, prev := range prevTxs {
+		byTxID[prev.TxID] = prev
+	}
+
+	p := Packet{
+		Inputs:  make([]Input, len(tx.Inputs)),
+		Outputs: make([]Output, len(tx.Outputs)),
+	}
+	for i := range tx.Inputs {
+		txin := &tx.Inputs[i]
+		u, ok := byOutpoint[txin.Outpoint]
+		if !ok {
+			return nil, fmt.Errorf("input %d: %w: %s", i, rawtx.ErrUnknownInput, txin.Outpoint)
+		}
+		if p.Inputs[i], err = newInput(tx.Coin, u, keys); err != nil {
+			return nil, fmt.Errorf("input %d: %w", i, err)
+		}
+		legacy := p.Inputs[i].WitnessScript == nil
+		if prev := byTxID[txin.Outpoint.TxID]; prev != nil || legacy {
+			if p.Inputs[i].NonWitnessUTXO, err = nonWitnessUTXO(prev, txin.Outpoint, p.Inputs[i].WitnessUTXO); err != nil {
+				return nil, fmt.Errorf("input %d: %w", i, err)
+			}
+		}
+		if legacy {
+			p.Inputs[i].WitnessUTXO = nil
+		}
+		if p.Inputs[i].PartialSigs, err = signatures(txin, &p.Inputs[i]); err != nil {
+			return nil, fmt.Errorf("input %d: %w", i, err)
+		}
+		txin.ScriptSig, txin.Witness = nil, nil
+	}
+
+	// The txid of a half-signed P2SH transaction changes when the input scripts are removed.
+	raw, err := tx.Encode()
+	if err != nil {
+		return nil, err
+	}
+	if p.UnsignedTx, err = rawtx.Decode(tx.Coin, raw); err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		p.XPubs = append(p.XPubs, XPub{Key: k.XPub, KeyOrigin: k.origin(nil)})
+	}
+	if len(keys) > 0 {
+		if err = changeOutputs(coin, p.Outputs, tx.Outputs, change, keys); err != nil {
+			return nil, err
+		}
+	}
+	return &p, nil
+}
+
+// changeOutputs describes the outputs which pay to the change addresses derived from the keys.
+func changeOutputs(coin string, outputs []Output, txouts []rawtx.Output, change []bitgo.WalletAddress, keys []Key) error {
+	for _, a := range change {
+		if a.Chain.IsTaproot() {
+			continue
+		}
+		derived, err := bitgo.DeriveMultisigScripts(xpubs(keys), a.Chain, a.Index)
+		if err != nil {
+			return fmt.Errorf("change address %s: %w", a.Address, err)
+		}
+		parsed, err := bitgo.ParseAddress(coin, a.Address)
+		if err != nil {
+			return err
+		}
+		if s, err := parsed.Script(); err != nil || !bytes.Equal(s, derived.Output) {
+			return fmt.Errorf("%w: change address %s", ErrKeyMismatch, a.Address)
+		}
+		for i, out := range txouts {
+			if bytes.Equal(out.Script, derived.Output) {
+				outputs[i] = Output{
+					RedeemScript:  derived.Redeem,
+					WitnessScript: derived.Witness,
+					Derivations:   derivations(keys, derived.PubKeys, a.DerivationPath()),
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// newInput describes how to sign the unspent of a 2-of-3 multisig wallet.
+func newInput(coin string, u *bitgo.Unspent, keys []Key) (Input, error) {
+	var in Input
+	redeem, err := hex.DecodeString(u.RedeemScript)
+	if err != nil {
+		return in, fmt.Errorf("psbt: unspent %s redeem script: %v", u.ID, err)
+	}
+	witness, err := hex.DecodeString(u.WitnessScript)
+	if err != nil {
+		return in, fmt.Errorf("psbt: unspent %s witness script: %v", u.ID, err)
+	}
+
+	// BitGo may send the witness script as the redeem script.
+	if len(witness) == 0 && !isWitnessProgram(redeem) {
+		witness = redeem
+	}
+	var script []byte
+	switch u.Chain &^ 1 {
+	case bitgo.ChainP2SH:
+		in.RedeemScript = redeem
+		script = bitgo.P2SHScript(redeem)
+	case bitgo.ChainP2SHP2WSH:
+		in.WitnessScript = witness
+		in.RedeemScript = bitgo.P2WSHScript(witness)
+		script = bitgo.P2SHScript(in.RedeemScript)
+	case bitgo.ChainP2WSH:
+		in.WitnessScript = witness
+		script = bitgo.P2WSHScript(witness)
+	default:
+		return in, fmt.Errorf("%w: %s unspent %s", ErrUnsupportedScript, u.Chain.ScriptType(), u.ID)
+	}
+	if _, _, ok := multisig(in.signingScript()); !ok {
+		return in, fmt.Errorf("%w: unspent %s is not multisig", ErrUnsupportedScript, u.ID)
+	}
+
+	if u.Address != "" {
+		a, err := bitgo.ParseAddress(coin, u.Address)
+		if err != nil {
+			return in, err
+		}
+		if s, err := a.Script(); err != nil || !bytes.Equal(s, script) {
+			return in, fmt.Errorf("psbt: scripts of unspent %s don't match its address %s", u.ID, u.Address)
+		}
+	}
+	in.WitnessUTXO = &rawtx.Output{Value: u.Value, Script: script}
+	in.SighashType = sighashType(coin)
+
+	if len(keys) == 0 {
+		return in, nil
+	}
+	derived, err := bitgo.DeriveMultisigScripts(xpubs(keys), u.Chain, u.Index)
+	if err != nil {
+		return in, err
+	}
+	if !bytes.Equal(derived.Output, script) {
+		return in, fmt.Errorf("%w: multisig script of unspent %s", ErrKeyMismatch, u.ID)
+	}
+	in.Derivations = derivations(keys, derived.PubKeys, u.DerivationPath())
+	return in, nil
+}
+
+// xpubs returns the xpubs of the keys.
+func xpubs(keys []Key) []*bitgo.ExtendedKey {
+	xpubs := make([]*bitgo.ExtendedKey, len(keys))
+	for i, k := range keys {
+		xpubs[i] = k.XPub
+	}
+	return xpubs
+}
+
+// derivations describes the public keys derived from the keys by the path.
+func derivations(keys []Key, pubkeys [][]byte, path []uint32) []Derivation {
+	d := make([]Derivation, len(pubkeys))
+	for i, pk := range pubkeys {
+		d[i] = Derivation{PubKey: pk, KeyOrigin: keys[i].origin(path)}
+	}
+	return d
+}
+
+// nonWitnessUTXO returns the serialized previous transaction
+// after checking that its output at the outpoint is the spent output.
+func nonWitnessUTXO(prev *rawtx.Tx, o bitgo.Outpoint, spent *rawtx.Output) ([]byte, error) {
+	if prev == nil {
+		return nil, fmt.Errorf("%w: previous transaction of P2SH input %s is missing", ErrUnsupportedScript, o)
+	}
+	if int(o.Vout) >= len(prev.Outputs) {
+		return nil, fmt.Errorf("psbt: previous transaction %s has no output %d", prev.TxID, o.Vout)
+	}
+	out := prev.Outputs[o.Vout]
+	if out.Value.Cmp(spent.Value) != 0 || !bytes.Equal(out.Script, spent.Script) {
+		return nil, fmt.Errorf("psbt: unspent %s doesn't match the output of the previous transaction", o)
+	}
+	return prev.Encode()
+}
+
+// signatures returns the signatures of a half-signed input.
+// BitGo keeps an empty placeholder for each missing signature, so the signatures are in the order of the public keys.
+func signatures(txin *rawtx.Input, in *Input) ([]PartialSig, error) {
+	stack := txin.Witness
+	if in.WitnessScript == nil {
+		var err error
+		if stack, err = pushes(txin.ScriptSig); err != nil {
+			return nil, err
+		}
+	}
+	if len(stack) == 0 {
+		return nil, nil
+	}
+
+	script := in.signingScript()
+	pubkeys, _, _ := multisig(script)
+	if len(stack) != len(pubkeys)+2 || len(stack[0]) != 0 || !bytes.Equal(stack[len(stack)-1], script) {
+		return nil, fmt.Errorf("psbt: can't match %d signatures to public keys", len(stack)-2)
+	}
+	var sigs []PartialSig
+	for i, sig := range stack[1 : len(stack)-1] {
+		if len(sig) > 0 {
+			sigs = append(sigs, PartialSig{PubKey: pubkeys[i], Signature: sig})
+		}
+	}
+	return sigs, nil
+}
+
+// Extract returns the hex encoded signed transaction.
+// It is half-signed if the inputs don't have enough signatures yet,
+// then the missing signatures are empty placeholders as BitGo expects,
+// see bitgo.Client.Wallet.Submit.
+// ErrNotSigned is returned if an input has no signatures.
+func (p *Packet) Extract() (string, error) {
+	if len(p.Inputs) != len(p.UnsignedTx.Inputs) {
+		return "", fmt.Errorf("%w: inputs don't match the transaction", ErrMalformed)
+	}
+	tx := *p.UnsignedTx
+	tx.Inputs = append([]rawtx.Input(nil), tx.Inputs...)
+	for i := range tx.Inputs {
+		in := &p.Inputs[i]
+		if in.FinalScriptSig != nil || in.FinalScriptWitness != nil {
+			tx.Inputs[i].ScriptSig, tx.Inputs[i].Witness = in.FinalScriptSig, in.FinalScriptWitness
+			continue
+		}
+		var err error
+		if tx.Inputs[i].ScriptSig, tx.Inputs[i].Witness, err = in.finalize(); err != nil {
+			return "", fmt.Errorf("input %d: %w", i, err)
+		}
+	}
+
+	raw, err := tx.Encode()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+// finalize returns the script and witness which spend the multisig input with the partial signatures.
+func (in *Input) finalize() (scriptSig []byte, witness [][]byte, err error) {
+	script := in.signingScript()
+	pubkeys, m, ok := multisig(script)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: not multisig", ErrUnsupportedScript)
+	}
+	sigs := make([][]byte, len(pubkeys))
+	n := 0
+	for _, s := range in.PartialSigs {
+		for i, pk := range pubkeys {
+			if bytes.Equal(pk, s.PubKey) && sigs[i] == nil {
+				sigs[i] = s.Signature
+				n++
+			}
+		}
+	}
+	if n == 0 {
+		return nil, nil, ErrNotSigned
+	}
+
+	// OP_CHECKMULTISIG pops an extra item.
+	stack := [][]byte{{}}
+	for _, sig := range sigs {
+		switch {
+		case n < m:
+			if sig == nil {
+				sig = []byte{}
+			}
+			stack = append(stack, sig)
+		case sig != nil && len(stack) <= m:
+			stack = append(stack, sig)
+		}
+	}
+	stack = append(stack, script)
+
+	if in.WitnessScript == nil {
+		return pushScript(stack), nil, nil
+	}
+	if in.RedeemScript != nil {
+		scriptSig = pushScript([][]byte{in.RedeemScript})
+	}
+	return scriptSig, stack, nil
+}
+
+// signingScript returns the script the signatures commit to.
+func (in *Input) signingScript() []byte {
+	if in.WitnessScript != nil {
+		return in.WitnessScript
+	}
+	return in.RedeemScript
+}
+
+// sighashType returns SIGHASH_ALL, with the fork id for the coins which require it.
+func sighashType(coin string) uint32 {
+	ci, _ := bitgo.LookupCoin(coin)
+	mainnet := ci.Ticker
+	if ci.Testnet {
+		mainnet = ci.Counterpart
+	}
+	switch mainnet {
+	case "bch", "bsv":
+		return 0x41
+	case "btg":
+		// Bitcoin Gold fork id is 79.
+		return 79<<8 | 0x41
+	}
+	return 0x01
+}
+
+// multisig returns the public keys and the number of required signatures of m-of-n multisig script.
+func multisig(script []byte) (pubkeys [][]byte, m int, ok bool) {
+	if len(script) < 3 || script[len(script)-1] != opCheckMultisig {
+		return nil, 0, false
+	}
+	m = int(script[0]) - op1 + 1
+	n := int(script[len(script)-2]) - op1 + 1
+	if m < 1 || n > op16-op1+1 || m > n {
+		return nil, 0, false
+	}
+	for i := 1; i < len(script)-2; {
+		size := int(script[i])
+		if size != 33 && size != 65 || i+1+size > len(script)-2 {
+			return nil, 0, false
+		}
+		pubkeys = append(pubkeys, script[i+1:i+1+size])
+		i += 1 + size
+	}
+	return pubkeys, m, len(pubkeys) == n
+}
+
+// pushes returns the data pushed by the script, e.g., signatures of a P2SH input.
+func pushes(script []byte) ([][]byte, error) {
+	var items [][]byte
+	for i := 0; i < len(script); {
+		op := script[i]
+		i++
+		size := int(op)
+		switch {
+		case op == op0:
+		case op < opPushData1:
+		case op == opPushData1 && i+1 <= len(script):
+			size = int(script[i])
+			i++
+		case op == opPushData2 && i+2 <= len(script):
+			size = int(binary.LittleEndian.Uint16(script[i:]))
+			i += 2
+		case op == opPushData4 && i+4 <= len(script):
+			size = int(binary.LittleEndian.Uint32(script[i:]))
+			i += 4
+		default:
+			return nil, fmt.Errorf("%w: script sig has opcode %#x", ErrUnsupportedScript, op)
+		}
+		if size < 0 || i+size > len(script) {
+			return nil, fmt.Errorf("%w: script sig push", ErrMalformed)
+		}
+		items = append(items, script[i:i+size])
+		i += size
+	}
+	return items, nil
+}
+
+// pushScript returns the script pushing the items, empty items are pushed with OP_0.
+func pushScript(items [][]byte) []byte {
+	var b []byte
+	for _, item := range items {
+		n := len(item)
+		switch {
+		case n < opPushData1:
+			b = append(b, byte(n))
+		case n <= 0xff:
+			b = append(b, opPushData1, byte(n))
+		case n <= 0xffff:
+			b = binary.LittleEndian.AppendUint16(append(b, opPushData2), uint16(n))
+		default:
+			b = binary.LittleEndian.AppendUint32(append(b, opPushData4), uint32(n))
+		}
+		b = append(b, item...)
+	}
+	return b
+}
+
+// isWitnessProgram reports whether the redeem script of P2SH-P2WSH is a hash of the witness script.
+func isWitnessProgram(redeem []byte) bool {
+	return len(redeem) == 34 && redeem[0] == op0 && redeem[1] == 32
+}
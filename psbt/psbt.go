@@ -0,0 +1,537 @@
+// Package psbt converts BitGo prebuilt and half-signed transactions into partially signed
+// Bitcoin transactions (BIP174) and back, so a wallet key can co-sign with standard offline tooling,
+// e.g., an air-gapped node or a hardware wallet, instead of handing the passphrase to BitGo Express.
+//
+//	prebuild, err := c.Wallet.Prebuild(ctx, walletID, &params)
+//	if err != nil {
+//		return err
+//	}
+//	p, err := psbt.FromPrebuild("btc", prebuild, change, prevTxs, keys)
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(p) // Base64 PSBT for the signer.
+//
+// The change addresses of the prebuild are fetched with c.Wallet.Address, so the signer recognizes the change outputs.
+// The prevTxs are the transactions which created the unspents, e.g., fetched from a trusted node.
+// They are required for P2SH unspents and can be nil if the signer accepts segwit inputs without them.
+//
+// The signed PSBT is turned into a half-signed transaction which BitGo co-signs.
+//
+//	p, err := psbt.DecodeString("btc", signed)
+//	if err != nil {
+//		return err
+//	}
+//	txHex, err := p.Extract()
+//	if err != nil {
+//		return err
+//	}
+//	tx, err := c.Wallet.Submit(ctx, walletID, &bitgo.SubmitParams{HalfSigned: bitgo.HalfSignedTx{TxHex: txHex}})
+package psbt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/marselester/bitgo-v2"
+	"github.com/marselester/bitgo-v2/rawtx"
+)
+
+var (
+	// ErrMalformed indicates that the PSBT can't be decoded.
+	ErrMalformed = errors.New("psbt: malformed packet")
+	// ErrUnsupportedScript indicates that an input can't be described or signed, e.g., a taproot input.
+	ErrUnsupportedScript = errors.New("psbt: unsupported script")
+	// ErrNotSigned indicates that an input has no signatures to extract.
+	ErrNotSigned = errors.New("psbt: input is not signed")
+	// ErrKeyMismatch indicates that a public key of an input isn't derived from the wallet keys.
+	ErrKeyMismatch = errors.New("psbt: public key is not derived from the wallet keys")
+)
+
+// magic starts every PSBT.
+var magic = []byte{'p', 's', 'b', 't', 0xff}
+
+// Key types of the global, input and output maps.
+const (
+	globalUnsignedTx = 0x00
+	globalXPub       = 0x01
+
+	inputNonWitnessUTXO     = 0x00
+	inputWitnessUTXO        = 0x01
+	inputPartialSig         = 0x02
+	inputSighashType        = 0x03
+	inputRedeemScript       = 0x04
+	inputWitnessScript      = 0x05
+	inputBIP32Derivation    = 0x06
+	inputFinalScriptSig     = 0x07
+	inputFinalScriptWitness = 0x08
+
+	outputRedeemScript    = 0x00
+	outputWitnessScript   = 0x01
+	outputBIP32Derivation = 0x02
+)
+
+// Packet is a partially signed transaction.
+type Packet struct {
+	// UnsignedTx is the transaction with empty input scripts and witnesses.
+	UnsignedTx *rawtx.Tx
+	// XPubs are the extended public keys of the wallet.
+	XPubs []XPub
+	// Inputs describe the inputs of UnsignedTx in the same order.
+	Inputs []Input
+	// Outputs describe the outputs of UnsignedTx in the same order.
+	Outputs []Output
+	// Unknown are the global entries the package doesn't interpret, they are kept when encoding.
+	Unknown []Unknown
+}
+
+// Input describes how to sign an input.
+type Input struct {
+	// NonWitnessUTXO is the serialized transaction which created the spent output.
+	NonWitnessUTXO []byte
+	// WitnessUTXO is the spent output.
+	WitnessUTXO *rawtx.Output
+	// PartialSigs are the signatures collected so far.
+	PartialSigs []PartialSig
+	// SighashType is the signature hash type the signers must use, zero if not set.
+	SighashType   uint32
+	RedeemScript  []byte
+	WitnessScript []byte
+	// Derivations tell the signers which keys sign the input.
+	Derivations []Derivation
+	// FinalScriptSig and FinalScriptWitness are set when the input is complete.
+	FinalScriptSig     []byte
+	FinalScriptWitness [][]byte
+	Unknown            []Unknown
+}
+
+// Output describes an output, e.g., the scripts and keys of the wallet's change.
+type Output struct {
+	RedeemScript  []byte
+	WitnessScript []byte
+	Derivations   []Derivation
+	Unknown       []Unknown
+}
+
+// PartialSig is a signature of the public key.
+type PartialSig struct {
+	PubKey []byte
+	// Signature is DER encoded and followed by the sighash type.
+	Signature []byte
+}
+
+// KeyOrigin is a fingerprint of the master key and a BIP32 path of a derived key.
+type KeyOrigin struct {
+	Fingerprint [4]byte
+	Path        []uint32
+}
+
+// String returns the origin as a fingerprint and a path, e.g., "d34db33f/m/48'/0/1".
+func (o KeyOrigin) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%x/m", o.Fingerprint)
+	for _, i := range o.Path {
+		b.WriteString("/" + strconv.FormatUint(uint64(i&^hardened), 10))
+		if i&hardened != 0 {
+			b.WriteString("'")
+		}
+	}
+	return b.String()
+}
+
+// hardened is the offset of hardened BIP32 child numbers.
+const hardened = 1 << 31
+
+// Derivation is a public key and where it is derived from.
+type Derivation struct {
+	PubKey []byte
+	KeyOrigin
+}
+
+// XPub is an extended public key and where it is derived from.
+type XPub struct {
+	Key *bitgo.ExtendedKey
+	KeyOrigin
+}
+
+// Unknown is a key-value entry of a type the package doesn't interpret.
+type Unknown struct {
+	Key   []byte
+	Value []byte
+}
+
+// DecodeString decodes a base64 encoded PSBT of the coin.
+func DecodeString(coin, s string) (*Packet, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return Decode(coin, b)
+}
+
+// Decode decodes a binary PSBT of the coin.
+func Decode(coin string, b []byte) (*Packet, error) {
+	if !bytes.HasPrefix(b, magic) {
+		return nil, fmt.Errorf("%w: no magic bytes", ErrMalformed)
+	}
+	r := bytes.NewReader(b[len(magic):])
+
+	var p Packet
+	err := readMap(r, func(key, value []byte) error {
+		switch {
+		case key[0] == globalUnsignedTx && len(key) == 1:
+			if p.UnsignedTx != nil {
+				return fmt.Errorf("%w: duplicate unsigned transaction", ErrMalformed)
+			}
+			tx, err := rawtx.Decode(coin, value)
+			if err != nil {
+				return err
+			}
+			for _, in := range tx.Inputs {
+				if len(in.ScriptSig) > 0 || len(in.Witness) > 0 {
+					return fmt.Errorf("%w: transaction has input scripts", ErrMalformed)
+				}
+			}
+			p.UnsignedTx = tx
+		case key[0] == globalXPub:
+			k, err := bitgo.DecodeExtendedKey(key[1:])
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrMalformed, err)
+			}
+			origin, err := decodeOrigin(value)
+			if err != nil {
+				return err
+			}
+			p.XPubs = append(p.XPubs, XPub{Key: k, KeyOrigin: origin})
+		default:
+			p.Unknown = append(p.Unknown, Unknown{Key: key, Value: value})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if p.UnsignedTx == nil {
+		return nil, fmt.Errorf("%w: no unsigned transaction", ErrMalformed)
+	}
+
+	p.Inputs = make([]Input, len(p.UnsignedTx.Inputs))
+	for i := range p.Inputs {
+		if err = readMap(r, p.Inputs[i].decode); err != nil {
+			return nil, fmt.Errorf("input %d: %w", i, err)
+		}
+	}
+	p.Outputs = make([]Output, len(p.UnsignedTx.Outputs))
+	for i := range p.Outputs {
+		if err = readMap(r, p.Outputs[i].decode); err != nil {
+			return nil, fmt.Errorf("output %d: %w", i, err)
+		}
+	}
+	if r.Len() > 0 {
+		return nil, fmt.Errorf("%w: %d bytes after outputs", ErrMalformed, r.Len())
+	}
+	return &p, nil
+}
+
+// decode sets the input field of the key.
+func (in *Input) decode(key, value []byte) error {
+	// Only signatures and derivations have key data.
+	if len(key) > 1 && key[0] != inputPartialSig && key[0] != inputBIP32Derivation {
+		in.Unknown = append(in.Unknown, Unknown{Key: key, Value: value})
+		return nil
+	}
+	var err error
+	switch key[0] {
+	case inputNonWitnessUTXO:
+		in.NonWitnessUTXO = value
+	case inputWitnessUTXO:
+		r := bytes.NewReader(value)
+		var v uint64
+		if err = binary.Read(r, binary.LittleEndian, &v); err != nil || int64(v) < 0 {
+			return fmt.Errorf("%w: witness utxo value", ErrMalformed)
+		}
+		out := rawtx.Output{Value: bitgo.NewAmount(int64(v))}
+		if out.Script, err = readBytes(r); err != nil {
+			return err
+		}
+		in.WitnessUTXO = &out
+	case inputPartialSig:
+		in.PartialSigs = append(in.PartialSigs, PartialSig{PubKey: key[1:], Signature: value})
+	case inputSighashType:
+		if len(value) != 4 {
+			return fmt.Errorf("%w: sighash type", ErrMalformed)
+		}
+		in.SighashType = binary.LittleEndian.Uint32(value)
+	case inputRedeemScript:
+		in.RedeemScript = value
+	case inputWitnessScript:
+		in.WitnessScript = value
+	case inputBIP32Derivation:
+		d := Derivation{PubKey: key[1:]}
+		if d.KeyOrigin, err = decodeOrigin(value); err != nil {
+			return err
+		}
+		in.Derivations = append(in.Derivations, d)
+	case inputFinalScriptSig:
+		in.FinalScriptSig = value
+	case inputFinalScriptWitness:
+		r := bytes.NewReader(value)
+		n, err := readVarInt(r)
+		if err != nil || n > uint64(r.Len()) {
+			return fmt.Errorf("%w: final script witness", ErrMalformed)
+		}
+		in.FinalScriptWitness = make([][]byte, n)
+		for i := range in.FinalScriptWitness {
+			if in.FinalScriptWitness[i], err = readBytes(r); err != nil {
+				return err
+			}
+		}
+	default:
+		in.Unknown = append(in.Unknown, Unknown{Key: key, Value: value})
+	}
+	return nil
+}
+
+// decode sets the output field of the key.
+func (out *Output) decode(key, value []byte) error {
+	if len(key) > 1 && key[0] != outputBIP32Derivation {
+		out.Unknown = append(out.Unknown, Unknown{Key: key, Value: value})
+		return nil
+	}
+	switch key[0] {
+	case outputRedeemScript:
+		out.RedeemScript = value
+	case outputWitnessScript:
+		out.WitnessScript = value
+	case outputBIP32Derivation:
+		origin, err := decodeOrigin(value)
+		if err != nil {
+			return err
+		}
+		out.Derivations = append(out.Derivations, Derivation{PubKey: key[1:], KeyOrigin: origin})
+	default:
+		out.Unknown = append(out.Unknown, Unknown{Key: key, Value: value})
+	}
+	return nil
+}
+
+// Encode serializes the PSBT.
+func (p *Packet) Encode() ([]byte, error) {
+	if p.UnsignedTx == nil {
+		return nil, fmt.Errorf("%w: no unsigned transaction", ErrMalformed)
+	}
+	if len(p.Inputs) != len(p.UnsignedTx.Inputs) || len(p.Outputs) != len(p.UnsignedTx.Outputs) {
+		return nil, fmt.Errorf("%w: inputs and outputs don't match the transaction", ErrMalformed)
+	}
+	tx, err := p.UnsignedTx.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	var w writer
+	w.b = append(w.b, magic...)
+	w.entry([]byte{globalUnsignedTx}, tx)
+	for _, x := range p.XPubs {
+		w.entry(append([]byte{globalXPub}, x.Key.Bytes()...), encodeOrigin(x.KeyOrigin))
+	}
+	w.unknown(p.Unknown)
+	w.end()
+
+	for _, in := range p.Inputs {
+		if in.NonWitnessUTXO != nil {
+			w.entry([]byte{inputNonWitnessUTXO}, in.NonWitnessUTXO)
+		}
+		if in.WitnessUTXO != nil {
+			v, ok := in.WitnessUTXO.Value.Int64()
+			if !ok {
+				return nil, fmt.Errorf("%w: witness utxo value", ErrMalformed)
+			}
+			value := binary.LittleEndian.AppendUint64(nil, uint64(v))
+			w.entry([]byte{inputWitnessUTXO}, appendBytes(value, in.WitnessUTXO.Script))
+		}
+		for _, s := range in.PartialSigs {
+			w.entry(append([]byte{inputPartialSig}, s.PubKey...), s.Signature)
+		}
+		if in.SighashType != 0 {
+			w.entry([]byte{inputSighashType}, binary.LittleEndian.AppendUint32(nil, in.SighashType))
+		}
+		if in.RedeemScript != nil {
+			w.entry([]byte{inputRedeemScript}, in.RedeemScript)
+		}
+		if in.WitnessScript != nil {
+			w.entry([]byte{inputWitnessScript}, in.WitnessScript)
+		}
+		for _, d := range in.Derivations {
+			w.entry(append([]byte{inputBIP32Derivation}, d.PubKey...), encodeOrigin(d.KeyOrigin))
+		}
+		if in.FinalScriptSig != nil {
+			w.entry([]byte{inputFinalScriptSig}, in.FinalScriptSig)
+		}
+		if in.FinalScriptWitness != nil {
+			stack := appendVarInt(nil, uint64(len(in.FinalScriptWitness)))
+			for _, item := range in.FinalScriptWitness {
+				stack = appendBytes(stack, item)
+			}
+			w.entry([]byte{inputFinalScriptWitness}, stack)
+		}
+		w.unknown(in.Unknown)
+		w.end()
+	}
+
+	for _, out := range p.Outputs {
+		if out.RedeemScript != nil {
+			w.entry([]byte{outputRedeemScript}, out.RedeemScript)
+		}
+		if out.WitnessScript != nil {
+			w.entry([]byte{outputWitnessScript}, out.WitnessScript)
+		}
+		for _, d := range out.Derivations {
+			w.entry(append([]byte{outputBIP32Derivation}, d.PubKey...), encodeOrigin(d.KeyOrigin))
+		}
+		w.unknown(out.Unknown)
+		w.end()
+	}
+	return w.b, nil
+}
+
+// String returns the base64 encoded PSBT, or an empty string if it can't be encoded.
+func (p *Packet) String() string {
+	b, err := p.Encode()
+	if err != nil {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// readMap reads key-value entries until the map separator.
+// Keys of the same map must be unique.
+func readMap(r *bytes.Reader, f func(key, value []byte) error) error {
+	seen := make(map[string]bool)
+	for {
+		key, err := readBytes(r)
+		if err != nil {
+			return err
+		}
+		if len(key) == 0 {
+			return nil
+		}
+		if seen[string(key)] {
+			return fmt.Errorf("%w: duplicate key %x", ErrMalformed, key)
+		}
+		seen[string(key)] = true
+
+		value, err := readBytes(r)
+		if err != nil {
+			return err
+		}
+		if err = f(key, value); err != nil {
+			return err
+		}
+	}
+}
+
+// decodeOrigin decodes a fingerprint followed by the path.
+func decodeOrigin(b []byte) (KeyOrigin, error) {
+	if len(b) < 4 || len(b)%4 != 0 {
+		return KeyOrigin{}, fmt.Errorf("%w: key origin", ErrMalformed)
+	}
+	var o KeyOrigin
+	copy(o.Fingerprint[:], b)
+	for b = b[4:]; len(b) > 0; b = b[4:] {
+		o.Path = append(o.Path, binary.LittleEndian.Uint32(b))
+	}
+	return o, nil
+}
+
+// encodeOrigin encodes a fingerprint followed by the path.
+func encodeOrigin(o KeyOrigin) []byte {
+	b := append([]byte(nil), o.Fingerprint[:]...)
+	for _, i := range o.Path {
+		b = binary.LittleEndian.AppendUint32(b, i)
+	}
+	return b
+}
+
+// writer appends key-value entries of the maps.
+type writer struct {
+	b []byte
+}
+
+func (w *writer) entry(key, value []byte) {
+	w.b = appendBytes(w.b, key)
+	w.b = appendBytes(w.b, value)
+}
+
+func (w *writer) unknown(entries []Unknown) {
+	for _, e := range entries {
+		w.entry(e.Key, e.Value)
+	}
+}
+
+// end appends the map separator.
+func (w *writer) end() {
+	w.b = append(w.b, 0)
+}
+
+// readVarInt reads Bitcoin's variable length integer.
+func readVarInt(r *bytes.Reader) (uint64, error) {
+	prefix, err := r.ReadByte()
+	if err != nil {
+		return 0, fmt.Errorf("%w: unexpected end", ErrMalformed)
+	}
+	var b [8]byte
+	var size int
+	switch prefix {
+	case 0xfd:
+		size = 2
+	case 0xfe:
+		size = 4
+	case 0xff:
+		size = 8
+	default:
+		return uint64(prefix), nil
+	}
+	if _, err = io.ReadFull(r, b[:size]); err != nil {
+		return 0, fmt.Errorf("%w: unexpected end", ErrMalformed)
+	}
+	return binary.LittleEndian.Uint64(b[:]), nil
+}
+
+// readBytes reads a byte slice prefixed with its length.
+func readBytes(r *bytes.Reader) ([]byte, error) {
+	n, err := readVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(r.Len()) {
+		return nil, fmt.Errorf("%w: unexpected end", ErrMalformed)
+	}
+	b := make([]byte, n)
+	_, err = io.ReadFull(r, b)
+	return b, err
+}
+
+// appendVarInt appends Bitcoin's variable length integer.
+func appendVarInt(b []byte, n uint64) []byte {
+	switch {
+	case n < 0xfd:
+		return append(b, byte(n))
+	case n <= 0xffff:
+		return binary.LittleEndian.AppendUint16(append(b, 0xfd), uint16(n))
+	case n <= 0xffffffff:
+		return binary.LittleEndian.AppendUint32(append(b, 0xfe), uint32(n))
+	default:
+		return binary.LittleEndian.AppendUint64(append(b, 0xff), n)
+	}
+}
+
+// appendBytes appends the byte slice prefixed with its length.
+func appendBytes(b, v []byte) []byte {
+	return append(appendVarInt(b, uint64(len(v))), v...)
+}
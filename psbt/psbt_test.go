@@ -0,0 +1,360 @@
+package psbt_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/marselester/bitgo-v2"
+	"github.com/marselester/bitgo-v2/psbt"
+	"github.com/marselester/bitgo-v2/rawtx"
+)
+
+// BIP32 test vector 1 public keys of m, m/0H, and m/0H/1 stand for the user, backup and BitGo xpubs.
+var xpubs = []string{
+	"xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8",
+	"xpub68Gmy5EdvgibQVfPdqkBBCHxA5htiqg55crXYuXoQRKfDBFA1WEjWgP6LHhwBZeNK1VTsfTFUHCdrfp1bgwQ9xv5ski8PX9rL2dZXvgGDnw",
+	"xpub6ASuArnXKPbfEwhqN6e3mwBcDTgzisQN1wXN9BJcM47sSikHjJf3UFHKkNAWbWMiGj7Wf5uMash7SyYq527Hqck2AxYysAA7xmALppuCkwQ",
+}
+
+// wallet is a 2-of-3 multisig of the user, backup and BitGo keys.
+type wallet struct {
+	keys []psbt.Key
+	// pubkeys are the multisig public keys of every unspent.
+	pubkeys  [][][]byte
+	unspents []bitgo.Unspent
+	// prevTx created the P2SH and P2SH-P2WSH unspents.
+	prevTx *rawtx.Tx
+	// change is the P2SH-P2WSH address of the second output.
+	change []bitgo.WalletAddress
+	txHex  string
+}
+
+// newWallet returns a wallet with P2SH, P2SH-P2WSH and P2WSH unspents spent by a prebuilt transaction
+// which pays back to the P2SH-P2WSH address as change.
+// The signatures are made up, they are not verified.
+func newWallet(t *testing.T) *wallet {
+	var (
+		w    wallet
+		keys []*bitgo.ExtendedKey
+	)
+	for _, s := range xpubs {
+		k, err := bitgo.ParseExtendedKey(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, k)
+		w.keys = append(w.keys, psbt.Key{XPub: k})
+	}
+	// multisig returns the scripts of the wallet address at the chain and index.
+	multisig := func(chain bitgo.Chain, index int) *bitgo.MultisigScripts {
+		s, err := bitgo.DeriveMultisigScripts(keys, chain, index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.pubkeys = append(w.pubkeys, s.PubKeys)
+		return s
+	}
+	address := func(script []byte) string {
+		a, err := bitgo.ScriptAddress("tbtc", script)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a.String()
+	}
+
+	legacy := multisig(bitgo.ChainP2SH, 3)
+	nested := multisig(bitgo.ChainP2SHP2WSHChange, 7)
+	native := multisig(bitgo.ChainP2WSH, 9)
+	w.prevTx = &rawtx.Tx{
+		Coin:    "tbtc",
+		Version: 1,
+		Inputs:  []rawtx.Input{{Outpoint: bitgo.Outpoint{TxID: strings.Repeat("11", 32)}, ScriptSig: []byte{0}, Sequence: 0xffffffff}},
+		Outputs: []rawtx.Output{
+			{Value: bitgo.NewAmount(100000), Script: legacy.Output},
+			{Value: bitgo.NewAmount(200000), Script: nested.Output},
+		},
+	}
+	raw, err := w.prevTx.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.prevTx, err = rawtx.Decode("tbtc", raw); err != nil {
+		t.Fatal(err)
+	}
+
+	w.unspents = []bitgo.Unspent{
+		{ID: w.prevTx.TxID + ":0", Value: bitgo.NewAmount(100000), Chain: bitgo.ChainP2SH, Index: 3, RedeemScript: hex.EncodeToString(legacy.Redeem), Address: address(legacy.Output)},
+		{ID: w.prevTx.TxID + ":1", Value: bitgo.NewAmount(200000), Chain: bitgo.ChainP2SHP2WSHChange, Index: 7, RedeemScript: hex.EncodeToString(nested.Redeem), WitnessScript: hex.EncodeToString(nested.Witness), Address: address(nested.Output)},
+		{ID: strings.Repeat("33", 32) + ":2", Value: bitgo.NewAmount(300000), Chain: bitgo.ChainP2WSH, Index: 9, RedeemScript: hex.EncodeToString(native.Witness), Address: address(native.Output)},
+	}
+
+	tx := rawtx.Tx{Coin: "tbtc", Version: 2, LockTime: 100}
+	for _, u := range w.unspents {
+		o, _ := u.Outpoint()
+		tx.Inputs = append(tx.Inputs, rawtx.Input{Outpoint: o, Sequence: 0xfffffffd})
+	}
+	tx.Outputs = []rawtx.Output{
+		{Value: bitgo.NewAmount(550000), Script: native.Output},
+		{Value: bitgo.NewAmount(45000), Script: nested.Output},
+	}
+	w.change = []bitgo.WalletAddress{{Address: address(nested.Output), Chain: bitgo.ChainP2SHP2WSHChange, Index: 7}}
+	if raw, err = tx.Encode(); err != nil {
+		t.Fatal(err)
+	}
+	w.txHex = hex.EncodeToString(raw)
+	return &w
+}
+
+func TestFromPrebuild(t *testing.T) {
+	w := newWallet(t)
+	prebuild := bitgo.TxPrebuild{TxHex: w.txHex, TxInfo: bitgo.PrebuildInfo{Unspents: w.unspents}}
+	p, err := psbt.FromPrebuild("tbtc", &prebuild, w.change, []*rawtx.Tx{w.prevTx}, w.keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(p.XPubs) != 3 || p.XPubs[1].Fingerprint != w.keys[1].XPub.Fingerprint() || len(p.XPubs[1].Path) != 0 {
+		t.Errorf("unexpected xpubs %+v", p.XPubs)
+	}
+	tests := []struct {
+		redeem  bool
+		witness bool
+		prev    bool
+		path    string
+		value   int64
+	}{
+		{redeem: true, prev: true, path: "m/0/0/0/3", value: 100000},
+		{redeem: true, witness: true, prev: true, path: "m/0/0/11/7", value: 200000},
+		{witness: true, path: "m/0/0/20/9", value: 300000},
+	}
+	for i, test := range tests {
+		in := p.Inputs[i]
+		if (in.RedeemScript != nil) != test.redeem || (in.WitnessScript != nil) != test.witness {
+			t.Errorf("input %d: unexpected redeem script %x and witness script %x", i, in.RedeemScript, in.WitnessScript)
+		}
+		// The P2SH input has only the previous transaction, segwit inputs keep the spent outputs.
+		if (in.WitnessUTXO != nil) != test.witness || (in.NonWitnessUTXO != nil) != test.prev {
+			t.Errorf("input %d: unexpected witness utxo %v and non-witness utxo %x", i, in.WitnessUTXO, in.NonWitnessUTXO)
+		}
+		utxo := in.WitnessUTXO
+		if test.prev {
+			prev, err := rawtx.Decode("tbtc", in.NonWitnessUTXO)
+			if err != nil {
+				t.Fatalf("input %d: %v", i, err)
+			}
+			o := p.UnsignedTx.Inputs[i].Outpoint
+			if prev.TxID != o.TxID {
+				t.Errorf("input %d: unexpected previous transaction %s", i, prev.TxID)
+			}
+			utxo = &prev.Outputs[o.Vout]
+		}
+		if utxo.Value.Cmp(bitgo.NewAmount(test.value)) != 0 || in.SighashType != 1 {
+			t.Errorf("input %d: unexpected utxo %v, sighash %d", i, utxo, in.SighashType)
+		}
+		if len(in.Derivations) != 3 {
+			t.Errorf("input %d: expected 3 derivations, got %d", i, len(in.Derivations))
+		}
+		for j, d := range in.Derivations {
+			fp := w.keys[j].XPub.Fingerprint()
+			if !bytes.Equal(d.PubKey, w.pubkeys[i][j]) || d.KeyOrigin.String() != hex.EncodeToString(fp[:])+"/"+test.path {
+				t.Errorf("input %d: unexpected derivation %x %s", i, d.PubKey, d.KeyOrigin)
+			}
+		}
+	}
+
+	// The payment output isn't described, the change output has the scripts and derivations of the P2SH-P2WSH address.
+	if out := p.Outputs[0]; out.RedeemScript != nil || out.WitnessScript != nil || out.Derivations != nil {
+		t.Errorf("unexpected payment output %+v", out)
+	}
+	change := p.Outputs[1]
+	if !bytes.Equal(change.RedeemScript, p.Inputs[1].RedeemScript) || !bytes.Equal(change.WitnessScript, p.Inputs[1].WitnessScript) {
+		t.Errorf("unexpected change scripts %x %x", change.RedeemScript, change.WitnessScript)
+	}
+	if len(change.Derivations) != 3 || change.Derivations[2].KeyOrigin.String() != p.Inputs[1].Derivations[2].KeyOrigin.String() {
+		t.Errorf("unexpected change derivations %+v", change.Derivations)
+	}
+
+	got, err := psbt.DecodeString("tbtc", p.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != p.String() || got.UnsignedTx.TxID != p.UnsignedTx.TxID {
+		t.Errorf("PSBT changed after decoding")
+	}
+}
+
+func TestExtract(t *testing.T) {
+	w := newWallet(t)
+	prevTxs := []*rawtx.Tx{w.prevTx}
+	p, err := psbt.New("tbtc", w.txHex, w.unspents, w.change, prevTxs, w.keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.Extract(); !errors.Is(err, psbt.ErrNotSigned) {
+		t.Fatalf("expected not signed input, got %v", err)
+	}
+
+	userSig := []byte{0x30, 1, 1}
+	for i := range p.Inputs {
+		p.Inputs[i].PartialSigs = []psbt.PartialSig{{PubKey: w.pubkeys[i][0], Signature: userSig}}
+	}
+	halfSigned, err := p.Extract()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := rawtx.DecodeString("tbtc", halfSigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The missing backup and BitGo signatures are empty placeholders.
+	if w := tx.Inputs[2].Witness; len(w) != 5 || len(w[0]) != 0 || !bytes.Equal(w[1], userSig) || len(w[2]) != 0 || len(w[3]) != 0 {
+		t.Errorf("unexpected half-signed witness %x", w)
+	}
+	if len(tx.Inputs[1].ScriptSig) != 35 || len(tx.Inputs[1].Witness) != 5 {
+		t.Errorf("unexpected half-signed P2SH-P2WSH input %+v", tx.Inputs[1])
+	}
+
+	// The half-signed transaction converts back to the same PSBT.
+	got, err := psbt.New("tbtc", halfSigned, w.unspents, w.change, prevTxs, w.keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != p.String() {
+		t.Errorf("expected %s, got %s", p, got)
+	}
+
+	for i := range p.Inputs {
+		p.Inputs[i].PartialSigs = append(p.Inputs[i].PartialSigs, psbt.PartialSig{PubKey: w.pubkeys[i][2], Signature: []byte{0x30, 2, 2}})
+	}
+	signed, err := p.Extract()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx, err = rawtx.DecodeString("tbtc", signed); err != nil {
+		t.Fatal(err)
+	}
+	scriptSig := hex.EncodeToString(tx.Inputs[0].ScriptSig)
+	// OP_0 followed by the user and BitGo signatures without placeholders.
+	if !strings.HasPrefix(scriptSig, "00"+"03300101"+"03300202"+"4c69") || len(tx.Inputs[2].Witness) != 4 {
+		t.Errorf("unexpected signed inputs %s %x", scriptSig, tx.Inputs[2].Witness)
+	}
+	if tx.TxID == p.UnsignedTx.TxID || tx.WTxID == p.UnsignedTx.WTxID {
+		t.Errorf("expected P2SH signature to change txid")
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	w := newWallet(t)
+	prevTxs := []*rawtx.Tx{w.prevTx}
+
+	unspents := append([]bitgo.Unspent(nil), w.unspents...)
+	unspents[2].Chain = bitgo.ChainP2TR
+	if _, err := psbt.New("tbtc", w.txHex, unspents, w.change, prevTxs, nil); !errors.Is(err, psbt.ErrUnsupportedScript) {
+		t.Errorf("expected unsupported taproot input, got %v", err)
+	}
+
+	unspents = append([]bitgo.Unspent(nil), w.unspents...)
+	unspents[2].Address = unspents[1].Address
+	if _, err := psbt.New("tbtc", w.txHex, unspents, w.change, prevTxs, nil); err == nil {
+		t.Error("expected address mismatch")
+	}
+	if _, err := psbt.New("tbtc", w.txHex, w.unspents[1:], w.change, prevTxs, nil); !errors.Is(err, rawtx.ErrUnknownInput) {
+		t.Errorf("expected unknown input, got %v", err)
+	}
+
+	if _, err := psbt.New("tbtc", w.txHex, w.unspents, w.change, nil, nil); !errors.Is(err, psbt.ErrUnsupportedScript) {
+		t.Errorf("expected P2SH input without previous transaction, got %v", err)
+	}
+	unspents = append([]bitgo.Unspent(nil), w.unspents...)
+	unspents[0].Value = bitgo.NewAmount(1000000)
+	if _, err := psbt.New("tbtc", w.txHex, unspents, w.change, prevTxs, nil); err == nil {
+		t.Error("expected previous transaction output mismatch")
+	}
+
+	if _, err := psbt.New("tbtc", w.txHex, w.unspents, w.change, prevTxs, w.keys[:2]); err == nil {
+		t.Error("expected keys mismatch")
+	}
+	swapped := []psbt.Key{w.keys[1], w.keys[0], w.keys[2]}
+	if _, err := psbt.New("tbtc", w.txHex, w.unspents, w.change, prevTxs, swapped); !errors.Is(err, psbt.ErrKeyMismatch) {
+		t.Errorf("expected swapped keys mismatch, got %v", err)
+	}
+	unspents = append([]bitgo.Unspent(nil), w.unspents...)
+	unspents[2].Index = 10
+	if _, err := psbt.New("tbtc", w.txHex, unspents, w.change, prevTxs, w.keys); !errors.Is(err, psbt.ErrKeyMismatch) {
+		t.Errorf("expected derivation path mismatch, got %v", err)
+	}
+	change := []bitgo.WalletAddress{{Address: w.change[0].Address, Chain: bitgo.ChainP2SHP2WSHChange, Index: 8}}
+	if _, err := psbt.New("tbtc", w.txHex, w.unspents, change, prevTxs, w.keys); !errors.Is(err, psbt.ErrKeyMismatch) {
+		t.Errorf("expected change address mismatch, got %v", err)
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	w := newWallet(t)
+	p, err := psbt.New("tbtc", w.txHex, w.unspents, w.change, []*rawtx.Tx{w.prevTx}, w.keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := p.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := [][]byte{
+		nil,
+		[]byte("psbt"),
+		b[:len(b)-1],
+		append(append([]byte(nil), b...), 0),
+		// Unsigned transaction key without a transaction.
+		{'p', 's', 'b', 't', 0xff, 1, 0, 0, 0},
+	}
+	for _, test := range tests {
+		if _, err := psbt.Decode("tbtc", test); !errors.Is(err, psbt.ErrMalformed) && !errors.Is(err, rawtx.ErrMalformed) {
+			t.Errorf("%x: expected malformed packet, got %v", test, err)
+		}
+	}
+}
+
+// TestDecodeBIP174 decodes the BIP174 test vector
+// "PSBT with one P2SH-P2WSH input of a 2-of-2 multisig, redeemScript, witnessScript, and keypaths are available.
+// Contains one signature."
+func TestDecodeBIP174(t *testing.T) {
+	const vector = "70736274ff0100550200000001279a2323a5dfb51fc45f220fa58b0fc13e1e3342792a85d7e36cd6333b5cbc390000000000ffffffff01a05aea0b000000001976a914ffe9c0061097cc3b636f2cb0460fa4fc427d2b4588ac0000000000010120955eea0b0000000017a9146345200f68d189e1adc0df1c4d16ea8f14c0dbeb87220203b1341ccba7683b6af4f1238cd6e97e7167d569fac47f1e48d47541844355bd4646304302200424b58effaaa694e1559ea5c93bbfd4a89064224055cdf070b6771469442d07021f5c8eb0fea6516d60b8acb33ad64ede60e8785bfb3aa94b99bdf86151db9a9a010104220020771fd18ad459666dd49f3d564e3dbc42f4c84774e360ada16816a8ed488d5681010547522103b1341ccba7683b6af4f1238cd6e97e7167d569fac47f1e48d47541844355bd462103de55d1e1dac805e3f8a58c1fbf9b94c02f3dbaafe127fefca4995f26f82083bd52ae220603b1341ccba7683b6af4f1238cd6e97e7167d569fac47f1e48d47541844355bd4610b4a6ba67000000800000008004000080220603de55d1e1dac805e3f8a58c1fbf9b94c02f3dbaafe127fefca4995f26f82083bd10b4a6ba670000008000000080050000800000"
+	b, err := hex.DecodeString(vector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := psbt.Decode("btc", b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(p.Inputs) != 1 || len(p.Outputs) != 1 || p.UnsignedTx.TxID != "b4ca8f48572bf08354f8302adfbd9e5c2fc2a52731de5401a39aa048f68c9c21" {
+		t.Fatalf("unexpected packet %+v", p)
+	}
+	in := p.Inputs[0]
+	if in.WitnessUTXO == nil || in.WitnessUTXO.Value.Cmp(bitgo.NewAmount(199909013)) != 0 {
+		t.Errorf("unexpected witness utxo %v", in.WitnessUTXO)
+	}
+	if hex.EncodeToString(in.RedeemScript) != "0020771fd18ad459666dd49f3d564e3dbc42f4c84774e360ada16816a8ed488d5681" {
+		t.Errorf("unexpected redeem script %x", in.RedeemScript)
+	}
+	if len(in.PartialSigs) != 1 || !bytes.Equal(in.PartialSigs[0].PubKey, in.Derivations[0].PubKey) {
+		t.Errorf("unexpected partial signatures %x", in.PartialSigs)
+	}
+	want := []string{"b4a6ba67/m/0'/0'/4'", "b4a6ba67/m/0'/0'/5'"}
+	if len(in.Derivations) != len(want) || in.Derivations[0].KeyOrigin.String() != want[0] || in.Derivations[1].KeyOrigin.String() != want[1] {
+		t.Errorf("unexpected derivations %v", in.Derivations)
+	}
+
+	got, err := p.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, b) {
+		t.Errorf("expected %x, got %x", b, got)
+	}
+}
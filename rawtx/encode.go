@@ -0,0 +1,89 @@
+package rawtx
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/marselester/bitgo-v2"
+)
+
+// Encode serializes the transaction, e.g., after its input scripts were changed.
+// Witnesses are included if any input has them.
+// Zcash and Dash special transactions can't be encoded, ErrUnsupportedVersion is returned.
+func (t *Tx) Encode() ([]byte, error) {
+	coin, ok := bitgo.LookupCoin(t.Coin)
+	if !ok {
+		return nil, fmt.Errorf("rawtx: unknown coin %q", t.Coin)
+	}
+	mainnet := coin.Ticker
+	if coin.Testnet {
+		mainnet = coin.Counterpart
+	}
+	if mainnet == "zec" || t.Type != 0 {
+		return nil, fmt.Errorf("%w: %s encoding", ErrUnsupportedVersion, t.Coin)
+	}
+
+	witness := false
+	for _, in := range t.Inputs {
+		witness = witness || len(in.Witness) > 0
+	}
+	if witness && !coin.Segwit {
+		return nil, fmt.Errorf("%w: %s has no segwit", bitgo.ErrUnsupportedByCoin, t.Coin)
+	}
+
+	b := binary.LittleEndian.AppendUint32(nil, uint32(t.Version))
+	if witness {
+		b = append(b, 0, 1)
+	}
+	b = appendVarInt(b, uint64(len(t.Inputs)))
+	for i, in := range t.Inputs {
+		hash, err := hex.DecodeString(in.Outpoint.TxID)
+		if err != nil || len(hash) != 32 {
+			return nil, fmt.Errorf("%w: input %d txid", ErrMalformed, i)
+		}
+		for i := len(hash) - 1; i >= 0; i-- {
+			b = append(b, hash[i])
+		}
+		b = binary.LittleEndian.AppendUint32(b, in.Outpoint.Vout)
+		b = appendBytes(b, in.ScriptSig)
+		b = binary.LittleEndian.AppendUint32(b, in.Sequence)
+	}
+	b = appendVarInt(b, uint64(len(t.Outputs)))
+	for i, out := range t.Outputs {
+		value, ok := out.Value.Int64()
+		if !ok || value < 0 {
+			return nil, fmt.Errorf("%w: output %d value", ErrMalformed, i)
+		}
+		b = binary.LittleEndian.AppendUint64(b, uint64(value))
+		b = appendBytes(b, out.Script)
+	}
+	if witness {
+		for _, in := range t.Inputs {
+			b = appendVarInt(b, uint64(len(in.Witness)))
+			for _, item := range in.Witness {
+				b = appendBytes(b, item)
+			}
+		}
+	}
+	return binary.LittleEndian.AppendUint32(b, t.LockTime), nil
+}
+
+// appendVarInt appends Bitcoin's variable length integer.
+func appendVarInt(b []byte, n uint64) []byte {
+	switch {
+	case n < 0xfd:
+		return append(b, byte(n))
+	case n <= 0xffff:
+		return binary.LittleEndian.AppendUint16(append(b, 0xfd), uint16(n))
+	case n <= 0xffffffff:
+		return binary.LittleEndian.AppendUint32(append(b, 0xfe), uint32(n))
+	default:
+		return binary.LittleEndian.AppendUint64(append(b, 0xff), n)
+	}
+}
+
+// appendBytes appends the byte slice prefixed with its length.
+func appendBytes(b, v []byte) []byte {
+	return append(appendVarInt(b, uint64(len(v))), v...)
+}
@@ -0,0 +1,474 @@
+// Package rawtx decodes serialized transactions of UTXO coins, e.g., TxInfo.Tx returned by consolidation,
+// so they can be inspected and audited.
+// It supports legacy and segwit serialization (btc, bch, bsv, btg, ltc), Dash special transactions,
+// and transparent Zcash v4 (Sapling) transactions.
+//
+//	t, err := rawtx.DecodeTxInfo("btc", txInfo)
+//	if err != nil {
+//		return err
+//	}
+//	for _, out := range t.Outputs {
+//		fmt.Println(out.Address, out.Value)
+//	}
+//	fee, err := t.Fee(unspents)
+package rawtx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/marselester/bitgo-v2"
+)
+
+var (
+	// ErrMalformed indicates that the transaction can't be decoded.
+	ErrMalformed = errors.New("rawtx: malformed transaction")
+	// ErrUnsupportedVersion indicates that the transaction is in a format the package doesn't decode,
+	// e.g., Zcash v5 or a shielded Zcash transaction.
+	ErrUnsupportedVersion = errors.New("rawtx: unsupported transaction version")
+	// ErrTxIDMismatch indicates that TxInfo.TxID doesn't match the decoded transaction.
+	ErrTxIDMismatch = errors.New("rawtx: txid mismatch")
+	// ErrUnknownInput indicates that the value of an input is unknown, so the fee can't be calculated.
+	ErrUnknownInput = errors.New("rawtx: unknown input")
+)
+
+// Tx is a decoded transaction.
+type Tx struct {
+	// Coin is a ticker of the coin the transaction was decoded for.
+	Coin string
+	// TxID is a hex encoded transaction ID (the hash of the transaction without witnesses).
+	TxID string
+	// WTxID is a hex encoded witness transaction ID, it equals TxID when there are no witnesses.
+	WTxID string
+	// Version of the transaction, Zcash overwintered flag is not included.
+	Version int32
+	// Type is a Dash special transaction type, zero for other coins.
+	Type     uint16
+	Inputs   []Input
+	Outputs  []Output
+	LockTime uint32
+	// ExpiryHeight is a Zcash block height after which the transaction can't be mined.
+	ExpiryHeight uint32
+	// Size is the serialized size in bytes including witnesses.
+	Size int
+	// Weight is the transaction weight (BIP 141), four times the size for transactions without witnesses.
+	Weight int
+	// VSize is the virtual size in vbytes used to calculate the fee rate.
+	VSize int
+}
+
+// Input is a transaction input.
+type Input struct {
+	// Outpoint is the spent output.
+	Outpoint bitgo.Outpoint
+	// ScriptSig is the unlocking script, e.g., signatures and a redeem script of P2SH input.
+	ScriptSig []byte
+	// Witness is the input's witness stack, nil for non-segwit inputs.
+	Witness  [][]byte
+	Sequence uint32
+}
+
+// Output is a transaction output.
+type Output struct {
+	// Value is the output value in satoshis.
+	Value bitgo.Amount
+	// Script is the output script (scriptPubKey).
+	Script []byte
+	// Address is the decoded output script, nil if the script doesn't pay to an address, e.g., OP_RETURN.
+	Address *bitgo.Address
+}
+
+// DecodeTxInfo decodes the transaction returned by BitGo and checks that its txid matches TxInfo.TxID.
+func DecodeTxInfo(coin string, info *bitgo.TxInfo) (*Tx, error) {
+	t, err := DecodeString(coin, info.Tx)
+	if err != nil {
+		return nil, err
+	}
+	if info.TxID != "" && !strings.EqualFold(info.TxID, t.TxID) {
+		return nil, fmt.Errorf("%w: expected %s, got %s", ErrTxIDMismatch, info.TxID, t.TxID)
+	}
+	return t, nil
+}
+
+// DecodeString decodes a hex encoded transaction of the coin.
+func DecodeString(coin, s string) (*Tx, error) {
+	raw, err := hex.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return Decode(coin, raw)
+}
+
+// Decode decodes a serialized transaction of the coin, e.g., "btc" or "tbch".
+func Decode(coin string, raw []byte) (*Tx, error) {
+	ci, ok := bitgo.LookupCoin(coin)
+	if !ok {
+		return nil, fmt.Errorf("rawtx: unknown coin %q", coin)
+	}
+	if ci.Family != bitgo.FamilyUTXO {
+		return nil, fmt.Errorf("%w: %s is %s based", bitgo.ErrUnsupportedByCoin, ci.Ticker, ci.Family)
+	}
+
+	d := decoder{r: bytes.NewReader(raw), coin: ci}
+	t, err := d.decode()
+	if err != nil {
+		return nil, err
+	}
+	if d.r.Len() > 0 {
+		return nil, fmt.Errorf("%w: %d bytes after lock time", ErrMalformed, d.r.Len())
+	}
+
+	t.Coin = ci.Ticker
+	t.Size = len(raw)
+	t.Weight = len(raw) + 3*d.baseSize(len(raw))
+	t.VSize = (t.Weight + 3) / 4
+	t.WTxID = hashID(raw)
+	t.TxID = t.WTxID
+	if d.witness {
+		t.TxID = hashID(d.stripWitness(raw))
+	}
+	for i := range t.Outputs {
+		t.Outputs[i].Address, _ = bitgo.ScriptAddress(ci.Ticker, t.Outputs[i].Script)
+	}
+	return t, nil
+}
+
+// OutputValue returns the total value of the outputs.
+func (t *Tx) OutputValue() bitgo.Amount {
+	var v bitgo.Amount
+	for _, out := range t.Outputs {
+		v = v.Add(out.Value)
+	}
+	return v
+}
+
+// InputValue returns the total value of the inputs looked up in the unspents by outpoint.
+// ErrUnknownInput is returned if an input is not among the unspents.
+func (t *Tx) InputValue(unspents []bitgo.Unspent) (bitgo.Amount, error) {
+	values := make(map[bitgo.Outpoint]bitgo.Amount, len(unspents))
+	for _, u := range unspents {
+		if op, err := u.Outpoint(); err == nil {
+			values[op] = u.Value
+		}
+	}
+
+	var v bitgo.Amount
+	for _, in := range t.Inputs {
+		value, ok := values[in.Outpoint]
+		if !ok {
+			return bitgo.Amount{}, fmt.Errorf("%w: %s", ErrUnknownInput, in.Outpoint)
+		}
+		v = v.Add(value)
+	}
+	return v, nil
+}
+
+// Fee returns the transaction fee, i.e., the inputs value minus the outputs value.
+// The inputs are looked up in the unspents, see InputValue.
+func (t *Tx) Fee(unspents []bitgo.Unspent) (bitgo.Amount, error) {
+	in, err := t.InputValue(unspents)
+	if err != nil {
+		return bitgo.Amount{}, err
+	}
+	return in.Sub(t.OutputValue()), nil
+}
+
+// hashID returns the double SHA-256 of the serialized transaction in the reversed byte order.
+func hashID(b []byte) string {
+	h := sha256.Sum256(b)
+	h = sha256.Sum256(h[:])
+	for i, j := 0, len(h)-1; i < j; i, j = i+1, j-1 {
+		h[i], h[j] = h[j], h[i]
+	}
+	return hex.EncodeToString(h[:])
+}
+
+// decoder reads a transaction and remembers where the witness data is
+// to compute the txid and the weight.
+type decoder struct {
+	r    *bytes.Reader
+	coin bitgo.CoinInfo
+	// witness is true when the transaction has segwit marker and flag.
+	witness bool
+	// witnessStart and witnessEnd are offsets of the witnesses in the transaction.
+	witnessStart, witnessEnd int
+}
+
+// decode reads the transaction in the coin's format.
+func (d *decoder) decode() (*Tx, error) {
+	var t Tx
+	header, err := d.uint32()
+	if err != nil {
+		return nil, err
+	}
+	t.Version = int32(header)
+
+	mainnet := d.coin.Ticker
+	if d.coin.Testnet {
+		mainnet = d.coin.Counterpart
+	}
+	switch mainnet {
+	case "zec":
+		return d.decodeZcash(&t, header)
+	case "dash":
+		// The upper two bytes of version 3 transactions are the special transaction type.
+		if t.Version>>16 != 0 && uint16(header) >= 3 {
+			t.Version = int32(uint16(header))
+			t.Type = uint16(header >> 16)
+		}
+	}
+
+	if err = d.decodeInputs(&t); err != nil {
+		return nil, err
+	}
+	if err = d.decodeOutputs(&t); err != nil {
+		return nil, err
+	}
+	if d.witness {
+		d.witnessStart = d.offset()
+		for i := range t.Inputs {
+			if t.Inputs[i].Witness, err = d.witnessStack(); err != nil {
+				return nil, err
+			}
+		}
+		d.witnessEnd = d.offset()
+	}
+	if t.LockTime, err = d.uint32(); err != nil {
+		return nil, err
+	}
+	if t.Type != 0 {
+		// Dash special transaction payload is not decoded.
+		if _, err = d.bytes(); err != nil {
+			return nil, err
+		}
+	}
+	return &t, nil
+}
+
+// decodeZcash reads the rest of a transparent Zcash v4 transaction.
+func (d *decoder) decodeZcash(t *Tx, header uint32) (*Tx, error) {
+	const (
+		overwintered   = 1 << 31
+		saplingGroupID = 0x892f2085
+	)
+	t.Version = int32(header &^ overwintered)
+	if header&overwintered == 0 || t.Version != 4 {
+		return nil, fmt.Errorf("%w: zcash v%d", ErrUnsupportedVersion, t.Version)
+	}
+	groupID, err := d.uint32()
+	if err != nil {
+		return nil, err
+	}
+	if groupID != saplingGroupID {
+		return nil, fmt.Errorf("%w: zcash version group %#x", ErrUnsupportedVersion, groupID)
+	}
+
+	if err = d.decodeInputs(t); err != nil {
+		return nil, err
+	}
+	if err = d.decodeOutputs(t); err != nil {
+		return nil, err
+	}
+	if t.LockTime, err = d.uint32(); err != nil {
+		return nil, err
+	}
+	if t.ExpiryHeight, err = d.uint32(); err != nil {
+		return nil, err
+	}
+	valueBalance, err := d.uint64()
+	if err != nil {
+		return nil, err
+	}
+	// Shielded spends, outputs and joinsplits must be empty.
+	for i := 0; i < 3; i++ {
+		n, err := d.varInt()
+		if err != nil {
+			return nil, err
+		}
+		if n != 0 || valueBalance != 0 {
+			return nil, fmt.Errorf("%w: shielded zcash transaction", ErrUnsupportedVersion)
+		}
+	}
+	return t, nil
+}
+
+// decodeInputs reads the inputs preceded by segwit marker and flag if the coin supports segwit.
+func (d *decoder) decodeInputs(t *Tx) error {
+	n, err := d.varInt()
+	if err != nil {
+		return err
+	}
+	if n == 0 && d.coin.Segwit {
+		flag, err := d.r.ReadByte()
+		if err != nil || flag != 1 {
+			return fmt.Errorf("%w: invalid segwit flag", ErrMalformed)
+		}
+		d.witness = true
+		if n, err = d.varInt(); err != nil {
+			return err
+		}
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: no inputs", ErrMalformed)
+	}
+	// Every input takes at least 41 bytes.
+	if n > uint64(d.r.Len()/41) {
+		return fmt.Errorf("%w: %d inputs", ErrMalformed, n)
+	}
+
+	t.Inputs = make([]Input, n)
+	for i := range t.Inputs {
+		in := &t.Inputs[i]
+		var hash [32]byte
+		if err = d.read(hash[:]); err != nil {
+			return err
+		}
+		for i, j := 0, len(hash)-1; i < j; i, j = i+1, j-1 {
+			hash[i], hash[j] = hash[j], hash[i]
+		}
+		in.Outpoint.TxID = hex.EncodeToString(hash[:])
+		if in.Outpoint.Vout, err = d.uint32(); err != nil {
+			return err
+		}
+		if in.ScriptSig, err = d.bytes(); err != nil {
+			return err
+		}
+		if in.Sequence, err = d.uint32(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeOutputs reads the outputs.
+func (d *decoder) decodeOutputs(t *Tx) error {
+	n, err := d.varInt()
+	if err != nil {
+		return err
+	}
+	// Every output takes at least 9 bytes.
+	if n > uint64(d.r.Len()/9) {
+		return fmt.Errorf("%w: %d outputs", ErrMalformed, n)
+	}
+
+	t.Outputs = make([]Output, n)
+	for i := range t.Outputs {
+		value, err := d.uint64()
+		if err != nil {
+			return err
+		}
+		if int64(value) < 0 {
+			return fmt.Errorf("%w: output %d value", ErrMalformed, i)
+		}
+		t.Outputs[i].Value = bitgo.NewAmount(int64(value))
+		if t.Outputs[i].Script, err = d.bytes(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// witnessStack reads witness items of an input.
+func (d *decoder) witnessStack() ([][]byte, error) {
+	n, err := d.varInt()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(d.r.Len()) {
+		return nil, fmt.Errorf("%w: %d witness items", ErrMalformed, n)
+	}
+	stack := make([][]byte, n)
+	for i := range stack {
+		if stack[i], err = d.bytes(); err != nil {
+			return nil, err
+		}
+	}
+	return stack, nil
+}
+
+// baseSize returns the size of the transaction without segwit marker, flag, and witnesses.
+func (d *decoder) baseSize(size int) int {
+	if !d.witness {
+		return size
+	}
+	return size - 2 - (d.witnessEnd - d.witnessStart)
+}
+
+// stripWitness returns the transaction serialized without segwit marker, flag, and witnesses.
+func (d *decoder) stripWitness(raw []byte) []byte {
+	b := make([]byte, 0, d.baseSize(len(raw)))
+	b = append(b, raw[:4]...)
+	b = append(b, raw[6:d.witnessStart]...)
+	return append(b, raw[d.witnessEnd:]...)
+}
+
+func (d *decoder) offset() int {
+	return int(d.r.Size()) - d.r.Len()
+}
+
+func (d *decoder) uint32() (uint32, error) {
+	var b [4]byte
+	if err := d.read(b[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b[:]), nil
+}
+
+func (d *decoder) uint64() (uint64, error) {
+	var b [8]byte
+	if err := d.read(b[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b[:]), nil
+}
+
+// varInt reads Bitcoin's variable length integer.
+func (d *decoder) varInt() (uint64, error) {
+	prefix, err := d.r.ReadByte()
+	if err != nil {
+		return 0, fmt.Errorf("%w: unexpected end", ErrMalformed)
+	}
+	var b [8]byte
+	var size int
+	switch prefix {
+	case 0xfd:
+		size = 2
+	case 0xfe:
+		size = 4
+	case 0xff:
+		size = 8
+	default:
+		return uint64(prefix), nil
+	}
+	if err = d.read(b[:size]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b[:]), nil
+}
+
+// bytes reads a byte slice prefixed with its length.
+func (d *decoder) bytes() ([]byte, error) {
+	n, err := d.varInt()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(d.r.Len()) {
+		return nil, fmt.Errorf("%w: unexpected end", ErrMalformed)
+	}
+	b := make([]byte, n)
+	return b, d.read(b)
+}
+
+// read fills b reporting a short read as ErrMalformed.
+func (d *decoder) read(b []byte) error {
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		return fmt.Errorf("%w: unexpected end", ErrMalformed)
+	}
+	return nil
+}
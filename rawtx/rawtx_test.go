@@ -0,0 +1,200 @@
+package rawtx_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/marselester/bitgo-v2"
+	"github.com/marselester/bitgo-v2/rawtx"
+)
+
+// genesisTx is the coinbase transaction of the Bitcoin genesis block.
+const (
+	genesisTx   = "01000000010000000000000000000000000000000000000000000000000000000000000000ffffffff4d04ffff001d0104455468652054696d65732030332f4a616e2f32303039204368616e63656c6c6f72206f6e206272696e6b206f66207365636f6e64206261696c6f757420666f722062616e6b73ffffffff0100f2052a01000000434104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac00000000"
+	genesisTxID = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+)
+
+// Parts of a transaction spending two outputs to a P2SH and a P2WSH address.
+const (
+	version = "01000000"
+	inputs  = "02" +
+		"1111111111111111111111111111111111111111111111111111111111111111" + "00000000" + "00" + "ffffffff" +
+		"2222222222222222222222222222222222222222222222222222222222222222" + "05000000" + "00" + "fdffffff"
+	outputs = "02" +
+		"a086010000000000" + "17" + "a914" + "76a04053bda0a88bda5177b86a15c3b29f559873" + "87" +
+		"50c3000000000000" + "22" + "0020" + "1863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262"
+	witness  = "02" + "0101" + "02aabb" + "00"
+	lockTime = "00000000"
+)
+
+func TestDecodeLegacy(t *testing.T) {
+	tx, err := rawtx.DecodeTxInfo("btc", &bitgo.TxInfo{TxID: genesisTxID, Tx: genesisTx})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.TxID != genesisTxID || tx.WTxID != genesisTxID {
+		t.Errorf("unexpected txid %s, wtxid %s", tx.TxID, tx.WTxID)
+	}
+	if tx.Size != 204 || tx.Weight != 816 || tx.VSize != 204 {
+		t.Errorf("unexpected size %d, weight %d, vsize %d", tx.Size, tx.Weight, tx.VSize)
+	}
+	if len(tx.Inputs) != 1 || tx.Inputs[0].Outpoint.Vout != 0xffffffff || len(tx.Inputs[0].ScriptSig) != 77 {
+		t.Errorf("unexpected inputs %+v", tx.Inputs)
+	}
+	// Pay-to-pubkey output has no address.
+	if len(tx.Outputs) != 1 || tx.Outputs[0].Value.Cmp(bitgo.NewAmount(5000000000)) != 0 || tx.Outputs[0].Address != nil {
+		t.Errorf("unexpected outputs %+v", tx.Outputs)
+	}
+
+	_, err = rawtx.DecodeTxInfo("btc", &bitgo.TxInfo{TxID: strings.Repeat("0", 64), Tx: genesisTx})
+	if !errors.Is(err, rawtx.ErrTxIDMismatch) {
+		t.Errorf("expected txid mismatch, got %v", err)
+	}
+}
+
+func TestDecodeSegwit(t *testing.T) {
+	legacy, err := rawtx.DecodeString("tbtc", version+inputs+outputs+lockTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	segwit, err := rawtx.DecodeString("tbtc", version+"0001"+inputs+outputs+witness+lockTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if segwit.TxID != legacy.TxID || segwit.WTxID == legacy.WTxID {
+		t.Errorf("expected txid %s and another wtxid, got %s and %s", legacy.TxID, segwit.TxID, segwit.WTxID)
+	}
+	// The marker, flag and witness take 2+7 bytes which weigh a quarter of the base bytes.
+	if segwit.Size != legacy.Size+9 || segwit.Weight != legacy.Weight+9 || segwit.VSize != legacy.VSize+3 {
+		t.Errorf("unexpected size %d, weight %d, vsize %d", segwit.Size, segwit.Weight, segwit.VSize)
+	}
+	in := segwit.Inputs[1]
+	if in.Outpoint.String() != strings.Repeat("22", 32)+":5" || in.Sequence != 0xfffffffd || len(in.Witness) != 0 {
+		t.Errorf("unexpected input %+v", in)
+	}
+	if w := segwit.Inputs[0].Witness; len(w) != 2 || hex.EncodeToString(w[1]) != "aabb" {
+		t.Errorf("unexpected witness %x", w)
+	}
+
+	want := []struct {
+		typ  bitgo.AddressType
+		hash string
+	}{
+		{bitgo.AddressP2SH, "76a04053bda0a88bda5177b86a15c3b29f559873"},
+		{bitgo.AddressP2WSH, "1863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262"},
+	}
+	for i, out := range segwit.Outputs {
+		if out.Address == nil || out.Address.Type != want[i].typ || hex.EncodeToString(out.Address.Hash) != want[i].hash {
+			t.Errorf("output %d: expected %s %s, got %+v", i, want[i].typ, want[i].hash, out.Address)
+		}
+	}
+	if a := segwit.Outputs[1].Address.String(); !strings.HasPrefix(a, "tb1q") {
+		t.Errorf("expected testnet bech32 address, got %s", a)
+	}
+	if v := segwit.OutputValue(); v.Cmp(bitgo.NewAmount(150000)) != 0 {
+		t.Errorf("unexpected output value %s", v)
+	}
+
+	unspents := []bitgo.Unspent{
+		{ID: strings.Repeat("11", 32) + ":0", Value: bitgo.NewAmount(100000)},
+		{ID: strings.Repeat("22", 32) + ":5", Value: bitgo.NewAmount(51000)},
+	}
+	fee, err := segwit.Fee(unspents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fee.Cmp(bitgo.NewAmount(1000)) != 0 {
+		t.Errorf("expected 1000 fee, got %s", fee)
+	}
+	if _, err = segwit.Fee(unspents[:1]); !errors.Is(err, rawtx.ErrUnknownInput) {
+		t.Errorf("expected unknown input, got %v", err)
+	}
+
+	// BCH has no segwit, so the marker is read as zero inputs.
+	if _, err = rawtx.DecodeString("tbch", version+"0001"+inputs+outputs+witness+lockTime); !errors.Is(err, rawtx.ErrMalformed) {
+		t.Errorf("expected malformed bch transaction, got %v", err)
+	}
+	bch, err := rawtx.DecodeString("tbch", version+inputs+outputs+lockTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := bch.Outputs[0].Address; a == nil || a.Format != bitgo.AddressCashAddr || !strings.HasPrefix(a.String(), "bchtest:p") || bch.Outputs[1].Address != nil {
+		t.Errorf("unexpected bch outputs %v %v", bch.Outputs[0].Address, bch.Outputs[1].Address)
+	}
+}
+
+func TestDecodeCoinFormats(t *testing.T) {
+	// Dash provider registration (type 1) transaction with a payload.
+	dash, err := rawtx.DecodeString("dash", "03000100"+inputs+outputs+lockTime+"02abcd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dash.Version != 3 || dash.Type != 1 {
+		t.Errorf("unexpected dash version %d, type %d", dash.Version, dash.Type)
+	}
+
+	// Transparent Zcash Sapling transaction.
+	zec, err := rawtx.DecodeString("zec", "04000080"+"85202f89"+inputs+outputs+lockTime+"10270000"+"0000000000000000"+"000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if zec.Version != 4 || zec.ExpiryHeight != 10000 || len(zec.Inputs) != 2 || zec.TxID != zec.WTxID {
+		t.Errorf("unexpected zcash transaction %+v", zec)
+	}
+	_, err = rawtx.DecodeString("zec", "05000080"+"0a27a726"+inputs+outputs+lockTime)
+	if !errors.Is(err, rawtx.ErrUnsupportedVersion) {
+		t.Errorf("expected unsupported zcash v5, got %v", err)
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	tests := []string{
+		"",
+		"zz",
+		version,
+		version + inputs,
+		version + inputs + outputs,
+		version + inputs + outputs + lockTime + "00",
+		version + "0002" + inputs + outputs + witness + lockTime,
+		version + "fdffff" + inputs,
+	}
+	for _, tx := range tests {
+		if _, err := rawtx.DecodeString("btc", tx); !errors.Is(err, rawtx.ErrMalformed) {
+			t.Errorf("%q: expected malformed transaction, got %v", tx, err)
+		}
+	}
+	if _, err := rawtx.DecodeString("eth", genesisTx); !errors.Is(err, bitgo.ErrUnsupportedByCoin) {
+		t.Errorf("expected unsupported coin, got %v", err)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := map[string]string{
+		"btc":  genesisTx,
+		"tbtc": version + "0001" + inputs + outputs + witness + lockTime,
+		"tbch": version + inputs + outputs + lockTime,
+	}
+	for coin, raw := range tests {
+		tx, err := rawtx.DecodeString(coin, raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := tx.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(b); got != raw {
+			t.Errorf("%s: expected %s, got %s", coin, raw, got)
+		}
+	}
+
+	zec, err := rawtx.DecodeString("zec", "04000080"+"85202f89"+inputs+outputs+lockTime+"10270000"+"0000000000000000"+"000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = zec.Encode(); !errors.Is(err, rawtx.ErrUnsupportedVersion) {
+		t.Errorf("expected unsupported zcash encoding, got %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package bitgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces secrets in logs and recorded cassettes.
+const Redacted = "[REDACTED]"
+
+// SensitiveFields are names of JSON body fields and URL query params that are always redacted.
+// Field names are case-insensitive.
+var SensitiveFields = []string{
+	"walletPassphrase",
+	"passphrase",
+	"password",
+	"prv",
+	"xprv",
+	"encryptedPrv",
+	"otp",
+	"access_token",
+	"token",
+}
+
+// SensitiveHeaders are names of HTTP headers that are always redacted.
+var SensitiveHeaders = []string{
+	"Authorization",
+	"Hmac",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// WithRedactedFields masks extra JSON fields and query params in logged requests and responses,
+// in addition to SensitiveFields which are always masked.
+// Field names are case-insensitive.
+func WithRedactedFields(names ...string) ConfigOption {
+	return func(c *Config) {
+		c.redactedFields = append(c.redactedFields, names...)
+	}
+}
+
+// isSensitive reports whether the field is in SensitiveFields or the extra fields.
+func isSensitive(name string, extra []string) bool {
+	for _, list := range [][]string{SensitiveFields, extra} {
+		for _, s := range list {
+			if strings.EqualFold(name, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RedactHeader returns a copy of the header with SensitiveHeaders values masked.
+func RedactHeader(h http.Header) http.Header {
+	h = h.Clone()
+	for _, name := range SensitiveHeaders {
+		if _, ok := h[http.CanonicalHeaderKey(name)]; ok {
+			h.Set(name, Redacted)
+		}
+	}
+	return h
+}
+
+// RedactURL returns the URL with values of SensitiveFields and extra query params masked.
+func RedactURL(u *url.URL, extra ...string) string {
+	if u.RawQuery == "" {
+		return u.String()
+	}
+	r := *u
+	q := r.Query()
+	for k := range q {
+		if isSensitive(k, extra) {
+			q.Set(k, Redacted)
+		}
+	}
+	r.RawQuery = q.Encode()
+	return r.String()
+}
+
+// RedactJSON returns a copy of JSON body with values of SensitiveFields and extra fields masked.
+// Bodies which can't be decoded (e.g., truncated ones) are masked field by field,
+// bodies without sensitive fields are returned as is.
+func RedactJSON(b []byte, extra ...string) []byte {
+	if len(b) == 0 {
+		return b
+	}
+
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return redactRawJSON(b, extra)
+	}
+	if !redactValue(v, extra) {
+		return b
+	}
+	r, err := json.Marshal(v)
+	if err != nil {
+		return redactRawJSON(b, extra)
+	}
+	return r
+}
+
+// logBody returns a body to be logged with sensitive fields masked.
+// It decodes the body, so it's called only when debug logs are enabled, see debugEnabled.
+func (c *Config) logBody(b []byte) []byte {
+	return RedactJSON(b, c.redactedFields...)
+}
+
+// logURL returns a request URL to be logged with sensitive query params masked.
+func (c *Config) logURL(urlStr string) string {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return urlStr
+	}
+	return RedactURL(u, c.redactedFields...)
+}
+
+// redactValue walks decoded JSON and masks sensitive fields in place.
+// It reports whether anything was masked.
+func redactValue(v interface{}, extra []string) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, field := range v {
+			if isSensitive(k, extra) {
+				v[k] = Redacted
+				changed = true
+			} else if redactValue(field, extra) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if redactValue(item, extra) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// rawFieldRe matches "key": "string value" pairs in JSON text.
+var rawFieldRe = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"(\s*:\s*)"(?:[^"\\]|\\.)*("?)`)
+
+// redactRawJSON masks string values of sensitive fields in JSON text which can't be decoded.
+func redactRawJSON(b []byte, extra []string) []byte {
+	return rawFieldRe.ReplaceAllFunc(b, func(m []byte) []byte {
+		sub := rawFieldRe.FindSubmatch(m)
+		if !isSensitive(string(sub[1]), extra) {
+			return m
+		}
+		return []byte(`"` + string(sub[1]) + `"` + string(sub[2]) + `"` + Redacted + `"`)
+	})
+}
@@ -0,0 +1,94 @@
+package bitgo_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/marselester/bitgo-v2"
+)
+
+func TestLogRedaction(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"txid":"5885a7e6","tx":"0100","status":"signed","prv":"xprv9s21ZrQH143K"}`))
+	}))
+	defer srv.Close()
+
+	var logs bytes.Buffer
+	logger := bitgo.LoggerFunc(func(keyvals ...interface{}) error {
+		fmt.Fprintf(&logs, "%q\n", keyvals)
+		return nil
+	})
+	c := bitgo.NewClient(
+		bitgo.WithBaseURL(srv.URL),
+		bitgo.WithProductionSpending(),
+		bitgo.WithAccesToken("swordfish"),
+		bitgo.WithLogger(logger),
+		bitgo.WithRedactedFields("memo"),
+	)
+	_, err := c.Wallet.Consolidate(context.Background(), "", &bitgo.WalletConsolidateParams{
+		WalletPassphrase: "root",
+		FeeRate:          5000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.NewRequest(context.Background(), http.MethodGet, "wallet", url.Values{"otp": {"0000000"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, secret := range []string{"swordfish", "root", "xprv9s21ZrQH143K", "0000000"} {
+		if bytes.Contains(logs.Bytes(), []byte(secret)) {
+			t.Errorf("logs contain secret %q:\n%s", secret, logs.String())
+		}
+	}
+	for _, s := range []string{"feeRate", "5885a7e6", "[REDACTED]"} {
+		if !bytes.Contains(logs.Bytes(), []byte(s)) {
+			t.Errorf("logs don't contain %q:\n%s", s, logs.String())
+		}
+	}
+}
+
+func TestRedactJSON(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"walletPassphrase":"root","feeRate":5000}`, `{"feeRate":5000,"walletPassphrase":"[REDACTED]"}`},
+		{`{"recipients":[{"address":"2N","memo":"salary"}]}`, `{"recipients":[{"address":"2N","memo":"[REDACTED]"}]}`},
+		{`{"access_token":"swordfish"}`, `{"access_token":"[REDACTED]"}`},
+		{`{"txid":"5885a7e6"}`, `{"txid":"5885a7e6"}`},
+		// Truncated bodies are masked field by field.
+		{`{"Passphrase": "root", "otp":"0000`, `{"Passphrase": "[REDACTED]", "otp":"[REDACTED]"`},
+		{`not json`, `not json`},
+	}
+	for _, test := range tests {
+		if got := string(bitgo.RedactJSON([]byte(test.body), "memo")); got != test.want {
+			t.Errorf("RedactJSON(%s) = %s, want %s", test.body, got, test.want)
+		}
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://test.bitgo.com/api/v2/tbtc/wallet?otp=0000000&limit=10", "https://test.bitgo.com/api/v2/tbtc/wallet?limit=10&otp=%5BREDACTED%5D"},
+		{"https://test.bitgo.com/api/v2/tbtc/wallet?Token=swordfish&memo=salary", "https://test.bitgo.com/api/v2/tbtc/wallet?Token=%5BREDACTED%5D&memo=%5BREDACTED%5D"},
+		{"https://test.bitgo.com/api/v2/tbtc/wallet?limit=10", "https://test.bitgo.com/api/v2/tbtc/wallet?limit=10"},
+	}
+	for _, test := range tests {
+		u, err := url.Parse(test.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := bitgo.RedactURL(u, "memo"); got != test.want {
+			t.Errorf("RedactURL(%s) = %s, want %s", test.url, got, test.want)
+		}
+	}
+}
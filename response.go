@@ -0,0 +1,94 @@
+package bitgo
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ResponseMeta is metadata of an API response which service methods such as Consolidate don't return.
+// Use WithResponseMeta context to collect it.
+type ResponseMeta struct {
+	// RequestID is BitGo's request ID to be mentioned in support tickets.
+	RequestID  string
+	StatusCode int
+	// RateLimited is true if the response had rate-limit headers,
+	// otherwise RateLimitLimit, RateLimitRemaining and RateLimitReset are zero.
+	RateLimited bool
+	// RateLimitLimit is how many requests are allowed in the current window.
+	RateLimitLimit int
+	// RateLimitRemaining is how many requests are left in the current window.
+	RateLimitRemaining int
+	// RateLimitReset is when the current rate-limit window resets.
+	RateLimitReset time.Time
+	// Date is the server's date of the response.
+	Date time.Time
+	// Latency is time passed since the request was sent till the response body was read.
+	Latency time.Duration
+}
+
+// responseMetaKey is a context key of a ResponseMeta.
+type responseMetaKey struct{}
+
+// WithResponseMeta returns a new context based on the provided parent ctx.
+// When an API call made with the returned context receives a response,
+// its metadata is stored in meta. In case of pagination meta holds the last page's metadata.
+//
+//	meta := bitgo.ResponseMeta{}
+//	tx, err := c.Wallet.Consolidate(bitgo.WithResponseMeta(ctx, &meta), walletID, params)
+//	log.Printf("request %s, %d requests left", meta.RequestID, meta.RateLimitRemaining)
+func WithResponseMeta(ctx context.Context, meta *ResponseMeta) context.Context {
+	return context.WithValue(ctx, responseMetaKey{}, meta)
+}
+
+// collectResponseMeta stores metadata of the response if the request's context has a collector.
+func collectResponseMeta(req *http.Request, resp *http.Response, latency time.Duration) {
+	meta, ok := req.Context().Value(responseMetaKey{}).(*ResponseMeta)
+	if !ok || meta == nil {
+		return
+	}
+	*meta = newResponseMeta(resp, latency)
+}
+
+// Rate-limit headers.
+const (
+	headerRateLimitLimit     = "X-RateLimit-Limit"
+	headerRateLimitRemaining = "X-RateLimit-Remaining"
+	headerRateLimitReset     = "X-RateLimit-Reset"
+)
+
+// newResponseMeta returns metadata of the response.
+func newResponseMeta(resp *http.Response, latency time.Duration) ResponseMeta {
+	meta := ResponseMeta{
+		RequestID:  headerRequestID(resp.Header),
+		StatusCode: resp.StatusCode,
+		Latency:    latency,
+	}
+	if d, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		meta.Date = d
+	}
+
+	remaining, err := strconv.Atoi(resp.Header.Get(headerRateLimitRemaining))
+	if err != nil {
+		return meta
+	}
+	meta.RateLimited = true
+	meta.RateLimitRemaining = remaining
+	meta.RateLimitLimit, _ = strconv.Atoi(resp.Header.Get(headerRateLimitLimit))
+
+	// Reset is either Unix time or a number of seconds till the window resets.
+	if reset, err := strconv.ParseInt(resp.Header.Get(headerRateLimitReset), 10, 64); err == nil {
+		const unixTimeThreshold = 1000000000
+		if reset >= unixTimeThreshold {
+			meta.RateLimitReset = time.Unix(reset, 0)
+		} else {
+			now := meta.Date
+			if now.IsZero() {
+				now = time.Now()
+			}
+			meta.RateLimitReset = now.Add(time.Duration(reset) * time.Second)
+		}
+	}
+	return meta
+}
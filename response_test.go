@@ -0,0 +1,44 @@
+package bitgo_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/marselester/bitgo-v2"
+)
+
+func TestResponseMeta(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Request-Id", "bj9h0dap1723kadrsnfkvsinz")
+		w.Header().Set("Date", "Tue, 15 Nov 1994 08:12:31 GMT")
+		w.Header().Set("X-RateLimit-Limit", "360")
+		w.Header().Set("X-RateLimit-Remaining", "359")
+		w.Header().Set("X-RateLimit-Reset", "60")
+		w.Write([]byte(`{"txid":"5885a7e6"}`))
+	}))
+	defer srv.Close()
+
+	c := bitgo.NewClient(
+		bitgo.WithBaseURL(srv.URL),
+		bitgo.WithProductionSpending(),
+	)
+	meta := bitgo.ResponseMeta{}
+	ctx := bitgo.WithResponseMeta(context.Background(), &meta)
+	if _, err := c.Wallet.Consolidate(ctx, "", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	date := time.Date(1994, 11, 15, 8, 12, 31, 0, time.UTC)
+	if meta.RequestID != "bj9h0dap1723kadrsnfkvsinz" || meta.StatusCode != http.StatusOK || !meta.Date.Equal(date) {
+		t.Errorf("unexpected response meta %#v", meta)
+	}
+	if !meta.RateLimited || meta.RateLimitLimit != 360 || meta.RateLimitRemaining != 359 || !meta.RateLimitReset.Equal(date.Add(time.Minute)) {
+		t.Errorf("unexpected rate limit %#v", meta)
+	}
+	if meta.Latency <= 0 {
+		t.Errorf("expected latency, got %v", meta.Latency)
+	}
+}
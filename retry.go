@@ -0,0 +1,117 @@
+package bitgo
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxRetryBackoff caps the doubling backoff, so many retries don't wait for hours or overflow.
+const maxRetryBackoff = time.Minute
+
+// WithRetry configures Client to retry idempotent (GET) requests
+// which failed due to network errors, throttling or temporary API errors.
+// The backoff is a wait before the first retry, it doubles with every attempt up to a minute.
+// The server's Retry-After header of a throttled or unavailable response takes precedence over the backoff.
+// By default requests are not retried.
+func WithRetry(maxRetries int, backoff time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.maxRetries = maxRetries
+		c.retryBackoff = backoff
+	}
+}
+
+// retry makes request attempts until one succeeds or the request shouldn't be retried anymore.
+// The RetryScheduled hook is called before every retry.
+func (c *Client) retry(req *http.Request, trace *ClientTrace, info RequestInfo, do func(attempt int) (*http.Response, error)) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := do(attempt)
+		wait, ok := c.retryWait(req, attempt, err)
+		if !ok {
+			return resp, err
+		}
+
+		c.config.log(req.Context(), LevelWarn, "retrying request", "attempt", attempt, "wait", wait, "err", err)
+		if trace.RetryScheduled != nil {
+			trace.RetryScheduled(RetryScheduledInfo{
+				Request: info,
+				Attempt: attempt,
+				Wait:    wait,
+				Err:     err,
+			})
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			t.Stop()
+			return resp, err
+		case <-t.C:
+		}
+
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return resp, err
+			}
+			req.Body = body
+		}
+	}
+}
+
+// retryWait reports whether a failed request attempt should be retried and how long to wait.
+// Only idempotent requests are retried when they failed due to network errors,
+// throttling or temporary API errors.
+func (c *Client) retryWait(req *http.Request, attempt int, err error) (time.Duration, bool) {
+	if err == nil || attempt > c.config.maxRetries || req.Context().Err() != nil {
+		return 0, false
+	}
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return 0, false
+	}
+
+	switch e := err.(type) {
+	case Error:
+		if !e.IsTemporary() && !e.IsRateLimited() {
+			return 0, false
+		}
+		if e.RetryAfter > 0 {
+			return e.RetryAfter, true
+		}
+	case *RequestError:
+		if e.Op != OpSend {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+
+	wait := c.config.retryBackoff
+	for i := 1; i < attempt && wait < maxRetryBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxRetryBackoff {
+		wait = maxRetryBackoff
+	}
+	return wait, true
+}
+
+// parseRetryAfter returns the wait of Retry-After header which is either seconds or an HTTP date,
+// or zero if the header is not set or invalid.
+func parseRetryAfter(h string, now time.Time) time.Duration {
+	if h == "" {
+		return 0
+	}
+	if sec, err := strconv.Atoi(h); err == nil {
+		if sec < 0 || time.Duration(sec) > math.MaxInt64/time.Second {
+			return 0
+		}
+		return time.Duration(sec) * time.Second
+	}
+	t, err := http.ParseTime(h)
+	if err != nil || !t.After(now) {
+		return 0
+	}
+	return t.Sub(now)
+}
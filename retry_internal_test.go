@@ -0,0 +1,45 @@
+package bitgo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryWaitCap(t *testing.T) {
+	c := NewClient(WithRetry(100, time.Second))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	prev := time.Duration(0)
+	for attempt := 1; attempt <= 100; attempt++ {
+		wait, ok := c.retryWait(req, attempt, Error{Type: ErrorTypeAPI})
+		if !ok {
+			t.Fatalf("attempt %d: expected a retry", attempt)
+		}
+		if wait < prev || wait > time.Minute {
+			t.Fatalf("attempt %d: unexpected wait %s after %s", attempt, wait, prev)
+		}
+		prev = wait
+	}
+	if prev != time.Minute {
+		t.Errorf("expected the backoff to be capped at a minute, got %s", prev)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"":                              0,
+		"7":                             7 * time.Second,
+		"-1":                            0,
+		"99999999999999999":             0,
+		"soon":                          0,
+		"Sun, 18 Oct 2026 12:00:30 GMT": 30 * time.Second,
+		"Sun, 18 Oct 2026 11:00:00 GMT": 0,
+	}
+	for h, want := range tests {
+		if got := parseRetryAfter(h, now); got != want {
+			t.Errorf("%q: expected %s, got %s", h, want, got)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package bitgo_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/marselester/bitgo-v2"
+)
+
+func TestRetryNotIdempotent(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		http.Error(w, "server is overloaded", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := bitgo.NewClient(
+		bitgo.WithBaseURL(srv.URL),
+		bitgo.WithProductionSpending(),
+		bitgo.WithRetry(2, 0),
+	)
+	if _, err := c.Wallet.Consolidate(context.Background(), "", nil); err == nil {
+		t.Fatal("expected an error")
+	}
+	if attempts != 1 {
+		t.Errorf("consolidation must not be retried, got %d attempts", attempts)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		http.Error(w, `{"error":"too many requests"}`, http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	c := bitgo.NewClient(
+		bitgo.WithBaseURL(srv.URL),
+		bitgo.WithRetry(2, time.Millisecond),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wait time.Duration
+	ctx = bitgo.WithClientTrace(ctx, &bitgo.ClientTrace{
+		RetryScheduled: func(info bitgo.RetryScheduledInfo) {
+			wait = info.Wait
+			// The retry is not needed, only its wait.
+			cancel()
+		},
+	})
+
+	err := c.Wallet.Unspents(ctx, "585951a5df8380e0e3063e9f", nil, func(*bitgo.UnspentList) {})
+	if !errors.Is(err, bitgo.ErrRateLimited) {
+		t.Fatalf("expected rate limited error, got %v", err)
+	}
+	if wait != 7*time.Second {
+		t.Errorf("expected Retry-After wait 7s, got %s", wait)
+	}
+}
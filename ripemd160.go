@@ -0,0 +1,99 @@
+package bitgo
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/bits"
+)
+
+// RIPEMD-160 message word selection, rotation amounts and constants of the left and right lines.
+var (
+	ripemdR = [80]uint8{
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+		7, 4, 13, 1, 10, 6, 15, 3, 12, 0, 9, 5, 2, 14, 11, 8,
+		3, 10, 14, 4, 9, 15, 8, 1, 2, 7, 0, 6, 13, 11, 5, 12,
+		1, 9, 11, 10, 0, 8, 12, 4, 13, 3, 7, 15, 14, 5, 6, 2,
+		4, 0, 5, 9, 7, 12, 2, 10, 14, 1, 3, 8, 11, 6, 15, 13,
+	}
+	ripemdRR = [80]uint8{
+		5, 14, 7, 0, 9, 2, 11, 4, 13, 6, 15, 8, 1, 10, 3, 12,
+		6, 11, 3, 7, 0, 13, 5, 10, 14, 15, 8, 12, 4, 9, 1, 2,
+		15, 5, 1, 3, 7, 14, 6, 9, 11, 8, 12, 2, 10, 0, 4, 13,
+		8, 6, 4, 1, 3, 11, 15, 0, 5, 12, 2, 13, 9, 7, 10, 14,
+		12, 15, 10, 4, 1, 5, 8, 7, 6, 2, 13, 14, 0, 3, 9, 11,
+	}
+	ripemdS = [80]uint8{
+		11, 14, 15, 12, 5, 8, 7, 9, 11, 13, 14, 15, 6, 7, 9, 8,
+		7, 6, 8, 13, 11, 9, 7, 15, 7, 12, 15, 9, 11, 7, 13, 12,
+		11, 13, 6, 7, 14, 9, 13, 15, 14, 8, 13, 6, 5, 12, 7, 5,
+		11, 12, 14, 15, 14, 15, 9, 8, 9, 14, 5, 6, 8, 6, 5, 12,
+		9, 15, 5, 11, 6, 8, 13, 12, 5, 12, 13, 14, 11, 8, 5, 6,
+	}
+	ripemdSR = [80]uint8{
+		8, 9, 9, 11, 13, 15, 15, 5, 7, 7, 8, 11, 14, 14, 12, 6,
+		9, 13, 15, 7, 12, 8, 9, 11, 7, 7, 12, 7, 6, 15, 13, 11,
+		9, 7, 15, 11, 8, 6, 6, 14, 12, 13, 5, 14, 13, 13, 7, 5,
+		15, 5, 8, 11, 14, 14, 6, 14, 6, 9, 12, 9, 12, 5, 15, 8,
+		8, 5, 12, 9, 12, 5, 14, 6, 8, 13, 6, 5, 15, 13, 11, 11,
+	}
+	ripemdK  = [5]uint32{0x00000000, 0x5a827999, 0x6ed9eba1, 0x8f1bbcdc, 0xa953fd4e}
+	ripemdKR = [5]uint32{0x50a28be6, 0x5c4dd124, 0x6d703ef3, 0x7a6d76e9, 0x00000000}
+)
+
+// Hash160 returns RIPEMD160(SHA256(b)) which is used in P2PKH and P2SH addresses and key fingerprints.
+func Hash160(b []byte) []byte {
+	h := sha256.Sum256(b)
+	return ripemd160(h[:])
+}
+
+// ripemd160 returns the RIPEMD-160 digest of the message.
+// The standard library doesn't provide it and the package has no dependencies.
+func ripemd160(msg []byte) []byte {
+	// The message is padded with 0x80, zeros and its bit length to a multiple of 64 bytes.
+	n := len(msg)
+	padded := make([]byte, (n+8)/64*64+64)
+	copy(padded, msg)
+	padded[n] = 0x80
+	binary.LittleEndian.PutUint64(padded[len(padded)-8:], uint64(n)*8)
+
+	h := [5]uint32{0x67452301, 0xefcdab89, 0x98badcfe, 0x10325476, 0xc3d2e1f0}
+	var x [16]uint32
+	for block := padded; len(block) > 0; block = block[64:] {
+		for i := range x {
+			x[i] = binary.LittleEndian.Uint32(block[i*4:])
+		}
+
+		a, b, c, d, e := h[0], h[1], h[2], h[3], h[4]
+		ar, br, cr, dr, er := a, b, c, d, e
+		for j := 0; j < 80; j++ {
+			t := bits.RotateLeft32(a+ripemdF(j, b, c, d)+x[ripemdR[j]]+ripemdK[j/16], int(ripemdS[j])) + e
+			a, e, d, c, b = e, d, bits.RotateLeft32(c, 10), b, t
+
+			t = bits.RotateLeft32(ar+ripemdF(79-j, br, cr, dr)+x[ripemdRR[j]]+ripemdKR[j/16], int(ripemdSR[j])) + er
+			ar, er, dr, cr, br = er, dr, bits.RotateLeft32(cr, 10), br, t
+		}
+		h[0], h[1], h[2], h[3], h[4] = h[1]+c+dr, h[2]+d+er, h[3]+e+ar, h[4]+a+br, h[0]+b+cr
+	}
+
+	sum := make([]byte, 20)
+	for i, v := range h {
+		binary.LittleEndian.PutUint32(sum[i*4:], v)
+	}
+	return sum
+}
+
+// ripemdF is the nonlinear function of the j-th RIPEMD-160 step.
+func ripemdF(j int, x, y, z uint32) uint32 {
+	switch j / 16 {
+	case 0:
+		return x ^ y ^ z
+	case 1:
+		return x&y | ^x&z
+	case 2:
+		return (x | ^y) ^ z
+	case 3:
+		return x&z | y&^z
+	default:
+		return x ^ (y | ^z)
+	}
+}
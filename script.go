@@ -0,0 +1,158 @@
+package bitgo
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNonstandardScript is returned when an output script doesn't pay to an address, e.g., OP_RETURN data.
+	ErrNonstandardScript = errors.New("bitgo: script doesn't pay to an address")
+	// ErrUnsupportedChain is returned when the multisig scripts of a chain can't be derived,
+	// e.g., taproot or unknown chains.
+	ErrUnsupportedChain = errors.New("bitgo: unsupported chain")
+)
+
+// Script opcodes of standard output scripts.
+const (
+	op0             = 0x00
+	opDup           = 0x76
+	opHash160       = 0xa9
+	opEqual         = 0x87
+	opEqualVerify   = 0x88
+	opCheckSig      = 0xac
+	opCheckMultisig = 0xae
+	op1             = 0x51
+	op16            = 0x60
+)
+
+// ScriptAddress decodes a standard output script (scriptPubKey) of the coin into an address,
+// e.g., OP_HASH160 <20-byte hash> OP_EQUAL into a P2SH address.
+// BCH addresses are in cashaddr format, segwit ones are bech32 or bech32m, the rest are base58check.
+// ErrNonstandardScript is returned for scripts that don't pay to an address.
+func ScriptAddress(coin string, script []byte) (*Address, error) {
+	n, ok := addressNetworks[coin]
+	if !ok || n.account != nil || n.hex {
+		return nil, fmt.Errorf("%w: %s script decoding", ErrUnsupportedByCoin, coin)
+	}
+
+	a := Address{Coin: coin, Format: AddressBase58Check}
+	if n.cashAddrPrefix != "" {
+		a.Format = AddressCashAddr
+	}
+	switch {
+	case len(script) == 25 && script[0] == opDup && script[1] == opHash160 && script[2] == 20 &&
+		script[23] == opEqualVerify && script[24] == opCheckSig:
+		a.Type = AddressP2PKH
+		a.Hash = script[3:23]
+	case len(script) == 23 && script[0] == opHash160 && script[1] == 20 && script[22] == opEqual:
+		a.Type = AddressP2SH
+		a.Hash = script[2:22]
+	case n.hrp != "" && len(script) >= 4 && len(script) <= 42 && int(script[1]) == len(script)-2 &&
+		(script[0] == 0 || script[0] >= op1 && script[0] <= op16):
+		a.Format = AddressBech32
+		a.Hash = script[2:]
+		a.Type = AddressWitnessUnknown
+		if script[0] != 0 {
+			a.Format = AddressBech32m
+			a.WitnessVersion = int(script[0]) - op1 + 1
+		}
+		switch {
+		case a.WitnessVersion == 0 && len(a.Hash) == 20:
+			a.Type = AddressP2WPKH
+		case a.WitnessVersion == 0 && len(a.Hash) == 32:
+			a.Type = AddressP2WSH
+		case a.WitnessVersion == 0:
+			return nil, ErrNonstandardScript
+		case a.WitnessVersion == 1 && len(a.Hash) == 32:
+			a.Type = AddressP2TR
+		}
+	default:
+		return nil, ErrNonstandardScript
+	}
+	a.Hash = append([]byte(nil), a.Hash...)
+	return &a, nil
+}
+
+// Script returns the output script (scriptPubKey) which pays to the address.
+func (a *Address) Script() ([]byte, error) {
+	switch a.Type {
+	case AddressP2PKH:
+		s := append([]byte{opDup, opHash160, 20}, a.Hash...)
+		return append(s, opEqualVerify, opCheckSig), nil
+	case AddressP2SH:
+		s := append([]byte{opHash160, 20}, a.Hash...)
+		return append(s, opEqual), nil
+	case AddressP2WPKH, AddressP2WSH, AddressP2TR, AddressWitnessUnknown:
+		version := byte(0)
+		if a.WitnessVersion > 0 {
+			version = byte(op1 + a.WitnessVersion - 1)
+		}
+		return append([]byte{version, byte(len(a.Hash))}, a.Hash...), nil
+	}
+	return nil, fmt.Errorf("%w: %s address has no output script", ErrUnsupportedByCoin, a.Type)
+}
+
+// P2SHScript returns the output script which pays to the hash of the redeem script.
+func P2SHScript(redeem []byte) []byte {
+	s := append([]byte{opHash160, 20}, Hash160(redeem)...)
+	return append(s, opEqual)
+}
+
+// P2WSHScript returns the output script which pays to the hash of the witness script.
+func P2WSHScript(witness []byte) []byte {
+	h := sha256.Sum256(witness)
+	return append([]byte{op0, 32}, h[:]...)
+}
+
+// MultisigScripts are the scripts of a BitGo 2-of-3 multisig wallet address.
+type MultisigScripts struct {
+	// Output is the output script (scriptPubKey) which pays to the address.
+	Output []byte
+	// Redeem is the redeem script of P2SH and P2SH-P2WSH addresses, nil for P2WSH.
+	Redeem []byte
+	// Witness is the multisig witness script of P2SH-P2WSH and P2WSH addresses, nil for P2SH.
+	Witness []byte
+	// PubKeys are the public keys of the multisig script in the order of the xpubs.
+	PubKeys [][]byte
+}
+
+// DeriveMultisigScripts derives the scripts of the wallet address at the chain and index
+// from the user, backup and BitGo xpubs in this order, i.e., at the path m/0/0/chain/index.
+// ErrUnsupportedChain is returned for taproot and unknown chains.
+func DeriveMultisigScripts(xpubs []*ExtendedKey, chain Chain, index int) (*MultisigScripts, error) {
+	if !chain.IsValid() || chain.IsTaproot() || index < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedChain, chain)
+	}
+	if len(xpubs) != 3 {
+		return nil, fmt.Errorf("bitgo: expected user, backup and BitGo xpubs, got %d keys", len(xpubs))
+	}
+
+	var s MultisigScripts
+	path := []uint32{0, 0, uint32(chain), uint32(index)}
+	multisig := []byte{op1 + 1}
+	for _, k := range xpubs {
+		child, err := k.Derive(path)
+		if err != nil {
+			return nil, err
+		}
+		s.PubKeys = append(s.PubKeys, child.PublicKey[:])
+		multisig = append(append(multisig, 33), child.PublicKey[:]...)
+	}
+	multisig = append(multisig, op1+2, opCheckMultisig)
+
+	switch chain &^ 1 {
+	case ChainP2SH:
+		s.Redeem = multisig
+		s.Output = P2SHScript(multisig)
+	case ChainP2SHP2WSH:
+		s.Witness = multisig
+		s.Redeem = P2WSHScript(multisig)
+		s.Output = P2SHScript(s.Redeem)
+	default:
+		s.Witness = multisig
+		s.Output = P2WSHScript(multisig)
+	}
+	return &s, nil
+}
@@ -0,0 +1,142 @@
+package bitgo_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/marselester/bitgo-v2"
+)
+
+func TestScriptAddress(t *testing.T) {
+	tests := []struct {
+		coin       string
+		address    string
+		wantScript string
+	}{
+		{"btc", "1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu", "76a91476a04053bda0a88bda5177b86a15c3b29f55987388ac"},
+		{"btc", "3CWFddi6m4ndiGyKqzYvsFYagqDLPVMTzC", "a91476a04053bda0a88bda5177b86a15c3b29f55987387"},
+		{"btc", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", "0014751e76e8199196d454941c45d1b3a323f1433bd6"},
+		{"tbtc", "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", "00201863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262"},
+		{"btc", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", "512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"},
+		{"bch", "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", "76a91476a04053bda0a88bda5177b86a15c3b29f55987388ac"},
+		{"bch", "bitcoincash:ppm2qsznhks23z7629mms6s4cwef74vcwvn0h829pq", "a91476a04053bda0a88bda5177b86a15c3b29f55987387"},
+	}
+	for _, test := range tests {
+		a, err := bitgo.ParseAddress(test.coin, test.address)
+		if err != nil {
+			t.Errorf("%s %s: %v", test.coin, test.address, err)
+			continue
+		}
+		script, err := a.Script()
+		if err != nil {
+			t.Errorf("%s %s: %v", test.coin, test.address, err)
+			continue
+		}
+		if got := hex.EncodeToString(script); got != test.wantScript {
+			t.Errorf("%s %s: expected script %s, got %s", test.coin, test.address, test.wantScript, got)
+		}
+
+		b, err := bitgo.ScriptAddress(test.coin, script)
+		if err != nil {
+			t.Errorf("%s %s: %v", test.coin, test.address, err)
+			continue
+		}
+		if b.String() != test.address || b.Type != a.Type {
+			t.Errorf("%s %s: decoded %s %s", test.coin, test.address, b.Type, b)
+		}
+	}
+}
+
+func TestScriptAddressError(t *testing.T) {
+	tests := []struct {
+		coin    string
+		script  string
+		wantErr error
+	}{
+		// OP_RETURN data.
+		{"btc", "6a0568656c6c6f", bitgo.ErrNonstandardScript},
+		// Witness v0 program must be 20 or 32 bytes.
+		{"btc", "0010000102030405060708090a0b0c0d0e0f", bitgo.ErrNonstandardScript},
+		// BCH has no segwit addresses.
+		{"bch", "0014751e76e8199196d454941c45d1b3a323f1433bd6", bitgo.ErrNonstandardScript},
+		{"eth", "a91476a04053bda0a88bda5177b86a15c3b29f55987387", bitgo.ErrUnsupportedByCoin},
+	}
+	for _, test := range tests {
+		script, _ := hex.DecodeString(test.script)
+		if _, err := bitgo.ScriptAddress(test.coin, script); !errors.Is(err, test.wantErr) {
+			t.Errorf("%s %s: expected %v, got %v", test.coin, test.script, test.wantErr, err)
+		}
+	}
+}
+
+func TestP2WSHScript(t *testing.T) {
+	// BIP173 P2WSH example tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7 of <pubkey> OP_CHECKSIG.
+	witness, _ := hex.DecodeString("210279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798ac")
+	want := "00201863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262"
+	if got := hex.EncodeToString(bitgo.P2WSHScript(witness)); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestDeriveMultisigScripts(t *testing.T) {
+	// BIP32 test vector 1 public keys of m, m/0H, and m/0H/1 stand for the user, backup and BitGo xpubs.
+	var xpubs []*bitgo.ExtendedKey
+	for _, s := range []string{
+		"xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8",
+		"xpub68Gmy5EdvgibQVfPdqkBBCHxA5htiqg55crXYuXoQRKfDBFA1WEjWgP6LHhwBZeNK1VTsfTFUHCdrfp1bgwQ9xv5ski8PX9rL2dZXvgGDnw",
+		"xpub6ASuArnXKPbfEwhqN6e3mwBcDTgzisQN1wXN9BJcM47sSikHjJf3UFHKkNAWbWMiGj7Wf5uMash7SyYq527Hqck2AxYysAA7xmALppuCkwQ",
+	} {
+		k, err := bitgo.ParseExtendedKey(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		xpubs = append(xpubs, k)
+	}
+
+	for _, chain := range []bitgo.Chain{bitgo.ChainP2SH, bitgo.ChainP2SHP2WSHChange, bitgo.ChainP2WSH} {
+		s, err := bitgo.DeriveMultisigScripts(xpubs, chain, 7)
+		if err != nil {
+			t.Fatalf("chain %s: %v", chain, err)
+		}
+		multisig := []byte{0x52}
+		for i, k := range xpubs {
+			child, err := k.Derive([]uint32{0, 0, uint32(chain), 7})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(s.PubKeys[i], child.PublicKey[:]) {
+				t.Errorf("chain %s: unexpected public key %d %x", chain, i, s.PubKeys[i])
+			}
+			multisig = append(append(multisig, 33), child.PublicKey[:]...)
+		}
+		multisig = append(multisig, 0x53, 0xae)
+
+		var want bitgo.MultisigScripts
+		switch chain {
+		case bitgo.ChainP2SH:
+			want.Redeem = multisig
+			want.Output = bitgo.P2SHScript(multisig)
+		case bitgo.ChainP2SHP2WSHChange:
+			want.Redeem = bitgo.P2WSHScript(multisig)
+			want.Witness = multisig
+			want.Output = bitgo.P2SHScript(want.Redeem)
+		default:
+			want.Witness = multisig
+			want.Output = bitgo.P2WSHScript(multisig)
+		}
+		if !bytes.Equal(s.Redeem, want.Redeem) || !bytes.Equal(s.Witness, want.Witness) || !bytes.Equal(s.Output, want.Output) {
+			t.Errorf("chain %s: unexpected scripts %x %x %x", chain, s.Redeem, s.Witness, s.Output)
+		}
+	}
+
+	for _, chain := range []bitgo.Chain{bitgo.ChainP2TR, 12} {
+		if _, err := bitgo.DeriveMultisigScripts(xpubs, chain, 0); !errors.Is(err, bitgo.ErrUnsupportedChain) {
+			t.Errorf("chain %s: expected unsupported chain, got %v", chain, err)
+		}
+	}
+	if _, err := bitgo.DeriveMultisigScripts(xpubs[:2], bitgo.ChainP2WSH, 0); err == nil {
+		t.Error("expected three xpubs to be required")
+	}
+}
@@ -0,0 +1,105 @@
+package bitgo
+
+import (
+	"errors"
+	"math/big"
+)
+
+// secp256k1 curve y² = x³ + 7 over the prime field p with the generator g of order n.
+// Only the public key operations needed by BIP32 public derivation are implemented.
+var (
+	secpP   = hexInt("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f")
+	secpN   = hexInt("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141")
+	secpG   = point{x: hexInt("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"), y: hexInt("483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8")}
+	secpB   = big.NewInt(7)
+	sqrtExp = new(big.Int).Rsh(new(big.Int).Add(secpP, big.NewInt(1)), 2)
+)
+
+// errInvalidPoint is returned when a public key is not on the curve.
+var errInvalidPoint = errors.New("bitgo: public key is not on secp256k1 curve")
+
+// point is an affine point of the curve, the nil x is the point at infinity.
+type point struct {
+	x, y *big.Int
+}
+
+func hexInt(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 16)
+	return n
+}
+
+// decompress returns the point of the compressed public key.
+func decompress(b []byte) (point, error) {
+	if len(b) != 33 || b[0] != 2 && b[0] != 3 {
+		return point{}, errInvalidPoint
+	}
+	x := new(big.Int).SetBytes(b[1:])
+	if x.Cmp(secpP) >= 0 {
+		return point{}, errInvalidPoint
+	}
+	// y = sqrt(x³ + 7) which is (x³ + 7)^((p+1)/4) since p = 3 mod 4.
+	y2 := new(big.Int).Exp(x, big.NewInt(3), secpP)
+	y2.Add(y2, secpB).Mod(y2, secpP)
+	y := new(big.Int).Exp(y2, sqrtExp, secpP)
+	if new(big.Int).Exp(y, big.NewInt(2), secpP).Cmp(y2) != 0 {
+		return point{}, errInvalidPoint
+	}
+	if y.Bit(0) != uint(b[0]&1) {
+		y.Sub(secpP, y)
+	}
+	return point{x: x, y: y}, nil
+}
+
+// compress returns the compressed public key of the point.
+func (a point) compress() [33]byte {
+	var b [33]byte
+	b[0] = 2 + byte(a.y.Bit(0))
+	a.x.FillBytes(b[1:])
+	return b
+}
+
+// add returns a + b.
+func (a point) add(b point) point {
+	switch {
+	case a.x == nil:
+		return b
+	case b.x == nil:
+		return a
+	}
+
+	var slope *big.Int
+	if a.x.Cmp(b.x) == 0 {
+		if a.y.Cmp(b.y) != 0 || a.y.Sign() == 0 {
+			return point{}
+		}
+		// Tangent slope is 3x² / 2y.
+		num := new(big.Int).Mul(a.x, a.x)
+		num.Mul(num, big.NewInt(3))
+		den := new(big.Int).Lsh(a.y, 1)
+		slope = num.Mul(num, den.ModInverse(den, secpP))
+	} else {
+		num := new(big.Int).Sub(b.y, a.y)
+		den := new(big.Int).Sub(b.x, a.x)
+		den.Mod(den, secpP)
+		slope = num.Mul(num, den.ModInverse(den, secpP))
+	}
+	slope.Mod(slope, secpP)
+
+	x := new(big.Int).Mul(slope, slope)
+	x.Sub(x, a.x).Sub(x, b.x).Mod(x, secpP)
+	y := new(big.Int).Sub(a.x, x)
+	y.Mul(y, slope).Sub(y, a.y).Mod(y, secpP)
+	return point{x: x, y: y}
+}
+
+// scalarBaseMult returns k*G.
+func scalarBaseMult(k *big.Int) point {
+	var r point
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		r = r.add(r)
+		if k.Bit(i) == 1 {
+			r = r.add(secpG)
+		}
+	}
+	return r
+}
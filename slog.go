@@ -0,0 +1,85 @@
+package bitgo
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// SlogLogger is a Logger adapter for log/slog.
+// It maps "level" key to slog levels, "msg" key to a message, and the rest of key/value pairs to attributes.
+// Request context is passed to slog handler, so it can pick up its own request-scoped attributes.
+type SlogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger returns a Logger which writes to l.
+//
+//	h := slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})
+//	c := bitgo.NewClient(bitgo.WithLogger(bitgo.NewSlogLogger(slog.New(h))))
+func NewSlogLogger(l *slog.Logger) *SlogLogger {
+	return &SlogLogger{logger: l}
+}
+
+// Log implements Logger.
+func (l *SlogLogger) Log(keyvals ...interface{}) error {
+	return l.LogContext(context.Background(), keyvals...)
+}
+
+// Enabled implements LevelLogger.
+func (l *SlogLogger) Enabled(ctx context.Context, level string) bool {
+	return l.logger.Enabled(ctx, slogLevel(level))
+}
+
+// LogContext implements ContextLogger.
+func (l *SlogLogger) LogContext(ctx context.Context, keyvals ...interface{}) error {
+	level := slog.LevelInfo
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if keyvals[i] == "level" {
+			level = slogLevel(fmt.Sprint(keyvals[i+1]))
+			break
+		}
+	}
+	if !l.logger.Enabled(ctx, level) {
+		return nil
+	}
+
+	msg := ""
+	attrs := make([]slog.Attr, 0, len(keyvals)/2)
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		var val interface{} = "(MISSING)"
+		if i+1 < len(keyvals) {
+			val = keyvals[i+1]
+		}
+
+		switch key {
+		case "level":
+			continue
+		case "msg":
+			msg = fmt.Sprint(val)
+			continue
+		}
+		// Bodies are logged as bytes, they are more readable as strings.
+		if b, ok := val.([]byte); ok {
+			val = string(b)
+		}
+		attrs = append(attrs, slog.Any(key, val))
+	}
+
+	l.logger.LogAttrs(ctx, level, msg, attrs...)
+	return nil
+}
+
+// slogLevel converts level key value to slog level.
+func slogLevel(level string) slog.Level {
+	switch level {
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelError:
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
@@ -0,0 +1,85 @@
+package bitgo_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marselester/bitgo-v2"
+)
+
+func TestSlogLogger(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"too many requests"}`, http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	h := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})
+	c := bitgo.NewClient(
+		bitgo.WithBaseURL(srv.URL),
+		bitgo.WithProductionSpending(),
+		bitgo.WithLogger(bitgo.NewSlogLogger(slog.New(h))),
+	)
+	ctx := bitgo.WithLogValues(context.Background(), "correlation_id", "c0ffee")
+	if _, err := c.Wallet.Consolidate(ctx, "585951a5df8380e0e3063e9f", nil); err == nil {
+		t.Fatal("expected rate limit error")
+	}
+
+	// Debug events are filtered out, only the rate limit warning is left.
+	var event map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &event); err != nil {
+		t.Fatalf("expected a single log event: %v\n%s", err, buf.String())
+	}
+	want := map[string]string{
+		"level":          "WARN",
+		"msg":            "rate limited",
+		"wallet":         "585951a5df8380e0e3063e9f",
+		"correlation_id": "c0ffee",
+	}
+	for k, v := range want {
+		if event[k] != v {
+			t.Errorf("expected %s=%s, got %v", k, v, event[k])
+		}
+	}
+}
+
+// levelLogger records events and reports that debug level is disabled.
+type levelLogger struct {
+	events [][]interface{}
+}
+
+func (l *levelLogger) Log(keyvals ...interface{}) error {
+	l.events = append(l.events, keyvals)
+	return nil
+}
+
+func (l *levelLogger) Enabled(_ context.Context, level string) bool {
+	return level != bitgo.LevelDebug
+}
+
+func TestLevelLoggerSkipsDebug(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"unspents":[],"nextBatchPrevId":""}`))
+	}))
+	defer srv.Close()
+
+	l := levelLogger{}
+	c := bitgo.NewClient(
+		bitgo.WithBaseURL(srv.URL),
+		bitgo.WithLogger(&l),
+	)
+	if err := c.Wallet.Unspents(context.Background(), "585951a5df8380e0e3063e9f", nil, func(*bitgo.UnspentList) {}); err != nil {
+		t.Fatal(err)
+	}
+	// Debug events and their bodies are not prepared for the disabled level.
+	for _, e := range l.events {
+		if len(e) > 1 && e[1] == bitgo.LevelDebug {
+			t.Errorf("unexpected debug event %v", e)
+		}
+	}
+}
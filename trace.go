@@ -0,0 +1,140 @@
+package bitgo
+
+import (
+	"context"
+	"time"
+)
+
+// ClientTrace is a set of hooks to run at various stages of an API call,
+// in the spirit of net/http/httptrace.ClientTrace.
+// Any particular hook may be nil. Functions may be called concurrently
+// from different goroutines and some may be called after the request has completed or failed.
+//
+// A trace can be set for all requests with WithTrace config option
+// or for a single call with WithClientTrace context.
+type ClientTrace struct {
+	// RequestBuilt is called when NewRequest has created a request.
+	RequestBuilt func(RequestBuiltInfo)
+	// RequestSent is called right before a request is handed over to HTTP client.
+	RequestSent func(RequestSentInfo)
+	// ResponseReceived is called when response body was read or a request failed.
+	ResponseReceived func(ResponseReceivedInfo)
+	// DecodeFailed is called when a successful response can't be decoded.
+	DecodeFailed func(DecodeFailedInfo)
+	// RetryScheduled is called when a failed request is about to be retried (see WithRetry).
+	RetryScheduled func(RetryScheduledInfo)
+	// PageFetched is called when a page of results was fetched during pagination.
+	PageFetched func(PageFetchedInfo)
+}
+
+// RequestInfo is the request metadata passed to ClientTrace hooks.
+type RequestInfo struct {
+	Method string
+	// URL is the full request URL including query string with the sensitive params masked, see RedactURL.
+	URL string
+	// Coin is the digital currency the request is made for.
+	Coin string
+}
+
+// RequestBuiltInfo is passed to ClientTrace.RequestBuilt hook.
+type RequestBuiltInfo struct {
+	Request RequestInfo
+	// Start is when NewRequest was called.
+	Start time.Time
+	// Duration is how long it took to build the request (mostly JSON encoding).
+	Duration time.Duration
+	// BodySize is the size of JSON encoded body in bytes.
+	BodySize int
+}
+
+// RequestSentInfo is passed to ClientTrace.RequestSent hook.
+type RequestSentInfo struct {
+	Request RequestInfo
+	// Attempt is a request attempt number starting from 1.
+	Attempt int
+	// Start is when the request was sent.
+	Start time.Time
+}
+
+// ResponseReceivedInfo is passed to ClientTrace.ResponseReceived hook.
+type ResponseReceivedInfo struct {
+	Request RequestInfo
+	Attempt int
+	// StatusCode is zero when a request has failed before a response was received.
+	StatusCode int
+	// Latency is time passed since the request was sent till the response body was read.
+	Latency time.Duration
+	// Err is a transport error or an API error.
+	Err error
+}
+
+// DecodeFailedInfo is passed to ClientTrace.DecodeFailed hook.
+type DecodeFailedInfo struct {
+	Request    RequestInfo
+	StatusCode int
+	// Latency is time passed since the request was sent till decoding has failed.
+	Latency time.Duration
+	Err     error
+}
+
+// RetryScheduledInfo is passed to ClientTrace.RetryScheduled hook.
+type RetryScheduledInfo struct {
+	Request RequestInfo
+	// Attempt is a number of the attempt that has failed.
+	Attempt int
+	// Wait is how long the client waits before the next attempt.
+	Wait time.Duration
+	// Err is the reason of the retry.
+	Err error
+}
+
+// PageFetchedInfo is passed to ClientTrace.PageFetched hook.
+type PageFetchedInfo struct {
+	Request RequestInfo
+	// Page is a page number starting from 1.
+	Page int
+	// Items is a number of items in the page.
+	Items int
+	// NextBatchPrevID is a cursor of the next page, empty on the last page.
+	NextBatchPrevID string
+	// Latency is time passed since the page was requested till it was decoded.
+	Latency time.Duration
+}
+
+// clientTraceKey is a context key of a ClientTrace.
+type clientTraceKey struct{}
+
+// WithClientTrace returns a new context based on the provided parent ctx.
+// API calls made with the returned context will use the provided trace hooks
+// instead of the ones configured with WithTrace.
+func WithClientTrace(ctx context.Context, trace *ClientTrace) context.Context {
+	return context.WithValue(ctx, clientTraceKey{}, trace)
+}
+
+// ContextClientTrace returns the ClientTrace associated with the provided context.
+// If none, it returns nil.
+func ContextClientTrace(ctx context.Context) *ClientTrace {
+	trace, _ := ctx.Value(clientTraceKey{}).(*ClientTrace)
+	return trace
+}
+
+// trace returns hooks for ctx: either from the context or the client config.
+// It never returns nil so the hooks can be checked without nil trace check.
+func (c *Client) trace(ctx context.Context) *ClientTrace {
+	if t := ContextClientTrace(ctx); t != nil {
+		return t
+	}
+	if c.config.trace != nil {
+		return c.config.trace
+	}
+	return &ClientTrace{}
+}
+
+// requestInfo returns trace metadata of the request.
+func (c *Client) requestInfo(method, urlStr string) RequestInfo {
+	return RequestInfo{
+		Method: method,
+		URL:    c.config.logURL(urlStr),
+		Coin:   c.config.coin,
+	}
+}
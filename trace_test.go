@@ -0,0 +1,107 @@
+package bitgo_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/marselester/bitgo-v2"
+)
+
+func TestClientTrace(t *testing.T) {
+	content, err := ioutil.ReadFile(filepath.Join("testdata", "unspents.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(content)
+	}))
+	defer srv.Close()
+
+	var (
+		mu     sync.Mutex
+		events []string
+		page   bitgo.PageFetchedInfo
+	)
+	record := func(event string) {
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}
+	trace := &bitgo.ClientTrace{
+		RequestBuilt:     func(bitgo.RequestBuiltInfo) { record("built") },
+		RequestSent:      func(bitgo.RequestSentInfo) { record("sent") },
+		ResponseReceived: func(bitgo.ResponseReceivedInfo) { record("received") },
+		DecodeFailed:     func(bitgo.DecodeFailedInfo) { record("decode failed") },
+		PageFetched: func(info bitgo.PageFetchedInfo) {
+			record("page")
+			page = info
+		},
+	}
+
+	c := bitgo.NewClient(
+		bitgo.WithBaseURL(srv.URL),
+		bitgo.WithTrace(trace),
+	)
+	// Hooks see the URL with the sensitive params masked.
+	params := url.Values{"passphrase": {"swordfish"}}
+	err = c.Wallet.Unspents(context.Background(), "58ae81a5df8380e0e307e876", params, func(*bitgo.UnspentList) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"built", "sent", "received", "page"}
+	if len(events) != len(want) {
+		t.Fatalf("expected %v events, got %v", want, events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Fatalf("expected %v events, got %v", want, events)
+		}
+	}
+	if page.Page != 1 || page.Items != 1 || page.Request.Method != http.MethodGet || page.Request.Coin != "btc" {
+		t.Errorf("unexpected page info %#v", page)
+	}
+	if strings.Contains(page.Request.URL, "swordfish") || !strings.Contains(page.Request.URL, "passphrase=") {
+		t.Errorf("expected passphrase to be masked in %s", page.Request.URL)
+	}
+}
+
+func TestClientTraceRetry(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			http.Error(w, "server is overloaded", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"unspents":[]}`))
+	}))
+	defer srv.Close()
+
+	var retries []bitgo.RetryScheduledInfo
+	ctx := bitgo.WithClientTrace(context.Background(), &bitgo.ClientTrace{
+		RetryScheduled: func(info bitgo.RetryScheduledInfo) {
+			retries = append(retries, info)
+		},
+	})
+	c := bitgo.NewClient(
+		bitgo.WithBaseURL(srv.URL),
+		bitgo.WithRetry(2, 0),
+	)
+	if err := c.Wallet.Unspents(ctx, "", nil, func(*bitgo.UnspentList) {}); err != nil {
+		t.Fatal(err)
+	}
+	if len(retries) != 2 {
+		t.Fatalf("expected 2 retries, got %d", len(retries))
+	}
+	if retries[1].Attempt != 2 {
+		t.Errorf("expected second attempt, got %d", retries[1].Attempt)
+	}
+}
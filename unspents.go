@@ -0,0 +1,104 @@
+package bitgo
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// UnspentsParams represents API parameters used to filter unspents of a wallet.
+// For more details, see https://www.bitgo.com/api/v2/#list-wallet-unspents.
+//
+//	params := bitgo.UnspentsParams{MinConfirms: 1, MaxValue: bitgo.NewAmount(100000)}
+//	if err := params.Validate(); err != nil {
+//		return err
+//	}
+//	err := c.Wallet.Unspents(ctx, walletID, params.Values(), f)
+type UnspentsParams struct {
+	// Continue iterating unspents from this ID as provided by nextBatchPrevId in the previous list.
+	PrevID string
+	// Ignore unspents smaller than this amount of satoshis.
+	MinValue Amount
+	// Ignore unspents larger than this amount of satoshis.
+	MaxValue Amount
+	// Ignore unspents confirmed at a lower block height than the given height.
+	MinHeight int64
+	// Ignore unspents that have fewer than the given confirmations.
+	MinConfirms int
+	// Return only unspents on these chains, e.g., ChainP2SH and ChainP2SHChange for P2SH unspents.
+	Chains []Chain
+	// Return only segwit (true) or non-segwit (false) unspents, nil means both.
+	Segwit *bool
+	// Return only unspents with these IDs (txid:vout).
+	UnspentIDs []string
+	// Max number of unspents in a page.
+	Limit int
+}
+
+// Validate checks that the params can be combined.
+func (p *UnspentsParams) Validate() error {
+	switch {
+	case p.MinValue.Sign() < 0:
+		return errors.New("bitgo: minValue must not be negative")
+	case p.MaxValue.Sign() < 0:
+		return errors.New("bitgo: maxValue must not be negative")
+	case p.MaxValue.Sign() > 0 && p.MinValue.Cmp(p.MaxValue) > 0:
+		return fmt.Errorf("bitgo: minValue %s is greater than maxValue %s", p.MinValue, p.MaxValue)
+	case p.MinHeight < 0:
+		return errors.New("bitgo: minHeight must not be negative")
+	case p.MinConfirms < 0:
+		return errors.New("bitgo: minConfirms must not be negative")
+	case p.Limit < 0:
+		return errors.New("bitgo: limit must not be negative")
+	}
+
+	for _, chain := range p.Chains {
+		if !chain.IsValid() {
+			return fmt.Errorf("bitgo: unknown chain %d", chain)
+		}
+		if p.Segwit != nil && *p.Segwit != chain.IsSegwit() {
+			return fmt.Errorf("bitgo: chain %d contradicts segwit=%t", chain, *p.Segwit)
+		}
+	}
+	for _, id := range p.UnspentIDs {
+		if id == "" {
+			return errors.New("bitgo: unspent ID must not be empty")
+		}
+	}
+	return nil
+}
+
+// Values encodes the params into query params. Zero values are omitted.
+// The params are expected to be validated with Validate.
+func (p *UnspentsParams) Values() url.Values {
+	v := url.Values{}
+	if p.PrevID != "" {
+		v.Set("prevId", p.PrevID)
+	}
+	if p.MinValue.Sign() > 0 {
+		v.Set("minValue", p.MinValue.String())
+	}
+	if p.MaxValue.Sign() > 0 {
+		v.Set("maxValue", p.MaxValue.String())
+	}
+	if p.MinHeight > 0 {
+		v.Set("minHeight", strconv.FormatInt(p.MinHeight, 10))
+	}
+	if p.MinConfirms > 0 {
+		v.Set("minConfirms", strconv.Itoa(p.MinConfirms))
+	}
+	for _, chain := range p.Chains {
+		v.Add("chains", strconv.Itoa(int(chain)))
+	}
+	if p.Segwit != nil {
+		v.Set("segwit", strconv.FormatBool(*p.Segwit))
+	}
+	for _, id := range p.UnspentIDs {
+		v.Add("unspentIds", id)
+	}
+	if p.Limit > 0 {
+		v.Set("limit", strconv.Itoa(p.Limit))
+	}
+	return v
+}
@@ -0,0 +1,59 @@
+package bitgo_test
+
+import (
+	"testing"
+
+	"github.com/marselester/bitgo-v2"
+)
+
+func TestUnspentsParamsValidate(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name    string
+		params  bitgo.UnspentsParams
+		wantErr bool
+	}{
+		{"empty", bitgo.UnspentsParams{}, false},
+		{"value range", bitgo.UnspentsParams{MinValue: bitgo.NewAmount(1000), MaxValue: bitgo.NewAmount(100000)}, false},
+		{"min value only", bitgo.UnspentsParams{MinValue: bitgo.NewAmount(1000)}, false},
+		{"inverted value range", bitgo.UnspentsParams{MinValue: bitgo.NewAmount(100000), MaxValue: bitgo.NewAmount(1000)}, true},
+		{"negative confirms", bitgo.UnspentsParams{MinConfirms: -1}, true},
+		{"unknown chain", bitgo.UnspentsParams{Chains: []bitgo.Chain{2}}, true},
+		{"segwit chains", bitgo.UnspentsParams{Chains: []bitgo.Chain{10, 20}, Segwit: &yes}, false},
+		{"non-segwit chain with segwit", bitgo.UnspentsParams{Chains: []bitgo.Chain{0}, Segwit: &yes}, true},
+		{"segwit chain without segwit", bitgo.UnspentsParams{Chains: []bitgo.Chain{11}, Segwit: &no}, true},
+		{"empty unspent ID", bitgo.UnspentsParams{UnspentIDs: []string{""}}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.params.Validate()
+			if (err != nil) != test.wantErr {
+				t.Errorf("expected error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestUnspentsParamsValues(t *testing.T) {
+	segwit := true
+	params := bitgo.UnspentsParams{
+		PrevID:      "952ac7fd9c1a5df8380e0e305fac8b42db:0",
+		MinValue:    bitgo.NewAmount(1000),
+		MaxValue:    bitgo.NewAmount(100000),
+		MinHeight:   500000,
+		MinConfirms: 1,
+		Chains:      []bitgo.Chain{10, 11},
+		Segwit:      &segwit,
+		UnspentIDs:  []string{"952ac7fd9c1a5df8380e0e305fac8b42db:1"},
+		Limit:       500,
+	}
+	want := "chains=10&chains=11&limit=500&maxValue=100000&minConfirms=1&minHeight=500000&minValue=1000" +
+		"&prevId=952ac7fd9c1a5df8380e0e305fac8b42db%3A0&segwit=true&unspentIds=952ac7fd9c1a5df8380e0e305fac8b42db%3A1"
+	if got := params.Values().Encode(); got != want {
+		t.Errorf("should be %s, not %s", want, got)
+	}
+
+	if got := (&bitgo.UnspentsParams{}).Values().Encode(); got != "" {
+		t.Errorf("expected empty query, got %s", got)
+	}
+}
@@ -0,0 +1,293 @@
+// Package verify checks a transaction prebuilt by BitGo against the payment intent before it is signed,
+// so a compromised proxy between the client and BitGo Express can't redirect the coins.
+//
+// Every recipient must be paid exactly by an output, the other outputs must pay to addresses
+// derived from the wallet xpubs (change and consolidation outputs), and the fee must not exceed the maximum.
+// P2SH inputs are refused by default, since their signatures don't commit to the spent values the fee is priced from.
+// The xpubs must come from a trusted source, e.g., saved when the wallet was created,
+// since the proxy could also forge the keychains it serves.
+//
+//	intent := verify.Intent{
+//		Recipients: params.Recipients,
+//		MaxFee:     bitgo.NewAmount(20000),
+//		Keys:       xpubs,
+//	}
+//	err := verify.Prebuild(ctx, client, walletID, prebuild, &intent)
+//	var verr *verify.Error
+//	if errors.As(err, &verr) {
+//		log.Printf("output %d to %s: %v", verr.Output, verr.Address, verr.Err)
+//	}
+package verify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/marselester/bitgo-v2"
+	"github.com/marselester/bitgo-v2/rawtx"
+)
+
+// Verification failures are wrapped in Error.
+var (
+	// ErrRecipientMissing means no output pays to the recipient address.
+	ErrRecipientMissing = errors.New("verify: recipient is not paid")
+	// ErrAmountMismatch means the output pays to the recipient address a different amount.
+	ErrAmountMismatch = errors.New("verify: recipient amount mismatch")
+	// ErrUnexpectedOutput means the output pays neither a recipient nor a wallet address.
+	ErrUnexpectedOutput = errors.New("verify: unexpected output")
+	// ErrChangeMismatch means the output address is not derived from the wallet xpubs
+	// at the chain and index BitGo reported.
+	ErrChangeMismatch = errors.New("verify: wallet address mismatch")
+	// ErrUnsupportedChain means the wallet address can't be derived, e.g., a taproot address,
+	// or the input spends an unspent of an unknown chain.
+	ErrUnsupportedChain = errors.New("verify: unsupported wallet address chain")
+	// ErrFeeTooHigh means the transaction fee exceeds Intent.MaxFee.
+	ErrFeeTooHigh = errors.New("verify: fee is too high")
+	// ErrNegativeFee means the outputs pay more than the reported values of the spent unspents.
+	ErrNegativeFee = errors.New("verify: negative fee")
+	// ErrLegacyInput means the input spends a P2SH unspent whose value the signature doesn't commit to,
+	// see Intent.AllowLegacyInputs.
+	ErrLegacyInput = errors.New("verify: legacy input")
+)
+
+// Error describes what didn't match the intent.
+type Error struct {
+	// Err is ErrRecipientMissing, ErrAmountMismatch, ErrUnexpectedOutput, ErrChangeMismatch,
+	// ErrUnsupportedChain, ErrFeeTooHigh, ErrNegativeFee, ErrLegacyInput,
+	// or rawtx.ErrUnknownInput if an input is not among the prebuild unspents.
+	Err error
+	// Output is the index of the offending transaction output, -1 if the error is not about an output.
+	Output int
+	// Input is the index of the offending transaction input for ErrLegacyInput, rawtx.ErrUnknownInput,
+	// and ErrUnsupportedChain if Output is -1.
+	Input int
+	// Address is the recipient or output address, empty for fee errors and nonstandard output scripts.
+	Address string
+	// Want is the recipient amount or the max fee.
+	Want bitgo.Amount
+	// Got is the output value, the fee, or the value of the offending input.
+	Got bitgo.Amount
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Err == ErrRecipientMissing:
+		return fmt.Sprintf("%v: no output pays %s to %s", e.Err, e.Want, e.Address)
+	case e.Err == ErrAmountMismatch:
+		return fmt.Sprintf("%v: output %d pays %s to %s, want %s", e.Err, e.Output, e.Got, e.Address, e.Want)
+	case e.Err == ErrFeeTooHigh:
+		return fmt.Sprintf("%v: fee %s exceeds max fee %s", e.Err, e.Got, e.Want)
+	case e.Err == ErrNegativeFee:
+		return fmt.Sprintf("%v: outputs exceed the inputs, fee is %s", e.Err, e.Got)
+	case e.Err == ErrLegacyInput:
+		return fmt.Sprintf("%v: input %d spends %s from a P2SH address", e.Err, e.Input, e.Got)
+	case e.Err == ErrUnsupportedChain && e.Output < 0:
+		return fmt.Sprintf("%v: input %d spends %s from an unknown chain", e.Err, e.Input, e.Got)
+	case e.Err == rawtx.ErrUnknownInput:
+		return fmt.Sprintf("%v: input %d is not among the prebuild unspents", e.Err, e.Input)
+	case e.Address == "":
+		return fmt.Sprintf("%v: output %d pays %s to a nonstandard script", e.Err, e.Output, e.Got)
+	}
+	return fmt.Sprintf("%v: output %d pays %s to %s", e.Err, e.Output, e.Got, e.Address)
+}
+
+// Unwrap returns the underlying error, so errors.Is(err, ErrFeeTooHigh) works.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Intent is what the prebuilt transaction is expected to do.
+type Intent struct {
+	// Recipients must be paid the exact amounts, e.g., SendManyParams.Recipients.
+	// Outputs BitGo adds on its own such as a PayGo fee must be listed here too, otherwise they are unexpected.
+	Recipients []bitgo.Recipient
+	// MaxFee is the maximum fee in base units the transaction may pay, it is required.
+	MaxFee bitgo.Amount
+	// Keys are the trusted user, backup and BitGo xpubs of the wallet in this order.
+	Keys []*bitgo.ExtendedKey
+	// AllowLegacyInputs accepts P2SH inputs whose values are reported by BitGo, but not signed.
+	// Set it only if the signer checks the values in the previous transactions,
+	// e.g., a PSBT with non-witness UTXOs fetched from a trusted node.
+	AllowLegacyInputs bool
+}
+
+// Prebuild verifies the prebuilt transaction of the wallet against the intent.
+// Outputs which don't pay to the recipients are looked up as the wallet addresses,
+// and then their addresses are derived from the intent xpubs, see Tx.
+func Prebuild(ctx context.Context, c *bitgo.Client, walletID string, prebuild *bitgo.TxPrebuild, intent *Intent) error {
+	coin := c.CoinInfo().Ticker
+	tx, err := rawtx.DecodeString(coin, prebuild.TxHex)
+	if err != nil {
+		return err
+	}
+	recipients, err := recipientScripts(coin, intent.Recipients)
+	if err != nil {
+		return err
+	}
+
+	var addresses []bitgo.WalletAddress
+	for _, out := range tx.Outputs {
+		if out.Address == nil || hasScript(recipients, out.Script) {
+			continue
+		}
+		a, err := c.Wallet.Address(ctx, walletID, out.Address.String())
+		// The output is reported as unexpected if the address doesn't belong to the wallet.
+		if errors.Is(err, bitgo.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		addresses = append(addresses, *a)
+	}
+	return Tx(coin, prebuild, intent, addresses)
+}
+
+// Tx verifies the prebuilt transaction of the coin against the intent.
+// The addresses are the wallet addresses BitGo reported for the outputs that don't pay the recipients,
+// they are not trusted: an output is accepted only if its address is derived from the intent xpubs
+// at the reported chain and index.
+//
+// The fee is calculated from the values of the prebuild unspents which are not trusted either.
+// Segwit signatures commit to the input values, so a signature over a misreported value is invalid,
+// but legacy P2SH ones don't, hence P2SH inputs are refused unless Intent.AllowLegacyInputs is set.
+// An unspent which claims a segwit chain for a P2SH address can't be spent with a segwit signature.
+func Tx(coin string, prebuild *bitgo.TxPrebuild, intent *Intent, addresses []bitgo.WalletAddress) error {
+	if intent.MaxFee.Sign() <= 0 {
+		return errors.New("verify: max fee is required")
+	}
+	if len(intent.Keys) != 3 {
+		return fmt.Errorf("verify: expected user, backup and BitGo xpubs, got %d keys", len(intent.Keys))
+	}
+	tx, err := rawtx.DecodeString(coin, prebuild.TxHex)
+	if err != nil {
+		return err
+	}
+	recipients, err := recipientScripts(coin, intent.Recipients)
+	if err != nil {
+		return err
+	}
+
+	// Outputs paying the exact amounts are matched first,
+	// so a recipient listed twice isn't reported because of the other's amount.
+	paid := make([]bool, len(tx.Outputs))
+	matched := make([]bool, len(recipients))
+	for i, r := range intent.Recipients {
+		for j, out := range tx.Outputs {
+			if !paid[j] && bytes.Equal(out.Script, recipients[i]) && out.Value.Cmp(r.Amount) == 0 {
+				paid[j], matched[i] = true, true
+				break
+			}
+		}
+	}
+	for i, r := range intent.Recipients {
+		if matched[i] {
+			continue
+		}
+		for j, out := range tx.Outputs {
+			if !paid[j] && bytes.Equal(out.Script, recipients[i]) {
+				return &Error{Err: ErrAmountMismatch, Output: j, Address: r.Address, Want: r.Amount, Got: out.Value}
+			}
+		}
+		return &Error{Err: ErrRecipientMissing, Output: -1, Address: r.Address, Want: r.Amount}
+	}
+
+	for i, out := range tx.Outputs {
+		if paid[i] {
+			continue
+		}
+		if err = walletOutput(coin, intent.Keys, addresses, i, &out); err != nil {
+			return err
+		}
+	}
+
+	unspents := make(map[bitgo.Outpoint]*bitgo.Unspent, len(prebuild.TxInfo.Unspents))
+	for i, u := range prebuild.TxInfo.Unspents {
+		if o, err := u.Outpoint(); err == nil {
+			unspents[o] = &prebuild.TxInfo.Unspents[i]
+		}
+	}
+	var inputs bitgo.Amount
+	for i, in := range tx.Inputs {
+		u, ok := unspents[in.Outpoint]
+		if !ok {
+			return &Error{Err: rawtx.ErrUnknownInput, Output: -1, Input: i}
+		}
+		// An unspent of an unknown chain is refused even if legacy inputs are allowed.
+		if !u.Chain.IsValid() {
+			return &Error{Err: ErrUnsupportedChain, Output: -1, Input: i, Got: u.Value}
+		}
+		if !u.Chain.IsSegwit() && !intent.AllowLegacyInputs {
+			return &Error{Err: ErrLegacyInput, Output: -1, Input: i, Got: u.Value}
+		}
+		inputs = inputs.Add(u.Value)
+	}
+	fee := inputs.Sub(tx.OutputValue())
+	if fee.Sign() < 0 {
+		return &Error{Err: ErrNegativeFee, Output: -1, Got: fee}
+	}
+	if fee.Cmp(intent.MaxFee) > 0 {
+		return &Error{Err: ErrFeeTooHigh, Output: -1, Want: intent.MaxFee, Got: fee}
+	}
+	return nil
+}
+
+// walletOutput checks that the output pays to one of the wallet addresses derived from the keys.
+func walletOutput(coin string, keys []*bitgo.ExtendedKey, addresses []bitgo.WalletAddress, i int, out *rawtx.Output) error {
+	verr := Error{Err: ErrUnexpectedOutput, Output: i, Got: out.Value}
+	if out.Address == nil {
+		return &verr
+	}
+	verr.Address = out.Address.String()
+
+	for _, a := range addresses {
+		parsed, err := bitgo.ParseAddress(coin, a.Address)
+		if err != nil {
+			continue
+		}
+		if script, err := parsed.Script(); err != nil || !bytes.Equal(script, out.Script) {
+			continue
+		}
+
+		scripts, err := bitgo.DeriveMultisigScripts(keys, a.Chain, a.Index)
+		switch {
+		case errors.Is(err, bitgo.ErrUnsupportedChain):
+			verr.Err = ErrUnsupportedChain
+		case err != nil:
+			return fmt.Errorf("verify: output %d: %w", i, err)
+		case bytes.Equal(scripts.Output, out.Script):
+			return nil
+		default:
+			verr.Err = ErrChangeMismatch
+		}
+		return &verr
+	}
+	return &verr
+}
+
+// recipientScripts returns the output scripts of the recipients.
+func recipientScripts(coin string, recipients []bitgo.Recipient) ([][]byte, error) {
+	scripts := make([][]byte, len(recipients))
+	for i, r := range recipients {
+		a, err := bitgo.ParseAddress(coin, r.Address)
+		if err != nil {
+			return nil, err
+		}
+		if scripts[i], err = a.Script(); err != nil {
+			return nil, err
+		}
+	}
+	return scripts, nil
+}
+
+// hasScript reports whether the script is among the scripts.
+func hasScript(scripts [][]byte, script []byte) bool {
+	for _, s := range scripts {
+		if bytes.Equal(s, script) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,274 @@
+package verify_test
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/marselester/bitgo-v2"
+	"github.com/marselester/bitgo-v2/rawtx"
+	"github.com/marselester/bitgo-v2/verify"
+)
+
+// BIP32 test vector 1 public keys of m, m/0H, and m/0H/1 stand for the user, backup and BitGo xpubs.
+var xpubs = []string{
+	"xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8",
+	"xpub68Gmy5EdvgibQVfPdqkBBCHxA5htiqg55crXYuXoQRKfDBFA1WEjWgP6LHhwBZeNK1VTsfTFUHCdrfp1bgwQ9xv5ski8PX9rL2dZXvgGDnw",
+	"xpub6ASuArnXKPbfEwhqN6e3mwBcDTgzisQN1wXN9BJcM47sSikHjJf3UFHKkNAWbWMiGj7Wf5uMash7SyYq527Hqck2AxYysAA7xmALppuCkwQ",
+}
+
+const recipient = "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7"
+
+// payment is a prebuilt transaction which pays 60000 to the recipient
+// and 39000 to the P2SH-P2WSH change address 0/0/11/5 of the wallet spending a 100000 unspent.
+type payment struct {
+	keys     []*bitgo.ExtendedKey
+	change   bitgo.WalletAddress
+	prebuild bitgo.TxPrebuild
+}
+
+func newPayment(t *testing.T) *payment {
+	var p payment
+	for _, s := range xpubs {
+		k, err := bitgo.ParseExtendedKey(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.keys = append(p.keys, k)
+	}
+	scripts, err := bitgo.DeriveMultisigScripts(p.keys, bitgo.ChainP2SHP2WSHChange, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changeScript := scripts.Output
+	change, err := bitgo.ScriptAddress("tbtc", changeScript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.change = bitgo.WalletAddress{Address: change.String(), Chain: bitgo.ChainP2SHP2WSHChange, Index: 5}
+
+	to, err := bitgo.ParseAddress("tbtc", recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	toScript, _ := to.Script()
+	unspent := bitgo.Unspent{ID: strings.Repeat("11", 32) + ":0", Value: bitgo.NewAmount(100000), Chain: bitgo.ChainP2WSH}
+	o, _ := unspent.Outpoint()
+	tx := rawtx.Tx{
+		Coin:    "tbtc",
+		Version: 2,
+		Inputs:  []rawtx.Input{{Outpoint: o, Sequence: 0xfffffffd}},
+		Outputs: []rawtx.Output{
+			{Value: bitgo.NewAmount(60000), Script: toScript},
+			{Value: bitgo.NewAmount(39000), Script: changeScript},
+		},
+	}
+	raw, err := tx.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.prebuild = bitgo.TxPrebuild{
+		TxHex:  hex.EncodeToString(raw),
+		TxInfo: bitgo.PrebuildInfo{Unspents: []bitgo.Unspent{unspent}, ChangeAddresses: []string{p.change.Address}},
+	}
+	return &p
+}
+
+func (p *payment) intent() *verify.Intent {
+	return &verify.Intent{
+		Recipients: []bitgo.Recipient{{Address: recipient, Amount: bitgo.NewAmount(60000)}},
+		MaxFee:     bitgo.NewAmount(1000),
+		Keys:       p.keys,
+	}
+}
+
+func TestTx(t *testing.T) {
+	p := newPayment(t)
+	if err := verify.Tx("tbtc", &p.prebuild, p.intent(), []bitgo.WalletAddress{p.change}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		modify  func(intent *verify.Intent, change *bitgo.WalletAddress)
+		want    error
+		output  int
+		address string
+	}{
+		"amount": {
+			modify: func(intent *verify.Intent, _ *bitgo.WalletAddress) {
+				intent.Recipients[0].Amount = bitgo.NewAmount(50000)
+			},
+			want:    verify.ErrAmountMismatch,
+			address: recipient,
+		},
+		"recipient": {
+			modify: func(intent *verify.Intent, _ *bitgo.WalletAddress) {
+				intent.Recipients = append(intent.Recipients, bitgo.Recipient{Address: "2N8hwP1WmJrFF5QWABn38y63uYLhnJYJYTF", Amount: bitgo.NewAmount(1)})
+			},
+			want:    verify.ErrRecipientMissing,
+			output:  -1,
+			address: "2N8hwP1WmJrFF5QWABn38y63uYLhnJYJYTF",
+		},
+		"change index": {
+			modify: func(_ *verify.Intent, change *bitgo.WalletAddress) {
+				change.Index = 6
+			},
+			want:    verify.ErrChangeMismatch,
+			output:  1,
+			address: "change",
+		},
+		"change keys": {
+			modify: func(intent *verify.Intent, _ *bitgo.WalletAddress) {
+				intent.Keys = []*bitgo.ExtendedKey{intent.Keys[1], intent.Keys[0], intent.Keys[2]}
+			},
+			want:    verify.ErrChangeMismatch,
+			output:  1,
+			address: "change",
+		},
+		"unknown change": {
+			modify: func(_ *verify.Intent, change *bitgo.WalletAddress) {
+				change.Address = recipient
+			},
+			want:    verify.ErrUnexpectedOutput,
+			output:  1,
+			address: "change",
+		},
+		"taproot change": {
+			modify: func(_ *verify.Intent, change *bitgo.WalletAddress) {
+				change.Chain = bitgo.ChainP2TRChange
+			},
+			want:    verify.ErrUnsupportedChain,
+			output:  1,
+			address: "change",
+		},
+		"fee": {
+			modify: func(intent *verify.Intent, _ *bitgo.WalletAddress) {
+				intent.MaxFee = bitgo.NewAmount(999)
+			},
+			want:   verify.ErrFeeTooHigh,
+			output: -1,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			intent, change := p.intent(), p.change
+			test.modify(intent, &change)
+			err := verify.Tx("tbtc", &p.prebuild, intent, []bitgo.WalletAddress{change})
+			if !errors.Is(err, test.want) {
+				t.Fatalf("expected %v, got %v", test.want, err)
+			}
+
+			var verr *verify.Error
+			if !errors.As(err, &verr) {
+				t.Fatalf("expected verify.Error, got %T", err)
+			}
+			if test.address == "change" {
+				test.address = p.change.Address
+			}
+			if verr.Output != test.output || verr.Address != test.address {
+				t.Errorf("unexpected error %+v", verr)
+			}
+		})
+	}
+
+	intent := p.intent()
+	intent.MaxFee = bitgo.Amount{}
+	if err := verify.Tx("tbtc", &p.prebuild, intent, nil); err == nil {
+		t.Error("expected max fee to be required")
+	}
+}
+
+func TestTxInputs(t *testing.T) {
+	p := newPayment(t)
+	unspent := p.prebuild.TxInfo.Unspents[0]
+	tests := map[string]struct {
+		unspents []bitgo.Unspent
+		want     error
+	}{
+		"unknown": {
+			want: rawtx.ErrUnknownInput,
+		},
+		"legacy": {
+			unspents: []bitgo.Unspent{{ID: unspent.ID, Value: unspent.Value, Chain: bitgo.ChainP2SH}},
+			want:     verify.ErrLegacyInput,
+		},
+		"forged chain": {
+			unspents: []bitgo.Unspent{{ID: unspent.ID, Value: unspent.Value, Chain: 12}},
+			want:     verify.ErrUnsupportedChain,
+		},
+		"negative fee": {
+			unspents: []bitgo.Unspent{{ID: unspent.ID, Value: bitgo.NewAmount(98999), Chain: unspent.Chain}},
+			want:     verify.ErrNegativeFee,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			prebuild := p.prebuild
+			prebuild.TxInfo.Unspents = test.unspents
+			err := verify.Tx("tbtc", &prebuild, p.intent(), []bitgo.WalletAddress{p.change})
+			if !errors.Is(err, test.want) {
+				t.Fatalf("expected %v, got %v", test.want, err)
+			}
+			var verr *verify.Error
+			if !errors.As(err, &verr) {
+				t.Fatalf("expected verify.Error, got %T", err)
+			}
+			if verr.Output != -1 || verr.Input != 0 {
+				t.Errorf("unexpected error %+v", verr)
+			}
+		})
+	}
+
+	prebuild := p.prebuild
+	prebuild.TxInfo.Unspents = []bitgo.Unspent{{ID: unspent.ID, Value: unspent.Value, Chain: bitgo.ChainP2SH}}
+	intent := p.intent()
+	intent.AllowLegacyInputs = true
+	if err := verify.Tx("tbtc", &prebuild, intent, []bitgo.WalletAddress{p.change}); err != nil {
+		t.Errorf("expected legacy input to be allowed, got %v", err)
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	err := &verify.Error{Err: verify.ErrAmountMismatch, Output: 0, Address: recipient, Want: bitgo.NewAmount(60000), Got: bitgo.NewAmount(6000)}
+	want := "verify: recipient amount mismatch: output 0 pays 6000 to " + recipient + ", want 60000"
+	if err.Error() != want {
+		t.Errorf("expected %q, got %q", want, err)
+	}
+
+	err = &verify.Error{Err: verify.ErrLegacyInput, Output: -1, Input: 1, Got: bitgo.NewAmount(100000)}
+	want = "verify: legacy input: input 1 spends 100000 from a P2SH address"
+	if err.Error() != want {
+		t.Errorf("expected %q, got %q", want, err)
+	}
+
+	err = &verify.Error{Err: verify.ErrUnsupportedChain, Output: -1, Input: 0, Got: bitgo.NewAmount(100000)}
+	want = "verify: unsupported wallet address chain: input 0 spends 100000 from an unknown chain"
+	if err.Error() != want {
+		t.Errorf("expected %q, got %q", want, err)
+	}
+}
+
+func TestPrebuild(t *testing.T) {
+	p := newPayment(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v2/tbtc/wallet/585951a5df8380e0e3063e9f/address/" + p.change.Address:
+			json.NewEncoder(w).Encode(p.change)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not found","name":"NotFound"}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := bitgo.NewClient(bitgo.WithBaseURL(srv.URL), bitgo.WithCoin("tbtc"))
+	if err := verify.Prebuild(context.Background(), c, "585951a5df8380e0e3063e9f", &p.prebuild, p.intent()); err != nil {
+		t.Fatal(err)
+	}
+}
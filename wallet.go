@@ -2,9 +2,12 @@ package bitgo
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 // walletService communicates with the wallet API endpoints.
@@ -12,7 +15,7 @@ type walletService struct {
 	client *Client
 }
 
-// TxInfo is a response we get from consolidateunspents API endpoint.
+// TxInfo is a response we get from consolidateunspents and sendmany API endpoints.
 type TxInfo struct {
 	// TxID is an id of the transaction.
 	TxID string `json:"txid"`
@@ -32,15 +35,16 @@ type WalletConsolidateParams struct {
 	// Number of unspents to select (defaults to 25, max is 200).
 	Limit int `json:"limit,omitempty"`
 	// Ignore unspents smaller than this amount of satoshis.
-	MinValue int64 `json:"minValue,omitempty"`
+	MinValue Amount `json:"minValue"`
 	// Ignore unspents larger than this amount of satoshis.
-	MaxValue int64 `json:"maxValue,omitempty"`
+	MaxValue Amount `json:"maxValue"`
 	// The minimum height of unspents on the block chain to use.
 	MinHeight int `json:"minHeight,omitempty"`
 	// The desired fee rate for the transaction in satoshis/KB.
 	FeeRate int `json:"feeRate,omitempty"`
 	// Fee rate is automatically chosen by targeting a transaction confirmation
-	// in this number of blocks (only available on BTC, FeeRate takes precedence if also set).
+	// in this number of blocks (only available on coins with CoinInfo.FeeTargeting such as BTC,
+	// FeeRate takes precedence if also set).
 	FeeTxConfirmTarget int `json:"feeTxConfirmTarget,omitempty"`
 	// Maximum percentage of an unspent's value to be used for fees. Cannot be combined with MinValue.
 	MaxFeePercentage int `json:"maxFeePercentage,omitempty"`
@@ -48,10 +52,60 @@ type WalletConsolidateParams struct {
 	MinConfirms int `json:"minConfirms,omitempty"`
 	// Apply the required confirmations set in MinConfirms for change outputs.
 	EnforceMinConfirmsForChange bool `json:"enforceMinConfirmsForChange,omitempty"`
+	// Unspent IDs (txid:vout) to consolidate instead of letting BitGo select among all the wallet's unspents,
+	// e.g., chosen by coinselect package. The other filters and Limit still apply to them.
+	Unspents []string `json:"unspents,omitempty"`
+}
+
+// Validate checks that the pinned unspent IDs are outpoints.
+func (p *WalletConsolidateParams) Validate() error {
+	for _, id := range p.Unspents {
+		if _, err := ParseOutpoint(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarshalJSON omits zero MinValue and MaxValue, so BitGo applies no value filter.
+// The omitzero tag isn't used because it is ignored before Go 1.24.
+func (p WalletConsolidateParams) MarshalJSON() ([]byte, error) {
+	type params WalletConsolidateParams
+	v := struct {
+		params
+		MinValue *Amount `json:"minValue,omitempty"`
+		MaxValue *Amount `json:"maxValue,omitempty"`
+	}{params: params(p)}
+	if !p.MinValue.IsZero() {
+		v.MinValue = &p.MinValue
+	}
+	if !p.MaxValue.IsZero() {
+		v.MaxValue = &p.MaxValue
+	}
+	return json.Marshal(v)
 }
 
 // Consolidate coalesces UTXOs currently held in a wallet to a smaller number.
+// In a mainnet environment it requires WithProductionSpending option.
+// The params are checked with WalletConsolidateParams.Validate and WalletConsolidateParams.ValidateCoin.
 func (s *walletService) Consolidate(ctx context.Context, walletID string, bodyParams *WalletConsolidateParams) (*TxInfo, error) {
+	if s.client.err != nil {
+		return nil, s.client.err
+	}
+	if err := s.client.config.checkSpending(); err != nil {
+		return nil, err
+	}
+	params := bodyParams
+	if params == nil {
+		params = &WalletConsolidateParams{}
+	}
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	if err := params.ValidateCoin(s.client.config.coinInfo); err != nil {
+		return nil, err
+	}
+	ctx = WithLogValues(ctx, "wallet", walletID)
 	path := fmt.Sprintf("wallet/%s/consolidateunspents", walletID)
 	req, err := s.client.NewRequest(ctx, http.MethodPost, path, nil, bodyParams)
 	if err != nil {
@@ -63,33 +117,402 @@ func (s *walletService) Consolidate(ctx context.Context, walletID string, bodyPa
 	return &tx, err
 }
 
+// Recipient is an address and an amount of coins to send to it.
+type Recipient struct {
+	// Address of the recipient.
+	Address string `json:"address"`
+	// Amount in base units, e.g., satoshis.
+	Amount Amount `json:"amount"`
+}
+
+// SendManyParams represents API parameters used when sending coins to multiple recipients.
+// For more details, see https://www.bitgo.com/api/v2/#send-to-many.
+type SendManyParams struct {
+	// Recipients of the transaction.
+	Recipients []Recipient `json:"recipients"`
+	// Passphrase to decrypt the wallet's private key.
+	WalletPassphrase string `json:"walletPassphrase,omitempty"`
+	// The desired fee rate for the transaction in satoshis/KB.
+	FeeRate int `json:"feeRate,omitempty"`
+	// Fee rate is automatically chosen by targeting a transaction confirmation
+	// in this number of blocks (only available on coins with CoinInfo.FeeTargeting such as BTC,
+	// FeeRate takes precedence if also set).
+	FeeTxConfirmTarget int `json:"feeTxConfirmTarget,omitempty"`
+	// The maximum fee rate in satoshis/KB BitGo may choose with FeeTxConfirmTarget.
+	MaxFeeRate int `json:"maxFeeRate,omitempty"`
+	// The required number of confirmations for each transaction input.
+	MinConfirms int `json:"minConfirms,omitempty"`
+	// Apply the required confirmations set in MinConfirms for change outputs.
+	EnforceMinConfirmsForChange bool `json:"enforceMinConfirmsForChange,omitempty"`
+	// Unspent IDs (txid:vout) to spend instead of letting BitGo select the inputs,
+	// e.g., chosen by coinselect package.
+	Unspents []string `json:"unspents,omitempty"`
+	// Optional metadata stored with the transfer.
+	Comment string `json:"comment,omitempty"`
+}
+
+// Validate checks that the params describe a payment.
+func (p *SendManyParams) Validate() error {
+	if len(p.Recipients) == 0 {
+		return errors.New("bitgo: at least one recipient is required")
+	}
+	for _, r := range p.Recipients {
+		if r.Address == "" {
+			return errors.New("bitgo: recipient address must not be empty")
+		}
+		if r.Amount.Sign() <= 0 {
+			return fmt.Errorf("bitgo: recipient %s amount must be positive", r.Address)
+		}
+	}
+	for _, id := range p.Unspents {
+		if _, err := ParseOutpoint(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SendMany sends coins to multiple recipients in a single transaction.
+// In a mainnet environment it requires WithProductionSpending option.
+// The params are checked with SendManyParams.Validate and SendManyParams.ValidateCoin,
+// so a mistyped or wrong network recipient address is reported with AddressError before anything is sent.
+func (s *walletService) SendMany(ctx context.Context, walletID string, bodyParams *SendManyParams) (*TxInfo, error) {
+	if s.client.err != nil {
+		return nil, s.client.err
+	}
+	if err := s.client.config.checkSpending(); err != nil {
+		return nil, err
+	}
+	if bodyParams == nil {
+		return nil, errors.New("bitgo: send params are required")
+	}
+	if err := bodyParams.Validate(); err != nil {
+		return nil, err
+	}
+	if err := bodyParams.ValidateCoin(s.client.config.coinInfo); err != nil {
+		return nil, err
+	}
+	ctx = WithLogValues(ctx, "wallet", walletID)
+	path := fmt.Sprintf("wallet/%s/sendmany", walletID)
+	req, err := s.client.NewRequest(ctx, http.MethodPost, path, nil, bodyParams)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := TxInfo{}
+	_, err = s.client.Do(req, &tx)
+	return &tx, err
+}
+
+// TxPrebuild is a transaction built by BitGo but not signed yet, see Prebuild.
+type TxPrebuild struct {
+	// TxHex is the serialized unsigned transaction.
+	TxHex string `json:"txHex"`
+	// TxInfo describes the transaction inputs.
+	TxInfo PrebuildInfo `json:"txInfo"`
+	// FeeInfo describes the estimated fee.
+	FeeInfo FeeInfo `json:"feeInfo"`
+	// WalletID is the id of the wallet the transaction spends from.
+	WalletID string `json:"walletId"`
+}
+
+// PrebuildInfo describes the inputs and outputs of a prebuilt transaction.
+type PrebuildInfo struct {
+	// Number of P2SH inputs.
+	NP2SHInputs int `json:"nP2SHInputs"`
+	// Number of segwit inputs.
+	NSegwitInputs int `json:"nSegwitInputs"`
+	// Number of outputs.
+	NOutputs int `json:"nOutputs"`
+	// The unspents spent by the transaction with their redeem and witness scripts.
+	Unspents []Unspent `json:"unspents"`
+	// Addresses of the wallet's change outputs.
+	ChangeAddresses []string `json:"changeAddresses"`
+}
+
+// FeeInfo is the fee BitGo estimated for a prebuilt transaction.
+type FeeInfo struct {
+	// Estimated size of the signed transaction in bytes.
+	Size int `json:"size"`
+	// Fee in satoshis.
+	Fee Amount `json:"fee"`
+	// Fee rate in satoshis/KB.
+	FeeRate int `json:"feeRate"`
+	// BitGo fee in satoshis paid by an output of the transaction.
+	PayGoFee Amount `json:"payGoFee"`
+}
+
+// Prebuild builds the transaction SendMany would send, but leaves the signing to the caller,
+// e.g., an offline signer of the psbt package.
+// WalletPassphrase of the params is not sent.
+func (s *walletService) Prebuild(ctx context.Context, walletID string, bodyParams *SendManyParams) (*TxPrebuild, error) {
+	if s.client.err != nil {
+		return nil, s.client.err
+	}
+	if bodyParams == nil {
+		return nil, errors.New("bitgo: prebuild params are required")
+	}
+	if err := bodyParams.Validate(); err != nil {
+		return nil, err
+	}
+	if err := bodyParams.ValidateCoin(s.client.config.coinInfo); err != nil {
+		return nil, err
+	}
+	params := *bodyParams
+	params.WalletPassphrase = ""
+	ctx = WithLogValues(ctx, "wallet", walletID)
+	path := fmt.Sprintf("wallet/%s/tx/build", walletID)
+	req, err := s.client.NewRequest(ctx, http.MethodPost, path, nil, &params)
+	if err != nil {
+		return nil, err
+	}
+
+	prebuild := TxPrebuild{}
+	_, err = s.client.Do(req, &prebuild)
+	return &prebuild, err
+}
+
+// SubmitParams represents API parameters used when sending a transaction signed by the caller.
+// For more details, see https://www.bitgo.com/api/v2/#send-transaction.
+type SubmitParams struct {
+	// HalfSigned is the transaction signed by the user key, BitGo adds its signature.
+	HalfSigned HalfSignedTx `json:"halfSigned"`
+	// Optional metadata stored with the transfer.
+	Comment string `json:"comment,omitempty"`
+}
+
+// HalfSignedTx is a transaction signed with one of the wallet keys.
+type HalfSignedTx struct {
+	// TxHex is the serialized transaction.
+	TxHex string `json:"txHex"`
+}
+
+// Submit sends the half-signed transaction to BitGo which co-signs and broadcasts it.
+// In a mainnet environment it requires WithProductionSpending option.
+func (s *walletService) Submit(ctx context.Context, walletID string, bodyParams *SubmitParams) (*TxInfo, error) {
+	if s.client.err != nil {
+		return nil, s.client.err
+	}
+	if err := s.client.config.checkSpending(); err != nil {
+		return nil, err
+	}
+	if bodyParams == nil || bodyParams.HalfSigned.TxHex == "" {
+		return nil, errors.New("bitgo: half-signed transaction is required")
+	}
+	ctx = WithLogValues(ctx, "wallet", walletID)
+	path := fmt.Sprintf("wallet/%s/tx/send", walletID)
+	req, err := s.client.NewRequest(ctx, http.MethodPost, path, nil, bodyParams)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := TxInfo{}
+	_, err = s.client.Do(req, &tx)
+	return &tx, err
+}
+
+// Wallet is a multisig wallet.
+type Wallet struct {
+	// The id of the wallet.
+	ID string `json:"id"`
+	// Coin of the wallet, e.g., "btc".
+	Coin string `json:"coin"`
+	// Label is a name of the wallet.
+	Label string `json:"label"`
+	// Keys are the ids of the user, backup and BitGo keychains in this order.
+	Keys []string `json:"keys"`
+	// Number of signatures required to spend.
+	M int `json:"m"`
+	// Number of the wallet keys.
+	N int `json:"n"`
+}
+
+// Get fetches the wallet.
+func (s *walletService) Get(ctx context.Context, walletID string) (*Wallet, error) {
+	if s.client.err != nil {
+		return nil, s.client.err
+	}
+	ctx = WithLogValues(ctx, "wallet", walletID)
+	req, err := s.client.NewRequest(ctx, http.MethodGet, "wallet/"+walletID, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	w := Wallet{}
+	_, err = s.client.Do(req, &w)
+	return &w, err
+}
+
+// Keychain is a public part of a wallet key.
+type Keychain struct {
+	// The id of the keychain.
+	ID string `json:"id"`
+	// Pub is the extended public key (xpub), see ParseExtendedKey.
+	Pub string `json:"pub"`
+	// Source is "user", "backup" or "bitgo".
+	Source string `json:"source"`
+}
+
+// Keychains fetches the wallet keychains in the order of Wallet.Keys: user, backup and BitGo.
+// The order matches the public keys of the wallet's multisig scripts.
+func (s *walletService) Keychains(ctx context.Context, walletID string) ([]Keychain, error) {
+	w, err := s.Get(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = WithLogValues(ctx, "wallet", walletID)
+	keys := make([]Keychain, len(w.Keys))
+	for i, id := range w.Keys {
+		req, err := s.client.NewRequest(ctx, http.MethodGet, "key/"+id, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = s.client.Do(req, &keys[i]); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// WalletAddress is an address of a wallet and where it is derived from.
+type WalletAddress struct {
+	// The id of the address.
+	ID string `json:"id"`
+	// Address is the encoded address, e.g., "2MvrwRYBAuRtPTiZ5MyKg42Ke55W3fZJfZS".
+	Address string `json:"address"`
+	// Chain defines the script type of the address and whether it receives change.
+	Chain Chain `json:"chain"`
+	// Index of the address on the chain.
+	Index int `json:"index"`
+	// Coin of the address, e.g., "btc".
+	Coin string `json:"coin"`
+	// Wallet is the id of the wallet the address belongs to.
+	Wallet string `json:"wallet"`
+}
+
+// DerivationPath returns the path of the address keys relative to the wallet xpubs (0/0/chain/index).
+func (a *WalletAddress) DerivationPath() []uint32 {
+	return []uint32{0, 0, uint32(a.Chain), uint32(a.Index)}
+}
+
+// Address fetches the wallet address, e.g., a change address of a prebuilt transaction.
+// The chain and index must not be trusted without deriving the address from the wallet xpubs.
+func (s *walletService) Address(ctx context.Context, walletID, address string) (*WalletAddress, error) {
+	if s.client.err != nil {
+		return nil, s.client.err
+	}
+	ctx = WithLogValues(ctx, "wallet", walletID)
+	path := fmt.Sprintf("wallet/%s/address/%s", walletID, url.PathEscape(address))
+	req, err := s.client.NewRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	a := WalletAddress{}
+	_, err = s.client.Do(req, &a)
+	return &a, err
+}
+
 // Unspent is an unspent transaction output (UTXO).
 type Unspent struct {
-	// The outpoint of the unspent (txid:vout). For example, "952ac7fd9c1a5df8380e0e305fac8b42db:0".
+	// The outpoint of the unspent (txid:vout). For example, "5885a7e6c7802206f69655ed763d14f101cf46501aef38e275c67c72cfcedb75:0".
 	ID string
 	// The address that owns this unspent.
 	Address string
 	// Value of the unspent in satoshis.
-	Value int64
-	// The height of the block that created this unspent.
+	Value Amount
+	// The height of the block that created this unspent, UnconfirmedHeight if it is not confirmed yet.
 	BlockHeight int64
-	// The date the unspent was created.
-	Date string
+	// The date the unspent was created, zero if BitGo sent an empty or invalid date.
+	Date time.Time
+	// RawDate is the date as BitGo sent it when it couldn't be parsed.
+	RawDate string `json:"-"`
 	// The id of the wallet the unspent is in.
 	Wallet string
 	// The id of the wallet the unspent came from (if it was sent from a BitGo wallet you're a member on)
 	FromWallet string
 	// The address type and derivation path of the unspent
 	// (0 = normal unspent, 1 = change unspent, 10 = segwit unspent, 11 = segwit change unspent).
-	Chain int
+	Chain Chain
 	// The position of the address in this chain's derivation path.
 	Index int
 	// The script defining the criteria to be satisfied to spend this unspent.
 	RedeemScript string
+	// The witness script of a segwit unspent, BitGo sends it in transaction prebuilds.
+	WitnessScript string
 	// A flag indicating whether this is a segwit unspent.
 	IsSegwit bool
 }
 
+// UnconfirmedHeight is a block height BitGo reports for unconfirmed unspents.
+const UnconfirmedHeight = 999999999
+
+// UnmarshalJSON decodes the unspent tolerating an empty or invalid date,
+// so that one odd unspent doesn't fail the whole page.
+// An invalid date is kept in RawDate.
+func (u *Unspent) UnmarshalJSON(b []byte) error {
+	type unspent Unspent
+	v := struct {
+		*unspent
+		Date string
+	}{unspent: (*unspent)(u)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	u.Date, u.RawDate = time.Time{}, ""
+	if v.Date == "" {
+		return nil
+	}
+	date, err := time.Parse(time.RFC3339, v.Date)
+	if err != nil {
+		u.RawDate = v.Date
+		return nil
+	}
+	u.Date = date
+	return nil
+}
+
+// MarshalJSON encodes the unspent so that it is decoded back by UnmarshalJSON as is:
+// a zero date is encoded as an empty string, and an invalid one as RawDate.
+func (u Unspent) MarshalJSON() ([]byte, error) {
+	type unspent Unspent
+	v := struct {
+		unspent
+		Date string
+	}{unspent: unspent(u), Date: u.RawDate}
+	if !u.Date.IsZero() {
+		v.Date = u.Date.Format(time.RFC3339Nano)
+	}
+	return json.Marshal(v)
+}
+
+// Outpoint parses the unspent ID into the transaction ID and output index.
+func (u *Unspent) Outpoint() (Outpoint, error) {
+	return ParseOutpoint(u.ID)
+}
+
+// DerivationPath returns the BIP32 path of the unspent's address keys relative to the wallet keychains,
+// i.e., m/0/0/chain/index.
+func (u *Unspent) DerivationPath() []uint32 {
+	return []uint32{0, 0, uint32(u.Chain), uint32(u.Index)}
+}
+
+// IsConfirmed reports whether the unspent is included in a block.
+func (u *Unspent) IsConfirmed() bool {
+	return u.BlockHeight > 0 && u.BlockHeight != UnconfirmedHeight
+}
+
+// Confirmations returns a number of confirmations of the unspent given the chain tip height.
+// It is zero for unconfirmed unspents, and one when the unspent is in the tip block.
+func (u *Unspent) Confirmations(tip int64) int64 {
+	if !u.IsConfirmed() || tip < u.BlockHeight {
+		return 0
+	}
+	return tip - u.BlockHeight + 1
+}
+
 // ListMeta is a pagination metadata.
 type ListMeta struct {
 	// Can be used to iterate the next batch of results.
@@ -108,27 +531,133 @@ type UnspentList struct {
 // It invokes f for each page of results.
 // You can filter unspents using query parameters as described in the docs
 // https://www.bitgo.com/api/v2/#list-wallet-unspents.
+// The query parameters are not modified.
+// Use UnspentsIter to stop iteration early or resume it from a cursor.
+// Iteration fails with PaginationError when the server repeats a cursor,
+// returns an empty page with a cursor, or more pages than WithMaxPages allows.
+// With WithPrefetch option the next pages are fetched while f handles the current one.
+// Account based coins have no unspents, so ErrUnsupportedByCoin is returned.
 func (s *walletService) Unspents(ctx context.Context, walletID string, queryParams url.Values, f func(*UnspentList)) error {
-	path := fmt.Sprintf("wallet/%s/unspents", walletID)
-
-	for {
-		req, err := s.client.NewRequest(ctx, http.MethodGet, path, queryParams, nil)
+	if s.client.err != nil {
+		return s.client.err
+	}
+	if err := s.client.config.requireUTXO("unspents listing"); err != nil {
+		return err
+	}
+	ctx = WithLogValues(ctx, "wallet", walletID)
+	cursor := queryParams.Get("prevId")
+	guard := pageGuard{maxPages: s.client.config.maxPages}
+	fetch := func(ctx context.Context, page int, cursor string) (*UnspentList, string, error) {
+		if err := guard.beforeFetch(page, cursor); err != nil {
+			return nil, "", err
+		}
+		v, err := s.unspentsPage(ctx, walletID, queryParams, 0, page, cursor)
 		if err != nil {
-			return err
+			return nil, "", err
+		}
+		if err = guard.afterFetch(page, cursor, len(v.Unspents), v.NextBatchPrevID); err != nil {
+			return nil, "", err
 		}
+		return v, v.NextBatchPrevID, nil
+	}
+
+	if n := s.client.config.prefetch; n > 0 {
+		ctx, cancel := context.WithCancel(WithClientTrace(ctx, s.client.trace(ctx)))
+		defer cancel()
+		for pg := range prefetch(ctx, n, 0, cursor, fetch) {
+			pg.hooks.deliver()
+			if pg.err != nil {
+				return pg.err
+			}
+			f(pg.page)
+
+			if pg.next == "" {
+				return nil
+			}
+		}
+		// The prefetching stopped before the last page because the context is done.
+		return ctx.Err()
+	}
 
-		v := UnspentList{}
-		_, err = s.client.Do(req, &v)
+	for page := 1; ; page++ {
+		v, next, err := fetch(ctx, page, cursor)
 		if err != nil {
 			return err
 		}
-		f(&v)
+		f(v)
 
-		if v.NextBatchPrevID == "" {
+		if next == "" {
 			break
 		}
-		queryParams.Set("prevId", v.NextBatchPrevID)
+		cursor = next
 	}
 
 	return nil
 }
+
+// UnspentsIter returns a Paginator over unspent transaction outputs (UTXOs) of a wallet.
+// Unspents can be filtered using query parameters, see Unspents.
+// The query parameters are not modified.
+func (s *walletService) UnspentsIter(ctx context.Context, walletID string, queryParams url.Values, opts *ListOptions) *Paginator[Unspent] {
+	if s.client.err != nil {
+		return errPaginator[Unspent](s.client.err)
+	}
+	if err := s.client.config.requireUTXO("unspents listing"); err != nil {
+		return errPaginator[Unspent](err)
+	}
+	if opts == nil {
+		opts = &ListOptions{}
+	}
+	ctx = WithLogValues(ctx, "wallet", walletID)
+	cursor := opts.Cursor
+	if cursor == "" {
+		cursor = queryParams.Get("prevId")
+	}
+
+	maxPages := opts.MaxPages
+	if maxPages == 0 {
+		maxPages = s.client.config.maxPages
+	}
+	prefetch := opts.Prefetch
+	if prefetch == 0 {
+		prefetch = s.client.config.prefetch
+	}
+
+	if prefetch > 0 {
+		// The prefetching goroutine defers the client's trace hooks till the pages are received.
+		ctx = WithClientTrace(ctx, s.client.trace(ctx))
+	}
+	return newPaginator(ctx, cursor, maxPages, prefetch, func(ctx context.Context, page int, cursor string) ([]Unspent, string, error) {
+		v, err := s.unspentsPage(ctx, walletID, queryParams, opts.PageSize, page, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		return v.Unspents, v.NextBatchPrevID, nil
+	})
+}
+
+// unspentsPage fetches a page of unspents which starts after the cursor.
+func (s *walletService) unspentsPage(ctx context.Context, walletID string, queryParams url.Values, pageSize, page int, cursor string) (*UnspentList, error) {
+	start := time.Now()
+	path := fmt.Sprintf("wallet/%s/unspents", walletID)
+	params := listParams(queryParams, pageSize, "prevId", cursor)
+	req, err := s.client.NewRequest(ctx, http.MethodGet, path, params, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	v := UnspentList{}
+	if _, err = s.client.Do(req, &v); err != nil {
+		return nil, err
+	}
+	if trace := s.client.trace(ctx); trace.PageFetched != nil {
+		trace.PageFetched(PageFetchedInfo{
+			Request:         s.client.requestInfo(req.Method, req.URL.String()),
+			Page:            page,
+			Items:           len(v.Unspents),
+			NextBatchPrevID: v.NextBatchPrevID,
+			Latency:         time.Since(start),
+		})
+	}
+	return &v, nil
+}
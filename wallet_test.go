@@ -2,11 +2,15 @@ package bitgo_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/marselester/bitgo-v2"
 )
@@ -30,6 +34,7 @@ func TestConsolidate(t *testing.T) {
 
 	c := bitgo.NewClient(
 		bitgo.WithBaseURL(srv.URL),
+		bitgo.WithProductionSpending(),
 	)
 	tx, err := c.Wallet.Consolidate(context.Background(), "", nil)
 	if err != nil {
@@ -40,6 +45,27 @@ func TestConsolidate(t *testing.T) {
 	}
 }
 
+func TestWalletConsolidateParamsJSON(t *testing.T) {
+	tests := []struct {
+		params bitgo.WalletConsolidateParams
+		want   string
+	}{
+		{bitgo.WalletConsolidateParams{}, `{}`},
+		{bitgo.WalletConsolidateParams{Limit: 200, FeeRate: 1000}, `{"limit":200,"feeRate":1000}`},
+		{bitgo.WalletConsolidateParams{MaxValue: bitgo.NewAmount(1000)}, `{"maxValue":1000}`},
+		{bitgo.WalletConsolidateParams{MinValue: bitgo.NewAmount(546), MaxValue: bitgo.NewAmount(1000)}, `{"minValue":546,"maxValue":1000}`},
+	}
+	for _, test := range tests {
+		b, err := json.Marshal(&test.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != test.want {
+			t.Errorf("expected %s, got %s", test.want, b)
+		}
+	}
+}
+
 func TestUnspents(t *testing.T) {
 	filename := filepath.Join("testdata", "unspents.json")
 	content, err := ioutil.ReadFile(filename)
@@ -49,9 +75,9 @@ func TestUnspents(t *testing.T) {
 	want := bitgo.Unspent{
 		ID:           "952ac7fd9c1a5df8380e0e305fac8b42db:0",
 		Address:      "2NEqutgZ741a5df8380e0e30gkrM9vAyn3",
-		Value:        203125000,
+		Value:        bitgo.NewAmount(203125000),
 		BlockHeight:  999999999,
-		Date:         "2017-02-23T06:59:21.538Z",
+		Date:         time.Date(2017, 2, 23, 6, 59, 21, 538000000, time.UTC),
 		Wallet:       "58ae81a5df8380e0e307e876",
 		FromWallet:   "",
 		Chain:        0,
@@ -101,3 +127,198 @@ func BenchmarkUnspents(b *testing.B) {
 		c.Wallet.Unspents(context.Background(), "", nil, f)
 	}
 }
+
+func TestUnspentJSON(t *testing.T) {
+	content, err := ioutil.ReadFile(filepath.Join("testdata", "unspents.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var list bitgo.UnspentList
+	if err = json.Unmarshal(content, &list); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got bitgo.UnspentList
+	if err = json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Unspents[0] != list.Unspents[0] {
+		t.Fatalf("should be %#v, not %#v", list.Unspents[0], got.Unspents[0])
+	}
+
+	var u bitgo.Unspent
+	if err = json.Unmarshal([]byte(`{"id":"ab:1","date":"","chain":20}`), &u); err != nil {
+		t.Fatal(err)
+	}
+	if !u.Date.IsZero() || u.Chain != bitgo.ChainP2WSH {
+		t.Errorf("unexpected unspent %#v", u)
+	}
+	// Zero and invalid dates round-trip.
+	for _, in := range []string{`{"date":""}`, `{"date":"yesterday"}`} {
+		if err = json.Unmarshal([]byte(in), &u); err != nil {
+			t.Fatal(err)
+		}
+		if b, err = json.Marshal(u); err != nil {
+			t.Fatal(err)
+		}
+		var got bitgo.Unspent
+		if err = json.Unmarshal(b, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got != u || !got.Date.IsZero() {
+			t.Errorf("%s: should be %#v, not %#v", in, u, got)
+		}
+	}
+	if u.RawDate != "yesterday" {
+		t.Errorf("expected invalid date to be kept, got %q", u.RawDate)
+	}
+}
+
+func TestUnspentConfirmations(t *testing.T) {
+	tests := []struct {
+		height    int64
+		tip       int64
+		confirmed bool
+		want      int64
+	}{
+		{bitgo.UnconfirmedHeight, 500000, false, 0},
+		{0, 500000, false, 0},
+		{500000, 500000, true, 1},
+		{499995, 500000, true, 6},
+		{500001, 500000, true, 0},
+	}
+	for _, test := range tests {
+		u := bitgo.Unspent{BlockHeight: test.height}
+		if u.IsConfirmed() != test.confirmed || u.Confirmations(test.tip) != test.want {
+			t.Errorf("height %d, tip %d: expected confirmed %t with %d confirmations, got %t with %d",
+				test.height, test.tip, test.confirmed, test.want, u.IsConfirmed(), u.Confirmations(test.tip))
+		}
+	}
+
+	u := bitgo.Unspent{ID: "5885a7e6c7802206f69655ed763d14f101cf46501aef38e275c67c72cfcedb75:7"}
+	op, err := u.Outpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if op.TxID != "5885a7e6c7802206f69655ed763d14f101cf46501aef38e275c67c72cfcedb75" || op.Vout != 7 || op.String() != u.ID {
+		t.Errorf("unexpected outpoint %#v", op)
+	}
+	// The txid of "ab:0" is valid hex, but it's not 32 bytes long.
+	txid := strings.Repeat("ab", 32)
+	for _, id := range []string{"", txid, ":0", "xyz:0", "ab:0", txid + ":-1", txid + ":4294967296"} {
+		if _, err = bitgo.ParseOutpoint(id); err == nil {
+			t.Errorf("expected %q outpoint error", id)
+		}
+	}
+}
+
+func TestSendManyValidation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL)
+	}))
+	defer srv.Close()
+
+	tbtc := bitgo.NewClient(bitgo.WithBaseURL(srv.URL), bitgo.WithCoin("tbtc"))
+	recipient := func(address string) []bitgo.Recipient {
+		return []bitgo.Recipient{{Address: address, Amount: bitgo.NewAmount(10000)}}
+	}
+	tests := []struct {
+		client *bitgo.Client
+		params *bitgo.SendManyParams
+		want   error
+	}{
+		{tbtc, &bitgo.SendManyParams{Recipients: recipient("1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu")}, bitgo.ErrAddressNetwork},
+		{tbtc, &bitgo.SendManyParams{Recipients: recipient("tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k8")}, bitgo.ErrAddressChecksum},
+		{
+			tbtc.Coin("hteth"),
+			&bitgo.SendManyParams{Recipients: recipient("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"), Unspents: []string{strings.Repeat("ab", 32) + ":0"}},
+			bitgo.ErrUnsupportedByCoin,
+		},
+	}
+	for _, test := range tests {
+		_, err := test.client.Wallet.SendMany(context.Background(), "", test.params)
+		if !errors.Is(err, test.want) {
+			t.Errorf("%+v: expected %v, got %v", test.params, test.want, err)
+		}
+	}
+
+	invalid := []*bitgo.SendManyParams{
+		nil,
+		{},
+		{Recipients: []bitgo.Recipient{{Address: "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7"}}},
+		{Recipients: recipient("tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7"), Unspents: []string{"ab"}},
+	}
+	for _, params := range invalid {
+		if _, err := tbtc.Wallet.SendMany(context.Background(), "", params); err == nil {
+			t.Errorf("%+v: expected validation error", params)
+		}
+	}
+}
+
+func TestPrebuildAndSubmit(t *testing.T) {
+	const xpub = "xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if r.Method == http.MethodPost {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "POST /api/v2/tbtc/wallet/585951a5df8380e0e3063e9f/tx/build":
+			if _, ok := body["walletPassphrase"]; ok {
+				t.Error("passphrase is sent to prebuild")
+			}
+			w.Write([]byte(`{"txHex":"0100","txInfo":{"nP2SHInputs":1,"unspents":[{"id":"ab:0","chain":10,"witnessScript":"52ae"}]},"feeInfo":{"size":300,"fee":1500,"feeRate":5000}}`))
+		case "POST /api/v2/tbtc/wallet/585951a5df8380e0e3063e9f/tx/send":
+			if body["halfSigned"].(map[string]interface{})["txHex"] != "0200" {
+				t.Errorf("unexpected body %v", body)
+			}
+			w.Write([]byte(`{"txid":"cd","tx":"0300","status":"signed"}`))
+		case "GET /api/v2/tbtc/wallet/585951a5df8380e0e3063e9f":
+			w.Write([]byte(`{"id":"585951a5df8380e0e3063e9f","keys":["u","b","g"],"m":2,"n":3}`))
+		case "GET /api/v2/tbtc/key/u", "GET /api/v2/tbtc/key/b", "GET /api/v2/tbtc/key/g":
+			w.Write([]byte(`{"id":"` + r.URL.Path[len(r.URL.Path)-1:] + `","pub":"` + xpub + `"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := bitgo.NewClient(bitgo.WithBaseURL(srv.URL), bitgo.WithCoin("tbtc"))
+	params := bitgo.SendManyParams{
+		Recipients:       []bitgo.Recipient{{Address: "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", Amount: bitgo.NewAmount(10000)}},
+		WalletPassphrase: "root",
+	}
+	prebuild, err := c.Wallet.Prebuild(ctx, "585951a5df8380e0e3063e9f", &params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prebuild.TxHex != "0100" || prebuild.TxInfo.Unspents[0].WitnessScript != "52ae" || prebuild.FeeInfo.Fee.Cmp(bitgo.NewAmount(1500)) != 0 {
+		t.Errorf("unexpected prebuild %+v", prebuild)
+	}
+
+	keys, err := c.Wallet.Keychains(ctx, "585951a5df8380e0e3063e9f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 3 || keys[0].ID != "u" || keys[2].ID != "g" || keys[1].Pub != xpub {
+		t.Errorf("unexpected keychains %+v", keys)
+	}
+
+	tx, err := c.Wallet.Submit(ctx, "585951a5df8380e0e3063e9f", &bitgo.SubmitParams{HalfSigned: bitgo.HalfSignedTx{TxHex: "0200"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.TxID != "cd" {
+		t.Errorf("unexpected tx %+v", tx)
+	}
+	if _, err = c.Wallet.Submit(ctx, "585951a5df8380e0e3063e9f", &bitgo.SubmitParams{}); err == nil {
+		t.Error("expected missing transaction error")
+	}
+}